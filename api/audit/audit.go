@@ -0,0 +1,277 @@
+package audit
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"service_hp/routes/middleware"
+	"time"
+)
+
+// Aksi umum, aksi khusus (mis. password.reset, servis.status) ditulis langsung oleh pemanggil
+const (
+	AksiCreate = "create"
+	AksiUpdate = "update"
+	AksiDelete = "delete"
+)
+
+// Entitas yang dicatat di audit_log
+const (
+	EntitasUser          = "user"
+	EntitasPegawai       = "pegawai"
+	EntitasUndangan      = "undangan"
+	EntitasServis        = "servis"
+	EntitasDetailServis  = "detail_servis"
+	EntitasBarang        = "barang"
+	EntitasPelanggan     = "pelanggan"
+	EntitasSupplier      = "supplier"
+	EntitasPembelian     = "pembelian"
+	EntitasLaporan       = "laporan"
+	EntitasKomisiAturan  = "komisi_aturan"
+	EntitasKomisiPeriode = "komisi_periode"
+	EntitasPembayaran    = "pembayaran"
+	EntitasNota          = "nota"
+	EntitasServisIntake  = "servis_intake"
+	EntitasLampiran      = "lampiran"
+	EntitasPenawaran     = "penawaran"
+)
+
+// tabel untuk Snapshot; kolom rahasia tidak pernah ikut tercatat
+var tabelEntitas = map[string]struct {
+	tabel    string
+	pk       string
+	sembunyi []string
+}{
+	EntitasUser:          {"user", "id_user", []string{"password", "token_version"}},
+	EntitasPegawai:       {"pegawai", "id_pegawai", nil},
+	EntitasUndangan:      {"undangan", "id_undangan", []string{"kode_hash"}},
+	EntitasServis:        {"servis", "id_servis", nil},
+	EntitasDetailServis:  {"detail_servis", "id_detail", nil},
+	EntitasBarang:        {"barang", "id_barang", nil},
+	EntitasPelanggan:     {"pelanggan", "id_pelanggan", nil},
+	EntitasSupplier:      {"supplier", "id_supplier", nil},
+	EntitasPembelian:     {"pembelian", "id_pembelian", nil},
+	EntitasLaporan:       {"laporan", "id_laporan", nil},
+	EntitasKomisiAturan:  {"komisi_aturan", "id_pegawai", nil},
+	EntitasKomisiPeriode: {"komisi_periode", "id_periode", nil},
+	EntitasPembayaran:    {"pembayaran", "id_pembayaran", nil},
+	EntitasNota:          {"nota", "id_nota", []string{"pdf"}},
+	EntitasServisIntake:  {"servis_intake", "id_servis", []string{"kode_kunci"}},
+	EntitasLampiran:      {"lampiran", "id_lampiran", nil},
+	EntitasPenawaran:     {"penawaran", "id_penawaran", nil},
+}
+
+// execer dipenuhi *sql.DB maupun *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// queryer dipenuhi *sql.DB maupun *sql.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Entry - satu perubahan data. Sebelum/Sesudah berupa struct atau map yang bisa di-JSON-kan,
+// jangan pernah berisi password atau token. Jika keduanya diisi, yang disimpan hanya
+// field yang berubah.
+type Entry struct {
+	Aksi      string
+	Entitas   string
+	IDEntitas int
+	Sebelum   interface{}
+	Sesudah   interface{}
+	IDUser    *int // pelaku; default diambil dari context RequireAuth
+}
+
+// Snapshot membaca satu baris entitas sebagai map kolom -> nilai untuk Entry.Sebelum/Sesudah.
+// Mengembalikan nil (tanpa error) jika baris tidak ada.
+func Snapshot(q queryer, entitas string, id int) (map[string]interface{}, error) {
+	t, ok := tabelEntitas[entitas]
+	if !ok {
+		return nil, fmt.Errorf("entitas audit tidak dikenal: %s", entitas)
+	}
+
+	rows, err := q.Query("SELECT * FROM `"+t.tabel+"` WHERE `"+t.pk+"` = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	vals := make([]interface{}, len(cols))
+	ptrs := make([]interface{}, len(cols))
+	for i := range vals {
+		ptrs[i] = &vals[i]
+	}
+	if err := rows.Scan(ptrs...); err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]interface{}, len(cols))
+	for i, c := range cols {
+		data[c] = normalize(vals[i])
+	}
+	for _, c := range t.sembunyi {
+		delete(data, c)
+	}
+	return data, rows.Err()
+}
+
+// normalize - []byte dari driver MySQL (DECIMAL, VARCHAR, ENUM) dijadikan string
+func normalize(v interface{}) interface{} {
+	switch x := v.(type) {
+	case []byte:
+		return string(x)
+	case time.Time:
+		return x.Format("2006-01-02 15:04:05")
+	}
+	return v
+}
+
+// Record menyimpan entry ke audit_log. Dipanggil di dalam transaksi yang sama dengan
+// perubahannya supaya jejak audit hanya tercatat jika perubahan tersimpan.
+func Record(q execer, r *http.Request, e Entry) error {
+	if e.IDUser == nil {
+		if id, ok := r.Context().Value(middleware.UserIDKey).(int); ok {
+			e.IDUser = &id
+		}
+	}
+
+	sebelum, sesudah, err := diff(e.Sebelum, e.Sesudah)
+	if err != nil {
+		return err
+	}
+
+	var idEntitas *int
+	if e.IDEntitas > 0 {
+		idEntitas = &e.IDEntitas
+	}
+
+	ua := r.UserAgent()
+	if len(ua) > 255 {
+		ua = ua[:255]
+	}
+
+	_, err = q.Exec(`
+		INSERT INTO audit_log (id_user, aksi, entitas, id_entitas, data_sebelum, data_sesudah, ip_address, user_agent)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, e.IDUser, e.Aksi, e.Entitas, idEntitas, sebelum, sesudah, middleware.ClientIP(r), ua)
+	return err
+}
+
+// Log - Record untuk perubahan yang tidak memakai transaksi. Gagal mencatat audit
+// hanya di-log karena perubahan datanya sudah tersimpan. Di dalam transaksi pakai
+// Record dan batalkan transaksinya jika gagal.
+func Log(db *sql.DB, r *http.Request, e Entry) {
+	if err := Record(db, r, e); err != nil {
+		log.Printf(" Error audit %s %s #%d: %v", e.Aksi, e.Entitas, e.IDEntitas, err)
+	}
+}
+
+// Conn dipenuhi *sql.DB maupun *sql.Tx
+type Conn interface {
+	execer
+	queryer
+}
+
+// CatatTx - cara singkat mencatat perubahan satu entitas di dalam transaksi: snapshot sesudah
+// dibaca dari q supaya yang tercatat sama dengan yang tersimpan. Untuk delete cukup sebelum.
+// sebelum diambil dengan Snapshot sebelum perubahan, nil untuk create.
+func CatatTx(q Conn, r *http.Request, aksi, entitas string, id int, sebelum map[string]interface{}) error {
+	var sesudah map[string]interface{}
+	if aksi != AksiDelete {
+		var err error
+		if sesudah, err = Snapshot(q, entitas, id); err != nil {
+			return fmt.Errorf("snapshot %s #%d: %w", entitas, id, err)
+		}
+	}
+	return Record(q, r, Entry{Aksi: aksi, Entitas: entitas, IDEntitas: id, Sebelum: sebelum, Sesudah: sesudah})
+}
+
+// Catat - CatatTx untuk perubahan yang tidak memakai transaksi, gagal hanya di-log
+func Catat(db *sql.DB, r *http.Request, aksi, entitas string, id int, sebelum map[string]interface{}) {
+	if err := CatatTx(db, r, aksi, entitas, id, sebelum); err != nil {
+		log.Printf(" Error audit %s %s #%d: %v", aksi, entitas, id, err)
+	}
+}
+
+// diff mengubah sebelum/sesudah ke JSON. Jika keduanya object, hanya field yang
+// berbeda yang disimpan.
+func diff(sebelum, sesudah interface{}) (interface{}, interface{}, error) {
+	a, err := toMap(sebelum)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := toMap(sesudah)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if a != nil && b != nil {
+		for k, v := range a {
+			if w, ok := b[k]; ok && reflect.DeepEqual(v, w) {
+				delete(a, k)
+				delete(b, k)
+			}
+		}
+	}
+
+	sa, err := toJSON(a, sebelum)
+	if err != nil {
+		return nil, nil, err
+	}
+	sb, err := toJSON(b, sesudah)
+	return sa, sb, err
+}
+
+// toMap - nil jika v kosong atau bukan object JSON
+func toMap(v interface{}) (map[string]interface{}, error) {
+	if isNil(v) {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if json.Unmarshal(raw, &m) != nil {
+		return nil, nil
+	}
+	return m, nil
+}
+
+func toJSON(m map[string]interface{}, asli interface{}) (interface{}, error) {
+	var v interface{} = m
+	if m == nil {
+		if isNil(asli) {
+			return nil, nil
+		}
+		v = asli
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Ptr, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
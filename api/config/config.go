@@ -1,14 +1,451 @@
 package config
 
-import "os"
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// DefaultJWTSecret hanya untuk development, ditolak saat APP_ENV=production
+const DefaultJWTSecret = "your-secret-key"
+
+// Duration - time.Duration yang dibaca dari string ("15s", "5m") di file config
+type Duration struct {
+    time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+    var s string
+    if err := json.Unmarshal(b, &s); err != nil {
+        return fmt.Errorf("durasi harus string seperti \"15s\": %w", err)
+    }
+    v, err := time.ParseDuration(s)
+    if err != nil {
+        return err
+    }
+    d.Duration = v
+    return nil
+}
+
+type ServerConfig struct {
+    Addr         string   `json:"addr"`
+    ReadTimeout  Duration `json:"read_timeout"`
+    WriteTimeout Duration `json:"write_timeout"`
+    IdleTimeout  Duration `json:"idle_timeout"`
+}
+
+type DatabaseConfig struct {
+    User            string   `json:"user"`
+    Password        string   `json:"password"`
+    Host            string   `json:"host"`
+    Port            string   `json:"port"`
+    Name            string   `json:"name"`
+    MaxOpenConns    int      `json:"max_open_conns"`
+    MaxIdleConns    int      `json:"max_idle_conns"`
+    ConnMaxLifetime Duration `json:"conn_max_lifetime"`
+    ConnMaxIdleTime Duration `json:"conn_max_idle_time"`
+    Timeout         Duration `json:"timeout"` // timeout dial koneksi
+}
+
+type CORSConfig struct {
+    AllowedOrigins []string `json:"allowed_origins"`
+}
+
+type JWTConfig struct {
+    Secret     string   `json:"secret"`
+    TTL        Duration `json:"ttl"`         // umur access token
+    RefreshTTL Duration `json:"refresh_ttl"` // umur refresh token
+    ResetTTL   Duration `json:"reset_ttl"`   // umur token reset password dari admin
+}
 
-var (
-    JWTSecret = getEnv("JWT_SECRET", "your-secret-key") // ganti di env
+// Mode signup pegawai
+const (
+    SignupNonaktif    = "nonaktif"    // signup publik ditutup, akun dibuat admin
+    SignupUndangan    = "undangan"    // wajib kode undangan dari admin
+    SignupPersetujuan = "persetujuan" // akun baru menunggu admin mendaftarkannya sebagai pegawai
 )
 
-func getEnv(key, fallback string) string {
+// LoginConfig - batas percobaan login gagal sebelum dikunci sementara
+type LoginConfig struct {
+    MaxGagalUser int      `json:"max_gagal_user"` // per username dalam Window
+    MaxGagalIP   int      `json:"max_gagal_ip"`   // per IP dalam Window
+    DelayMulai   int      `json:"delay_mulai"`    // gagal ke-n mulai kena jeda bertahap
+    Window       Duration `json:"window"`
+    Lockout      Duration `json:"lockout"`
+}
+
+type NotifConfig struct {
+    Driver        string `json:"driver"` // log (default, untuk testing), webhook, nonaktif
+    WebhookURL    string `json:"webhook_url"`
+    WebhookToken  string `json:"webhook_token"`
+    LogFile       string `json:"log_file"`
+    NamaToko      string `json:"nama_toko"`
+    MaxPercobaan  int    `json:"max_percobaan"`
+    PengingatHari int    `json:"pengingat_hari"` // jeda pengingat HP belum diambil
+}
+
+// NotaConfig - identitas toko & format nomor nota. Nama toko memakai notifikasi.nama_toko.
+type NotaConfig struct {
+    Prefix        string `json:"prefix"` // nomor nota: PREFIX/YYYY/MM/0001
+    Alamat        string `json:"alamat"`
+    Telepon       string `json:"telepon"`
+    CatatanKaki   string `json:"catatan_kaki"`
+    CatatanTerima string `json:"catatan_terima"` // kaki tanda terima servis
+}
+
+// LampiranConfig - penyimpanan foto & dokumen servis
+type LampiranConfig struct {
+    Driver      string `json:"driver"` // local (S3-compatible menyusul)
+    Dir         string `json:"dir"`    // root folder untuk driver local
+    MaxUkuranMB int    `json:"max_ukuran_mb"`
+    ThumbPx     int    `json:"thumb_px"` // sisi terpanjang thumbnail
+}
+
+// PenawaranConfig - persetujuan biaya servis oleh pelanggan. Jika diwajibkan, barang
+// tidak bisa dicatat saat servis diterima, baru setelah penawaran disetujui.
+type PenawaranConfig struct {
+    WajibPersetujuan bool `json:"wajib_persetujuan"` // pemakaian barang dibatasi item penawaran yang disetujui
+    BerlakuHari      int  `json:"berlaku_hari"`      // masa berlaku default penawaran
+}
+
+// Config - seluruh konfigurasi aplikasi
+type Config struct {
+    Env       string          `json:"env"` // development, production
+    Server    ServerConfig    `json:"server"`
+    Database  DatabaseConfig  `json:"database"`
+    CORS      CORSConfig      `json:"cors"`
+    JWT       JWTConfig       `json:"jwt"`
+    Login     LoginConfig     `json:"login"`
+    Signup    string          `json:"signup_mode"`
+    Notif     NotifConfig     `json:"notifikasi"`
+    Nota      NotaConfig      `json:"nota"`
+    Lampiran  LampiranConfig  `json:"lampiran"`
+    Penawaran PenawaranConfig `json:"penawaran"`
+}
+
+// Cfg - konfigurasi aktif, diisi oleh Load() saat startup
+var Cfg = Default()
+
+// Default - nilai bawaan untuk development lokal (Laragon + Vite)
+func Default() *Config {
+    return &Config{
+        Env: "development",
+        Server: ServerConfig{
+            Addr:         ":8080",
+            ReadTimeout:  Duration{15 * time.Second},
+            WriteTimeout: Duration{30 * time.Second},
+            IdleTimeout:  Duration{60 * time.Second},
+        },
+        Database: DatabaseConfig{
+            User:            "root",
+            Password:        "", // default Laragon password kosong
+            Host:            "localhost",
+            Port:            "3306",
+            Name:            "service_hp",
+            MaxOpenConns:    25,
+            MaxIdleConns:    10,
+            ConnMaxLifetime: Duration{30 * time.Minute},
+            ConnMaxIdleTime: Duration{5 * time.Minute},
+            Timeout:         Duration{5 * time.Second},
+        },
+        CORS: CORSConfig{
+            AllowedOrigins: []string{"http://localhost:5173"},
+        },
+        JWT: JWTConfig{
+            Secret:     DefaultJWTSecret,
+            TTL:        Duration{15 * time.Minute},
+            RefreshTTL: Duration{7 * 24 * time.Hour},
+            ResetTTL:   Duration{24 * time.Hour},
+        },
+        Signup: SignupPersetujuan,
+        Login: LoginConfig{
+            MaxGagalUser: 5,
+            MaxGagalIP:   20,
+            DelayMulai:   3,
+            Window:       Duration{15 * time.Minute},
+            Lockout:      Duration{15 * time.Minute},
+        },
+        Notif: NotifConfig{
+            Driver:        "log",
+            NamaToko:      "Service HP",
+            MaxPercobaan:  5,
+            PengingatHari: 3,
+        },
+        Nota: NotaConfig{
+            Prefix:        "INV",
+            CatatanKaki:   "Terima kasih. Simpan nota ini untuk pengambilan & klaim garansi.",
+            CatatanTerima: "Simpan tanda terima ini dan tunjukkan saat pengambilan. Cek status servis dengan kode tracking di atas.",
+        },
+        Lampiran: LampiranConfig{
+            Driver:      "local",
+            Dir:         "uploads/lampiran",
+            MaxUkuranMB: 10,
+            ThumbPx:     320,
+        },
+        Penawaran: PenawaranConfig{
+            WajibPersetujuan: true,
+            BerlakuHari:      7,
+        },
+    }
+}
+
+// Load membaca konfigurasi: default -> file JSON (CONFIG_FILE, opsional) -> env var,
+// lalu memvalidasi hasilnya. Cfg hanya diganti jika semuanya valid.
+func Load() error {
+    c := Default()
+
+    if path := os.Getenv("CONFIG_FILE"); path != "" {
+        b, err := os.ReadFile(path)
+        if err != nil {
+            return fmt.Errorf("gagal membaca config file: %w", err)
+        }
+        if err := json.Unmarshal(b, c); err != nil {
+            return fmt.Errorf("config file %s tidak valid: %w", path, err)
+        }
+    }
+
+    if err := c.applyEnv(); err != nil {
+        return err
+    }
+    if err := c.Validate(); err != nil {
+        return err
+    }
+
+    Cfg = c
+    return nil
+}
+
+func (c *Config) applyEnv() error {
+    var errs []error
+
+    setString(&c.Env, "APP_ENV")
+
+    setString(&c.Server.Addr, "SERVER_ADDR")
+    errs = append(errs,
+        setDuration(&c.Server.ReadTimeout, "SERVER_READ_TIMEOUT"),
+        setDuration(&c.Server.WriteTimeout, "SERVER_WRITE_TIMEOUT"),
+        setDuration(&c.Server.IdleTimeout, "SERVER_IDLE_TIMEOUT"),
+    )
+
+    setString(&c.Database.User, "DB_USER")
+    if v, ok := os.LookupEnv("DB_PASSWORD"); ok {
+        c.Database.Password = v // boleh kosong
+    }
+    setString(&c.Database.Host, "DB_HOST")
+    setString(&c.Database.Port, "DB_PORT")
+    setString(&c.Database.Name, "DB_NAME")
+    errs = append(errs,
+        setInt(&c.Database.MaxOpenConns, "DB_MAX_OPEN_CONNS"),
+        setInt(&c.Database.MaxIdleConns, "DB_MAX_IDLE_CONNS"),
+        setDuration(&c.Database.ConnMaxLifetime, "DB_CONN_MAX_LIFETIME"),
+        setDuration(&c.Database.ConnMaxIdleTime, "DB_CONN_MAX_IDLE_TIME"),
+        setDuration(&c.Database.Timeout, "DB_TIMEOUT"),
+    )
+
+    if v := os.Getenv("CORS_ALLOWED_ORIGINS"); v != "" {
+        c.CORS.AllowedOrigins = nil
+        for _, o := range strings.Split(v, ",") {
+            if o = strings.TrimSpace(o); o != "" {
+                c.CORS.AllowedOrigins = append(c.CORS.AllowedOrigins, o)
+            }
+        }
+    }
+
+    setString(&c.JWT.Secret, "JWT_SECRET")
+    errs = append(errs,
+        setDuration(&c.JWT.TTL, "JWT_TTL"),
+        setDuration(&c.JWT.RefreshTTL, "JWT_REFRESH_TTL"),
+        setDuration(&c.JWT.ResetTTL, "PASSWORD_RESET_TTL"),
+    )
+
+    errs = append(errs,
+        setInt(&c.Login.MaxGagalUser, "LOGIN_MAX_GAGAL_USER"),
+        setInt(&c.Login.MaxGagalIP, "LOGIN_MAX_GAGAL_IP"),
+        setInt(&c.Login.DelayMulai, "LOGIN_DELAY_MULAI"),
+        setDuration(&c.Login.Window, "LOGIN_WINDOW"),
+        setDuration(&c.Login.Lockout, "LOGIN_LOCKOUT"),
+    )
+
+    setString(&c.Signup, "SIGNUP_MODE")
+
+    setString(&c.Notif.Driver, "NOTIF_DRIVER")
+    setString(&c.Notif.WebhookURL, "NOTIF_WEBHOOK_URL")
+    setString(&c.Notif.WebhookToken, "NOTIF_WEBHOOK_TOKEN")
+    setString(&c.Notif.LogFile, "NOTIF_LOG_FILE")
+    setString(&c.Notif.NamaToko, "NOTIF_NAMA_TOKO")
+    errs = append(errs,
+        setInt(&c.Notif.MaxPercobaan, "NOTIF_MAX_PERCOBAAN"),
+        setInt(&c.Notif.PengingatHari, "NOTIF_PENGINGAT_HARI"),
+    )
+
+    setString(&c.Nota.Prefix, "NOTA_PREFIX")
+    setString(&c.Nota.Alamat, "NOTA_ALAMAT")
+    setString(&c.Nota.Telepon, "NOTA_TELEPON")
+    setString(&c.Nota.CatatanKaki, "NOTA_CATATAN_KAKI")
+    setString(&c.Nota.CatatanTerima, "NOTA_CATATAN_TERIMA")
+
+    setString(&c.Lampiran.Driver, "LAMPIRAN_DRIVER")
+    setString(&c.Lampiran.Dir, "LAMPIRAN_DIR")
+    errs = append(errs,
+        setInt(&c.Lampiran.MaxUkuranMB, "LAMPIRAN_MAX_UKURAN_MB"),
+        setInt(&c.Lampiran.ThumbPx, "LAMPIRAN_THUMB_PX"),
+    )
+
+    errs = append(errs,
+        setBool(&c.Penawaran.WajibPersetujuan, "PENAWARAN_WAJIB_PERSETUJUAN"),
+        setInt(&c.Penawaran.BerlakuHari, "PENAWARAN_BERLAKU_HARI"),
+    )
+
+    return errors.Join(errs...)
+}
+
+// Validate memeriksa konfigurasi sebelum server dijalankan
+func (c *Config) Validate() error {
+    var errs []error
+
+    if c.Env != "development" && c.Env != "production" {
+        errs = append(errs, fmt.Errorf("APP_ENV harus development atau production, bukan %q", c.Env))
+    }
+    if c.IsProduction() {
+        if c.JWT.Secret == DefaultJWTSecret {
+            errs = append(errs, errors.New("JWT_SECRET default tidak boleh dipakai di production"))
+        } else if len(c.JWT.Secret) < 32 {
+            errs = append(errs, errors.New("JWT_SECRET minimal 32 karakter di production"))
+        }
+    }
+    if c.JWT.Secret == "" {
+        errs = append(errs, errors.New("JWT_SECRET tidak boleh kosong"))
+    }
+    if c.JWT.TTL.Duration <= 0 || c.JWT.RefreshTTL.Duration <= c.JWT.TTL.Duration {
+        errs = append(errs, errors.New("JWT_TTL harus lebih dari 0 dan lebih pendek dari JWT_REFRESH_TTL"))
+    }
+    if c.JWT.ResetTTL.Duration <= 0 {
+        errs = append(errs, errors.New("PASSWORD_RESET_TTL harus lebih dari 0"))
+    }
+
+    if c.Server.Addr == "" {
+        errs = append(errs, errors.New("SERVER_ADDR tidak boleh kosong"))
+    }
+    if c.Database.Host == "" || c.Database.Name == "" || c.Database.User == "" {
+        errs = append(errs, errors.New("DB_HOST, DB_NAME dan DB_USER wajib diisi"))
+    }
+    if c.Database.MaxOpenConns < 1 || c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+        errs = append(errs, errors.New("DB_MAX_IDLE_CONNS harus antara 0 dan DB_MAX_OPEN_CONNS (minimal 1)"))
+    }
+
+    if len(c.CORS.AllowedOrigins) == 0 {
+        errs = append(errs, errors.New("CORS_ALLOWED_ORIGINS minimal satu origin"))
+    }
+    for _, o := range c.CORS.AllowedOrigins {
+        // Credentials diizinkan, jadi wildcard tidak aman
+        if o == "*" {
+            errs = append(errs, errors.New("CORS_ALLOWED_ORIGINS tidak boleh berisi *"))
+        }
+    }
+
+    if c.Login.MaxGagalUser < 1 || c.Login.MaxGagalIP < 1 || c.Login.DelayMulai < 1 ||
+        c.Login.Window.Duration <= 0 || c.Login.Lockout.Duration <= 0 {
+        errs = append(errs, errors.New("konfigurasi LOGIN_* harus lebih dari 0"))
+    }
+
+    switch c.Signup {
+    case SignupNonaktif, SignupUndangan, SignupPersetujuan:
+    default:
+        errs = append(errs, fmt.Errorf("SIGNUP_MODE harus nonaktif, undangan atau persetujuan, bukan %q", c.Signup))
+    }
+
+    switch c.Notif.Driver {
+    case "log", "nonaktif":
+    case "webhook":
+        if c.Notif.WebhookURL == "" {
+            errs = append(errs, errors.New("NOTIF_WEBHOOK_URL wajib diisi untuk driver webhook"))
+        }
+    default:
+        errs = append(errs, fmt.Errorf("NOTIF_DRIVER tidak dikenal: %s", c.Notif.Driver))
+    }
+    if c.Notif.MaxPercobaan < 1 || c.Notif.PengingatHari < 1 {
+        errs = append(errs, errors.New("NOTIF_MAX_PERCOBAAN dan NOTIF_PENGINGAT_HARI minimal 1"))
+    }
+
+    if c.Nota.Prefix == "" || len(c.Nota.Prefix) > 10 || strings.ContainsAny(c.Nota.Prefix, "/ ") {
+        errs = append(errs, errors.New("NOTA_PREFIX wajib diisi, maksimal 10 karakter, tanpa / atau spasi"))
+    }
+
+    switch c.Lampiran.Driver {
+    case "local":
+        if c.Lampiran.Dir == "" {
+            errs = append(errs, errors.New("LAMPIRAN_DIR wajib diisi untuk driver local"))
+        }
+    default:
+        errs = append(errs, fmt.Errorf("LAMPIRAN_DRIVER tidak dikenal: %s", c.Lampiran.Driver))
+    }
+    if c.Lampiran.MaxUkuranMB < 1 || c.Lampiran.ThumbPx < 32 {
+        errs = append(errs, errors.New("LAMPIRAN_MAX_UKURAN_MB minimal 1 dan LAMPIRAN_THUMB_PX minimal 32"))
+    }
+
+    if c.Penawaran.BerlakuHari < 1 || c.Penawaran.BerlakuHari > 90 {
+        errs = append(errs, errors.New("PENAWARAN_BERLAKU_HARI harus antara 1 dan 90"))
+    }
+
+    return errors.Join(errs...)
+}
+
+func (c *Config) IsProduction() bool {
+    return c.Env == "production"
+}
+
+// DSN untuk driver go-sql-driver/mysql
+func (d DatabaseConfig) DSN() string {
+    return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true&timeout=%s",
+        d.User, d.Password, d.Host, d.Port, d.Name, d.Timeout.Duration,
+    )
+}
+
+func setString(dst *string, key string) {
     if v := os.Getenv(key); v != "" {
-        return v
+        *dst = v
+    }
+}
+
+func setInt(dst *int, key string) error {
+    v := os.Getenv(key)
+    if v == "" {
+        return nil
+    }
+    n, err := strconv.Atoi(v)
+    if err != nil {
+        return fmt.Errorf("%s harus angka: %q", key, v)
+    }
+    *dst = n
+    return nil
+}
+
+func setBool(dst *bool, key string) error {
+    v := os.Getenv(key)
+    if v == "" {
+        return nil
+    }
+    b, err := strconv.ParseBool(v)
+    if err != nil {
+        return fmt.Errorf("%s harus true atau false: %q", key, v)
+    }
+    *dst = b
+    return nil
+}
+
+func setDuration(dst *Duration, key string) error {
+    v := os.Getenv(key)
+    if v == "" {
+        return nil
+    }
+    d, err := time.ParseDuration(v)
+    if err != nil {
+        return fmt.Errorf("%s harus durasi seperti 15s/5m: %q", key, v)
     }
-    return fallback
+    dst.Duration = d
+    return nil
 }
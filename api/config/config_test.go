@@ -0,0 +1,130 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultValid(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("konfigurasi default tidak valid: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		nama  string
+		ubah  func(c *Config)
+		pesan string // potongan pesan error yang diharapkan
+	}{
+		{"env tidak dikenal", func(c *Config) { c.Env = "staging" }, "APP_ENV"},
+		{"secret default di production", func(c *Config) { c.Env = "production" }, "JWT_SECRET default"},
+		{"secret pendek di production", func(c *Config) {
+			c.Env = "production"
+			c.JWT.Secret = "pendek"
+		}, "minimal 32 karakter"},
+		{"secret kosong", func(c *Config) { c.JWT.Secret = "" }, "JWT_SECRET tidak boleh kosong"},
+		{"ttl lebih lama dari refresh", func(c *Config) { c.JWT.TTL.Duration = 30 * 24 * time.Hour }, "JWT_TTL"},
+		{"addr kosong", func(c *Config) { c.Server.Addr = "" }, "SERVER_ADDR"},
+		{"db name kosong", func(c *Config) { c.Database.Name = "" }, "DB_HOST, DB_NAME"},
+		{"idle melebihi open", func(c *Config) { c.Database.MaxIdleConns = c.Database.MaxOpenConns + 1 }, "DB_MAX_IDLE_CONNS"},
+		{"cors kosong", func(c *Config) { c.CORS.AllowedOrigins = nil }, "minimal satu origin"},
+		{"cors wildcard", func(c *Config) { c.CORS.AllowedOrigins = []string{"*"} }, "tidak boleh berisi *"},
+		{"login nol", func(c *Config) { c.Login.MaxGagalIP = 0 }, "LOGIN_*"},
+		{"signup tidak dikenal", func(c *Config) { c.Signup = "terbuka" }, "SIGNUP_MODE"},
+		{"webhook tanpa url", func(c *Config) {
+			c.Notif.Driver = "webhook"
+			c.Notif.WebhookURL = ""
+		}, "NOTIF_WEBHOOK_URL"},
+		{"driver notif tidak dikenal", func(c *Config) { c.Notif.Driver = "sms" }, "NOTIF_DRIVER"},
+		{"prefix nota berspasi", func(c *Config) { c.Nota.Prefix = "IN V" }, "NOTA_PREFIX"},
+		{"driver lampiran tidak dikenal", func(c *Config) { c.Lampiran.Driver = "s3" }, "LAMPIRAN_DRIVER"},
+		{"thumbnail terlalu kecil", func(c *Config) { c.Lampiran.ThumbPx = 16 }, "LAMPIRAN_THUMB_PX"},
+		{"penawaran berlaku terlalu lama", func(c *Config) { c.Penawaran.BerlakuHari = 91 }, "PENAWARAN_BERLAKU_HARI"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			c := Default()
+			tt.ubah(c)
+			err := c.Validate()
+			if err == nil {
+				t.Fatalf("Validate lolos, seharusnya error %q", tt.pesan)
+			}
+			if !strings.Contains(err.Error(), tt.pesan) {
+				t.Errorf("Validate error = %q, seharusnya berisi %q", err, tt.pesan)
+			}
+		})
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	tests := []struct {
+		nama  string
+		env   map[string]string
+		cek   func(c *Config) bool
+		gagal bool
+	}{
+		{"addr", map[string]string{"SERVER_ADDR": ":9000"}, func(c *Config) bool { return c.Server.Addr == ":9000" }, false},
+		{"password", map[string]string{"DB_PASSWORD": "rahasia"}, func(c *Config) bool { return c.Database.Password == "rahasia" }, false},
+		{"angka", map[string]string{"DB_MAX_OPEN_CONNS": "42"}, func(c *Config) bool { return c.Database.MaxOpenConns == 42 }, false},
+		{"durasi", map[string]string{"JWT_TTL": "5m"}, func(c *Config) bool { return c.JWT.TTL.Duration == 5*time.Minute }, false},
+		{"bool", map[string]string{"PENAWARAN_WAJIB_PERSETUJUAN": "false"}, func(c *Config) bool { return !c.Penawaran.WajibPersetujuan }, false},
+		{
+			"daftar cors dipangkas",
+			map[string]string{"CORS_ALLOWED_ORIGINS": " https://a.id , ,https://b.id"},
+			func(c *Config) bool {
+				o := c.CORS.AllowedOrigins
+				return len(o) == 2 && o[0] == "https://a.id" && o[1] == "https://b.id"
+			},
+			false,
+		},
+		{"angka tidak valid", map[string]string{"DB_MAX_OPEN_CONNS": "banyak"}, nil, true},
+		{"durasi tidak valid", map[string]string{"JWT_TTL": "15"}, nil, true},
+		{"bool tidak valid", map[string]string{"PENAWARAN_WAJIB_PERSETUJUAN": "ya"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			c := Default()
+			err := c.applyEnv()
+			if (err != nil) != tt.gagal {
+				t.Fatalf("applyEnv error = %v, seharusnya gagal = %v", err, tt.gagal)
+			}
+			if tt.cek != nil && !tt.cek(c) {
+				t.Errorf("env %v tidak diterapkan", tt.env)
+			}
+		})
+	}
+}
+
+func TestDurationJSON(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+		gagal bool
+	}{
+		{`"15m"`, 15 * time.Minute, false},
+		{`"720h"`, 720 * time.Hour, false},
+		{`"15"`, 0, true},
+		{`15`, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var d Duration
+			err := json.Unmarshal([]byte(tt.input), &d)
+			if (err != nil) != tt.gagal {
+				t.Fatalf("Unmarshal(%s) error = %v, seharusnya gagal = %v", tt.input, err, tt.gagal)
+			}
+			if !tt.gagal && d.Duration != tt.want {
+				t.Errorf("Unmarshal(%s) = %v, seharusnya %v", tt.input, d.Duration, tt.want)
+			}
+		})
+	}
+}
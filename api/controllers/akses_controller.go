@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"service_hp/audit"
+	"service_hp/database"
+	"service_hp/models"
+	"service_hp/routes/middleware"
+	"strconv"
+	"strings"
+)
+
+// =======================================================
+// GET DAFTAR ROLE & PERMISSION
+// =======================================================
+func GetAllRoleAkses(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	list := []models.RoleAkses{}
+	for _, role := range []string{middleware.AksesAdmin, middleware.AksesKasir, middleware.AksesTeknisi} {
+		list = append(list, models.RoleAkses{Role: role, Permissions: middleware.PermissionsOf(role)})
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+// =======================================================
+// GET USER + ROLE
+// =======================================================
+func GetAllUserAkses(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rows, err := database.DB.Query(`
+		SELECT u.id_user, u.nama, u.username, u.role,
+			COALESCE(p.jabatan, ''), COALESCE(p.status, '')
+		FROM user u
+		LEFT JOIN pegawai p ON p.id_user = u.id_user
+		ORDER BY u.nama ASC
+	`)
+	if err != nil {
+		log.Println(" Error query user akses:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	list := []models.UserAkses{}
+	for rows.Next() {
+		var u models.UserAkses
+		if err := rows.Scan(&u.IDUser, &u.Nama, &u.Username, &u.Role, &u.Jabatan, &u.Status); err != nil {
+			log.Println(" Error scan user akses:", err)
+			continue
+		}
+		list = append(list, u)
+	}
+
+	json.NewEncoder(w).Encode(list)
+}
+
+// =======================================================
+// UBAH ROLE USER (PUT /api/admin/users/{id}/akses)
+// admin -> user.role = admin, kasir/teknisi -> user.role = pegawai + pegawai.jabatan
+// =======================================================
+func UpdateUserAkses(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	idUser, err := extractUserSubID(r.URL.Path, "akses")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.UbahAksesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	akses := strings.ToLower(strings.TrimSpace(req.Akses))
+	if !middleware.AksesValid(akses) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Akses harus admin, kasir atau teknisi"})
+		return
+	}
+
+	// Admin tidak bisa menurunkan dirinya sendiri agar tidak terkunci
+	if self := currentUserID(r); self != nil && *self == idUser && akses != middleware.AksesAdmin {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Tidak bisa menurunkan akses akun sendiri"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	var roleLama string
+	err = tx.QueryRow(`SELECT role FROM user WHERE id_user = ? FOR UPDATE`, idUser).Scan(&roleLama)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "User tidak ditemukan"})
+		return
+	} else if err != nil {
+		tx.Rollback()
+		log.Println(" Error select user:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	if akses != middleware.AksesAdmin {
+		// Kasir / teknisi harus sudah terdaftar sebagai pegawai
+		var exists int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM pegawai WHERE id_user = ?`, idUser).Scan(&exists); err != nil {
+			tx.Rollback()
+			log.Println(" Error check pegawai:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+			return
+		}
+		if exists == 0 {
+			tx.Rollback()
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "User belum terdaftar sebagai pegawai, tambahkan di menu pegawai dulu",
+			})
+			return
+		}
+	}
+
+	if akses == middleware.AksesAdmin {
+		_, err = tx.Exec(`UPDATE user SET role = ? WHERE id_user = ?`, middleware.RoleAdmin, idUser)
+	} else {
+		jabatan, _ := normalizeJabatan(akses)
+		_, err = tx.Exec(`UPDATE pegawai SET jabatan = ? WHERE id_user = ?`, jabatan, idUser)
+		if err == nil {
+			_, err = tx.Exec(`UPDATE user SET role = ? WHERE id_user = ?`, middleware.RolePegawai, idUser)
+		}
+	}
+	if err == nil {
+		err = audit.Record(tx, r, audit.Entry{
+			Aksi:      "akses.ubah",
+			Entitas:   audit.EntitasUser,
+			IDEntitas: idUser,
+			Sebelum:   map[string]string{"role": roleLama},
+			Sesudah:   map[string]string{"akses": akses},
+		})
+	}
+	if err != nil {
+		tx.Rollback()
+		log.Println(" Error update akses user:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal mengubah akses user"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":     "Akses user berhasil diubah",
+		"role_lama":   roleLama,
+		"akses":       akses,
+		"permissions": middleware.PermissionsOf(akses),
+	})
+}
+
+// Helper: ambil id user dari /api/admin/users/{id}/<aksi>
+func extractUserSubID(path, aksi string) (int, error) {
+	idStr := strings.TrimPrefix(path, "/api/admin/users/")
+	idStr = strings.TrimSuffix(strings.TrimSuffix(idStr, "/"), "/"+aksi)
+	return strconv.Atoi(idStr)
+}
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"service_hp/database"
+	"service_hp/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Helper: tulis response saat audit log gagal dicatat. Transaksi harus dibatalkan
+// pemanggil, perubahan tanpa jejak audit tidak boleh tersimpan.
+func writeAuditError(w http.ResponseWriter, err error) {
+	log.Println(" Error catat audit:", err)
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(map[string]string{"error": "Gagal mencatat audit log"})
+}
+
+// =======================================================
+// AUDIT LOG (GET /api/admin/audit)
+// Filter: ?entitas=&id_entitas=&id_user=&aksi=&dari=YYYY-MM-DD&sampai=YYYY-MM-DD&limit=&offset=
+// =======================================================
+func GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+	query := `
+		SELECT a.id_audit, a.id_user, COALESCE(u.nama, ''), a.aksi, a.entitas, a.id_entitas,
+			a.data_sebelum, a.data_sesudah, COALESCE(a.ip_address, ''), COALESCE(a.user_agent, ''), a.created_at
+		FROM audit_log a
+		LEFT JOIN user u ON u.id_user = a.id_user
+		WHERE 1=1
+	`
+	var args []interface{}
+	if v := strings.TrimSpace(q.Get("entitas")); v != "" {
+		query += " AND a.entitas = ?"
+		args = append(args, v)
+	}
+	if v := strings.TrimSpace(q.Get("aksi")); v != "" {
+		query += " AND a.aksi = ?"
+		args = append(args, v)
+	}
+	for _, f := range []struct{ param, kolom string }{
+		{"id_entitas", "a.id_entitas"},
+		{"id_user", "a.id_user"},
+	} {
+		v := strings.TrimSpace(q.Get(f.param))
+		if v == "" {
+			continue
+		}
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": f.param + " harus angka"})
+			return
+		}
+		query += " AND " + f.kolom + " = ?"
+		args = append(args, id)
+	}
+
+	// Rentang tanggal inklusif, sampai=2024-01-31 mencakup seluruh hari itu
+	for _, f := range []struct{ param, op string }{
+		{"dari", ">="},
+		{"sampai", "<"},
+	} {
+		v := strings.TrimSpace(q.Get(f.param))
+		if v == "" {
+			continue
+		}
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": f.param + " harus format YYYY-MM-DD"})
+			return
+		}
+		if f.param == "sampai" {
+			t = t.AddDate(0, 0, 1)
+		}
+		query += " AND a.created_at " + f.op + " ?"
+		args = append(args, t)
+	}
+
+	limit := 100
+	if v, err := strconv.Atoi(q.Get("limit")); err == nil && v > 0 && v <= 500 {
+		limit = v
+	}
+	offset := 0
+	if v, err := strconv.Atoi(q.Get("offset")); err == nil && v > 0 {
+		offset = v
+	}
+	query += " ORDER BY a.id_audit DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		log.Println(" Error query audit log:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	list := []models.AuditLog{}
+	for rows.Next() {
+		var a models.AuditLog
+		var idUser, idEntitas sql.NullInt64
+		var sebelum, sesudah sql.NullString
+		if err := rows.Scan(&a.IDAudit, &idUser, &a.NamaUser, &a.Aksi, &a.Entitas, &idEntitas,
+			&sebelum, &sesudah, &a.IPAddress, &a.UserAgent, &a.CreatedAt); err != nil {
+			log.Println(" Error scan audit log:", err)
+			continue
+		}
+		a.IDUser = nullIntPtr(idUser)
+		a.IDEntitas = nullIntPtr(idEntitas)
+		if sebelum.Valid {
+			a.DataSebelum = json.RawMessage(sebelum.String)
+		}
+		if sesudah.Valid {
+			a.DataSesudah = json.RawMessage(sesudah.String)
+		}
+		list = append(list, a)
+	}
+
+	json.NewEncoder(w).Encode(list)
+}
@@ -6,16 +6,25 @@ import (
     "encoding/json"
     
     "net/http"
+    "service_hp/audit"
     "service_hp/config"
     "service_hp/database"
     "service_hp/models"
-    "time"
+    "service_hp/routes/middleware"
     "log"
+    "strings"
     "golang.org/x/crypto/bcrypt"
-    "github.com/golang-jwt/jwt/v4"
 )
 
-func Register(w http.ResponseWriter, r *http.Request) {
+// currentUserID mengambil id_user dari JWT (nil untuk route tanpa RequireAuth)
+func currentUserID(r *http.Request) *int {
+    if id, ok := r.Context().Value(middleware.UserIDKey).(int); ok {
+        return &id
+    }
+    return nil
+}
+
+func Login(w http.ResponseWriter, r *http.Request) {
     if r.Method != http.MethodPost {
         http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
         return
@@ -27,72 +36,28 @@ func Register(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-    // simple validation
-    if req.Username == "" || req.Password == "" || req.Nama == "" {
-        http.Error(w, "nama, username and password are required", http.StatusBadRequest)
-        return
-    }
+    req.Username = strings.TrimSpace(req.Username)
 
-    // check username exists
-    var exists string
-    err := database.DB.QueryRow("SELECT username FROM user WHERE username = ?", req.Username).Scan(&exists)
-    if err != nil && err != sql.ErrNoRows {
-        log.Println("DB check error:", err)
-        http.Error(w, "Server error", http.StatusInternalServerError)
-        return
-    }
-    if exists != "" {
-        http.Error(w, "Username already exists", http.StatusConflict)
-        return
-    }
-
-    // hash password
-    hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-    if err != nil {
-        http.Error(w, "Failed to hash password", http.StatusInternalServerError)
-        return
-    }
-
-    // insert role=pegawai
-    res, err := database.DB.Exec("INSERT INTO user (nama, username, password, role) VALUES (?, ?, ?, ?)",
-        req.Nama, req.Username, string(hashed), "pegawai")
+    // Lockout per IP / username dan jeda bertahap setelah beberapa kali gagal
+    idAttempt, tunggu, err := checkLoginAllowed(r, req.Username)
     if err != nil {
-        log.Println("DB insert error:", err)
-        http.Error(w, "Failed to create user", http.StatusInternalServerError)
-        return
-    }
-
-    lastID, _ := res.LastInsertId()
-    resp := map[string]interface{}{
-        "message": "User created",
-        "user": map[string]interface{}{
-            "id_user": lastID,
-            "nama": req.Nama,
-            "username": req.Username,
-            "role": "pegawai",
-        },
-    }
-    w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(resp)
-}
-
-func Login(w http.ResponseWriter, r *http.Request) {
-    if r.Method != http.MethodPost {
-        http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+        log.Println("DB error:", err)
+        http.Error(w, "Server error", http.StatusInternalServerError)
         return
     }
-
-    var req models.User
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        http.Error(w, "Invalid JSON", http.StatusBadRequest)
+    if tunggu > 0 {
+        writeLoginLocked(w, tunggu)
         return
     }
 
     var user models.User
-    err := database.DB.QueryRow("SELECT id_user, nama, username, password, role FROM user WHERE username = ?", req.Username).
+    err = database.DB.QueryRow("SELECT id_user, nama, username, password, role FROM user WHERE username = ?", req.Username).
         Scan(&user.ID, &user.Nama, &user.Username, &user.Password, &user.Role)
     if err == sql.ErrNoRows {
-        http.Error(w, "User not found", http.StatusUnauthorized)
+        // Tetap jalankan bcrypt agar waktu respon tidak membocorkan username
+        compareDummyPassword(req.Password)
+        recordLoginAttempt(idAttempt, req.Username, nil, models.LoginUserTidakAda)
+        http.Error(w, pesanLoginGagal, http.StatusUnauthorized)
         return
     } else if err != nil {
         log.Println("DB error:", err)
@@ -102,28 +67,39 @@ func Login(w http.ResponseWriter, r *http.Request) {
 
     // compare hashed password
     if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-        http.Error(w, "Wrong password", http.StatusUnauthorized)
+        recordLoginAttempt(idAttempt, req.Username, &user.ID, models.LoginPasswordSalah)
+        http.Error(w, pesanLoginGagal, http.StatusUnauthorized)
         return
     }
 
-    // create JWT
-    claims := jwt.MapClaims{
-        "user_id": user.ID,
-        "username": user.Username,
-        "role": user.Role,
-        "exp": time.Now().Add(time.Hour * 24).Unix(),
+    // Pegawai nonaktif / belum terdaftar tidak boleh login
+    sesi, _, err := middleware.LoadSesi(user.ID)
+    if err != nil {
+        log.Println("DB error:", err)
+        http.Error(w, "Server error", http.StatusInternalServerError)
+        return
     }
-    token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-    tokenString, err := token.SignedString([]byte(config.JWTSecret))
+    if !sesi.Aktif {
+        recordLoginAttempt(idAttempt, req.Username, &user.ID, models.LoginAkunNonaktif)
+        http.Error(w, "Akun tidak aktif", http.StatusForbidden)
+        return
+    }
+
+    recordLoginAttempt(idAttempt, req.Username, &user.ID, models.LoginBerhasil)
+
+    // access token berumur pendek + refresh token
+    tokens, err := issueTokens(r, user, sesi.TokenVersion)
     if err != nil {
-        log.Println("JWT sign error:", err)
+        log.Println("Token error:", err)
         http.Error(w, "Server error", http.StatusInternalServerError)
         return
     }
 
     resp := map[string]interface{}{
         "message": "Login success",
-        "token": tokenString,
+        "token": tokens.Token,
+        "refresh_token": tokens.RefreshToken,
+        "expires_in": tokens.ExpiresIn,
         "user": map[string]interface{}{
             "id_user": user.ID,
             "nama": user.Nama,
@@ -135,42 +111,147 @@ func Login(w http.ResponseWriter, r *http.Request) {
     json.NewEncoder(w).Encode(resp)
 }
 
+// SignUpPegawai - pendaftaran pegawai sesuai SIGNUP_MODE:
+// nonaktif ditolak, undangan wajib kode dari admin (langsung aktif dengan jabatan undangan),
+// persetujuan membuat akun yang baru bisa login setelah admin mendaftarkannya sebagai pegawai.
 func SignUpPegawai(w http.ResponseWriter, r *http.Request) {
     if r.Method != http.MethodPost {
         http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
         return
     }
 
-    var req models.User
+    mode := config.Cfg.Signup
+    if mode == config.SignupNonaktif {
+        http.Error(w, "Pendaftaran ditutup, hubungi admin", http.StatusForbidden)
+        return
+    }
+
+    var req models.SignupRequest
     if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
         http.Error(w, "Invalid JSON", http.StatusBadRequest)
         return
     }
 
+    req.Nama = strings.TrimSpace(req.Nama)
+    req.Username = strings.ToLower(strings.TrimSpace(req.Username))
+    for _, err := range []error{
+        validateNama(req.Nama),
+        validateUsername(req.Username),
+        validatePassword(req.Password, req.Username),
+    } {
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+    }
+    if mode == config.SignupUndangan && strings.TrimSpace(req.KodeUndangan) == "" {
+        http.Error(w, "Kode undangan wajib diisi", http.StatusBadRequest)
+        return
+    }
+
+    hashed, err := hashPassword(req.Password)
+    if err != nil {
+        log.Println("Hash error:", err)
+        http.Error(w, "Server error", http.StatusInternalServerError)
+        return
+    }
+
+    tx, err := database.DB.Begin()
+    if err != nil {
+        log.Println("DB error:", err)
+        http.Error(w, "Server error", http.StatusInternalServerError)
+        return
+    }
+    defer tx.Rollback()
+
     // Cek username duplikat
-    var exists string
-    err := database.DB.QueryRow("SELECT username FROM user WHERE username = ?", req.Username).Scan(&exists)
-    if err != sql.ErrNoRows {
+    var exists int
+    if err := tx.QueryRow("SELECT COUNT(*) FROM user WHERE username = ?", req.Username).Scan(&exists); err != nil {
+        log.Println("DB error:", err)
+        http.Error(w, "Server error", http.StatusInternalServerError)
+        return
+    }
+    if exists > 0 {
         http.Error(w, "Username sudah digunakan", http.StatusConflict)
         return
     }
 
-    // Hash password
-    hashed, _ := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+    // Undangan dikunci dulu agar tidak bisa dipakai dua kali bersamaan
+    var idUndangan int
+    var jabatan string
+    if mode == config.SignupUndangan {
+        err := tx.QueryRow(`
+            SELECT id_undangan, jabatan FROM undangan
+            WHERE kode_hash = ? AND dipakai_at IS NULL AND dibatalkan_at IS NULL AND expires_at > NOW()
+            FOR UPDATE
+        `, hashToken(normalizeKodeUndangan(req.KodeUndangan))).Scan(&idUndangan, &jabatan)
+        if err == sql.ErrNoRows {
+            http.Error(w, "Kode undangan tidak valid atau sudah dipakai", http.StatusBadRequest)
+            return
+        } else if err != nil {
+            log.Println("DB error:", err)
+            http.Error(w, "Server error", http.StatusInternalServerError)
+            return
+        }
+    }
 
     // Insert user pegawai
-    _, err = database.DB.Exec(`
+    res, err := tx.Exec(`
         INSERT INTO user (nama, username, password, role)
         VALUES (?, ?, ?, 'pegawai')
-    `, req.Nama, req.Username, string(hashed))
+    `, req.Nama, req.Username, hashed)
     if err != nil {
         log.Println("DB insert error:", err)
         http.Error(w, "Server error", http.StatusInternalServerError)
         return
     }
+    idUser, _ := res.LastInsertId()
+
+    message := "Registrasi berhasil, tunggu persetujuan admin sebelum login"
+    if mode == config.SignupUndangan {
+        _, err = tx.Exec(`
+            INSERT INTO pegawai (id_user, nama_pegawai, jabatan, tanggal_masuk, status)
+            VALUES (?, ?, ?, NOW(), 'Aktif')
+        `, idUser, req.Nama, jabatan)
+        if err == nil {
+            _, err = tx.Exec(`UPDATE undangan SET dipakai_oleh = ?, dipakai_at = NOW() WHERE id_undangan = ?`, idUser, idUndangan)
+        }
+        if err != nil {
+            log.Println("DB insert error:", err)
+            http.Error(w, "Server error", http.StatusInternalServerError)
+            return
+        }
+        message = "Registrasi berhasil, silakan login"
+    }
+
+    actor := int(idUser)
+    if err := audit.Record(tx, r, audit.Entry{
+        Aksi:      "signup",
+        Entitas:   audit.EntitasUser,
+        IDEntitas: actor,
+        Sesudah:   map[string]string{"nama": req.Nama, "username": req.Username, "mode": mode},
+        IDUser:    &actor,
+    }); err != nil {
+        log.Println("Audit error:", err)
+        http.Error(w, "Server error", http.StatusInternalServerError)
+        return
+    }
+
+    if err := tx.Commit(); err != nil {
+        log.Println("DB commit error:", err)
+        http.Error(w, "Server error", http.StatusInternalServerError)
+        return
+    }
 
     w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
     json.NewEncoder(w).Encode(map[string]string{
-        "message": "Registrasi berhasil",
+        "message": message,
     })
 }
+
+// SignupMode - mode pendaftaran agar frontend tahu form yang harus ditampilkan
+func SignupMode(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(map[string]string{"mode": config.Cfg.Signup})
+}
@@ -1,10 +1,13 @@
 package controllers
 
 import (
+	"database/sql"
 	"encoding/json"
 	"net/http"
+	"service_hp/audit"
 	"service_hp/database"
 	"service_hp/models"
+	"service_hp/routes/middleware"
 	"strconv"
 	"strings"
 	"log"
@@ -20,7 +23,8 @@ func GetAllBarang(w http.ResponseWriter, r *http.Request) {
 			nama_barang,
 			stok,
 			harga,
-			COALESCE(harga_modal, 0) as harga_modal
+			COALESCE(harga_modal, 0) as harga_modal,
+			garansi_hari
 		FROM barang
 		ORDER BY nama_barang ASC
 	`)
@@ -42,6 +46,7 @@ func GetAllBarang(w http.ResponseWriter, r *http.Request) {
 			&b.Stok,
 			&b.Harga,
 			&b.HargaModal,
+			&b.GaransiHari,
 		)
 		if err != nil {
 			log.Println(" Error scan row:", err)
@@ -99,6 +104,12 @@ func CreateBarang(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.GaransiHari < 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Garansi tidak boleh negatif"})
+		return
+	}
+
 	// Validasi logis: Harga modal tidak boleh lebih besar dari harga jual
 	if req.HargaModal > req.Harga {
 		w.WriteHeader(http.StatusBadRequest)
@@ -108,13 +119,22 @@ func CreateBarang(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Insert barang dengan harga_modal
-	result, err := database.DB.Exec(`
-		INSERT INTO barang (nama_barang, stok, harga, harga_modal)
-		VALUES (?, ?, ?, ?)`,
-		req.NamaBarang, req.Stok, req.Harga, req.HargaModal)
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	// Insert barang dengan harga_modal, stok awal masuk lewat kartu stok
+	result, err := tx.Exec(`
+		INSERT INTO barang (nama_barang, stok, harga, harga_modal, garansi_hari)
+		VALUES (?, 0, ?, ?, ?)`,
+		req.NamaBarang, req.Harga, req.HargaModal, req.GaransiHari)
 
 	if err != nil {
+		tx.Rollback()
 		log.Println(" Error insert barang:", err)
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
@@ -122,6 +142,35 @@ func CreateBarang(w http.ResponseWriter, r *http.Request) {
 	}
 
 	lastID, _ := result.LastInsertId()
+
+	if req.Stok > 0 {
+		err = recordStockMovement(tx, models.StockMovement{
+			IDBarang:   int(lastID),
+			Jenis:      models.MutasiPenyesuaian,
+			Jumlah:     req.Stok,
+			IDUser:     currentUserID(r),
+			Keterangan: "Stok awal",
+		})
+		if err != nil {
+			tx.Rollback()
+			writeStokError(w, err)
+			return
+		}
+	}
+
+	if err := audit.CatatTx(tx, r, audit.AksiCreate, audit.EntitasBarang, int(lastID), nil); err != nil {
+		tx.Rollback()
+		writeAuditError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
+	}
+
 	log.Println(" Barang berhasil ditambahkan dengan ID:", lastID)
 	
 	w.WriteHeader(http.StatusCreated)
@@ -191,6 +240,12 @@ func UpdateBarang(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.GaransiHari < 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Garansi tidak boleh negatif"})
+		return
+	}
+
 	// Validasi logis
 	if req.HargaModal > req.Harga {
 		w.WriteHeader(http.StatusBadRequest)
@@ -200,24 +255,83 @@ func UpdateBarang(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	var stokLama int
+	var hargaLama, modalLama float64
+	err = tx.QueryRow(`SELECT stok, harga, harga_modal FROM barang WHERE id_barang=? FOR UPDATE`, idBarang).
+		Scan(&stokLama, &hargaLama, &modalLama)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Barang tidak ditemukan"})
+		return
+	} else if err != nil {
+		tx.Rollback()
+		log.Println(" Error select barang:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	sebelum, _ := audit.Snapshot(tx, audit.EntitasBarang, idBarang)
+
+	// Perubahan harga jual / modal hanya untuk yang punya izin barang.edit_harga
+	if (req.Harga != hargaLama || req.HargaModal != modalLama) &&
+		!middleware.HasPermission(r.Context(), middleware.PermBarangEditHarga) {
+		tx.Rollback()
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Anda tidak punya izin mengubah harga barang"})
+		return
+	}
+
 	// Update barang dengan harga_modal
-	result, err := database.DB.Exec(`
+	_, err = tx.Exec(`
 		UPDATE barang 
-		SET nama_barang=?, stok=?, harga=?, harga_modal=?
+		SET nama_barang=?, harga=?, harga_modal=?, garansi_hari=?
 		WHERE id_barang=?`,
-		req.NamaBarang, req.Stok, req.Harga, req.HargaModal, idBarang)
+		req.NamaBarang, req.Harga, req.HargaModal, req.GaransiHari, idBarang)
 	
 	if err != nil {
+		tx.Rollback()
 		log.Println(" Error update barang:", err)
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Barang tidak ditemukan"})
+	// Perubahan stok manual dicatat sebagai penyesuaian di kartu stok
+	if req.Stok != stokLama {
+		err = recordStockMovement(tx, models.StockMovement{
+			IDBarang:   idBarang,
+			Jenis:      models.MutasiPenyesuaian,
+			Jumlah:     req.Stok - stokLama,
+			IDUser:     currentUserID(r),
+			Keterangan: "Perubahan stok manual",
+		})
+		if err != nil {
+			tx.Rollback()
+			writeStokError(w, err)
+			return
+		}
+	}
+
+	if err := audit.CatatTx(tx, r, audit.AksiUpdate, audit.EntitasBarang, idBarang, sebelum); err != nil {
+		tx.Rollback()
+		writeAuditError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
 		return
 	}
 
@@ -248,6 +362,8 @@ func DeleteBarang(w http.ResponseWriter, r *http.Request) {
 
 	log.Println(" Delete barang ID:", idBarang)
 
+	sebelum, _ := audit.Snapshot(database.DB, audit.EntitasBarang, idBarang)
+
 	result, err := database.DB.Exec("DELETE FROM barang WHERE id_barang=?", idBarang)
 	if err != nil {
 		log.Println(" Error delete barang:", err)
@@ -263,6 +379,8 @@ func DeleteBarang(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	audit.Catat(database.DB, r, audit.AksiDelete, audit.EntitasBarang, idBarang, sebelum)
+
 	log.Println(" Barang berhasil dihapus")
 	json.NewEncoder(w).Encode(map[string]string{"message": "Barang berhasil dihapus"})
 }
\ No newline at end of file
@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"service_hp/database"
+	"service_hp/models"
 	"time"
 )
 
@@ -18,6 +19,7 @@ type DashboardPegawaiStats struct {
 	TotalPendapatanBulanIni  float64                `json:"total_pendapatan_bulan_ini"`
 	ServisHariIni            []ServisHariIni        `json:"servis_hari_ini"`
 	BarangMenipis            []BarangMenipis        `json:"barang_menipis"`
+	BebanTeknisi             []models.BebanTeknisi  `json:"beban_teknisi"`
 }
 
 type DashboardAdminStats struct {
@@ -29,6 +31,7 @@ type DashboardAdminStats struct {
 	TotalPendapatanBulanIni  float64                `json:"total_pendapatan_bulan_ini"`
 	ServisHariIni            []ServisHariIni        `json:"servis_hari_ini"`
 	BarangMenipis            []BarangMenipis        `json:"barang_menipis"`
+	BebanTeknisi             []models.BebanTeknisi  `json:"beban_teknisi"`
 }
 
 type ServisHariIni struct {
@@ -91,7 +94,7 @@ type BarangMenipis struct {
 	err = database.DB.QueryRow(`
 		SELECT COUNT(*) 
 		FROM servis 
-		WHERE (status_servis = 'selesai' OR status_servis = 'siap_diambil')
+		WHERE status_servis IN ('selesai', 'siap_diambil', 'diambil')
 		AND DATE(tanggal_masuk) >= ? AND DATE(tanggal_masuk) <= ?
 	`, startOfMonthStr, endOfMonthStr).Scan(&stats.ServisSelesai)
 
@@ -116,7 +119,7 @@ type BarangMenipis struct {
 	err = database.DB.QueryRow(`
 		SELECT COALESCE(SUM(biaya_total), 0) 
 		FROM servis 
-		WHERE (status_servis = 'selesai' OR status_servis = 'siap_diambil')
+		WHERE status_servis IN ('selesai', 'siap_diambil', 'diambil')
 		AND DATE(tanggal_selesai) = ?
 	`, today).Scan(&stats.TotalPendapatanHariIni)
 	
@@ -129,7 +132,8 @@ type BarangMenipis struct {
 		err = database.DB.QueryRow(`
 			SELECT COALESCE(SUM(biaya_total), 0) 
 			FROM servis 
-			WHERE MONTH(tanggal_masuk) = MONTH(CURDATE())
+			WHERE status_servis <> 'batal'
+			AND MONTH(tanggal_masuk) = MONTH(CURDATE())
 			AND YEAR(tanggal_masuk) = YEAR(CURDATE())
 		`).Scan(&stats.TotalPendapatanBulanIni)
 		
@@ -202,6 +206,13 @@ type BarangMenipis struct {
 			}
 		}
 
+		// 9. Beban kerja per teknisi (selesai & rata-rata lama perbaikan bulan ini)
+		stats.BebanTeknisi, err = loadBebanTeknisi(startOfMonthStr)
+		if err != nil {
+			log.Println(" Error query beban teknisi:", err)
+			stats.BebanTeknisi = []models.BebanTeknisi{}
+		}
+
 		// Return response
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(stats)
@@ -250,7 +261,7 @@ type BarangMenipis struct {
 	err = database.DB.QueryRow(`
 		SELECT COUNT(*) 
 		FROM servis 
-		WHERE (status_servis = 'selesai' OR status_servis = 'siap_diambil')
+		WHERE status_servis IN ('selesai', 'siap_diambil', 'diambil')
 		AND DATE(tanggal_masuk) >= ? AND DATE(tanggal_masuk) <= ?
 	`, startOfMonthStr, endOfMonthStr).Scan(&stats.ServisSelesai)
 
@@ -275,7 +286,7 @@ type BarangMenipis struct {
 	err = database.DB.QueryRow(`
 		SELECT COALESCE(SUM(biaya_total), 0) 
 		FROM servis 
-		WHERE (status_servis = 'selesai' OR status_servis = 'siap_diambil')
+		WHERE status_servis IN ('selesai', 'siap_diambil', 'diambil')
 		AND DATE(tanggal_selesai) = ?
 	`, today).Scan(&stats.TotalPendapatanHariIni)
 	
@@ -288,7 +299,8 @@ type BarangMenipis struct {
 		err = database.DB.QueryRow(`
 			SELECT COALESCE(SUM(biaya_total), 0) 
 			FROM servis 
-			WHERE MONTH(tanggal_masuk) = MONTH(CURDATE())
+			WHERE status_servis <> 'batal'
+			AND MONTH(tanggal_masuk) = MONTH(CURDATE())
 			AND YEAR(tanggal_masuk) = YEAR(CURDATE())
 		`).Scan(&stats.TotalPendapatanBulanIni)
 		
@@ -361,6 +373,13 @@ type BarangMenipis struct {
 			}
 		}
 
+		// 9. Beban kerja per teknisi (selesai & rata-rata lama perbaikan bulan ini)
+		stats.BebanTeknisi, err = loadBebanTeknisi(startOfMonthStr)
+		if err != nil {
+			log.Println(" Error query beban teknisi:", err)
+			stats.BebanTeknisi = []models.BebanTeknisi{}
+		}
+
 		// Return response
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(stats)
@@ -386,8 +405,8 @@ func GetSimpleStats(w http.ResponseWriter, r *http.Request) {
 	database.DB.QueryRow(`
 		SELECT 
 			COUNT(*),
-			COALESCE(SUM(biaya_total), 0),
-			SUM(CASE WHEN status_servis IN ('selesai', 'siap_diambil') THEN 1 ELSE 0 END),
+			COALESCE(SUM(CASE WHEN status_servis <> 'batal' THEN biaya_total ELSE 0 END), 0),
+			SUM(CASE WHEN status_servis IN ('selesai', 'siap_diambil', 'diambil') THEN 1 ELSE 0 END),
 			SUM(CASE WHEN status_servis = 'dalam_perbaikan' THEN 1 ELSE 0 END)
 		FROM servis
 		WHERE DATE(tanggal_masuk) = ?
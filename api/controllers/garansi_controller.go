@@ -0,0 +1,447 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"service_hp/audit"
+	"service_hp/database"
+	"service_hp/models"
+	"strings"
+	"time"
+)
+
+// garansiSampaiSQL - tanggal habis garansi baris detail (alias ds) milik servis (alias s).
+// Garansi dihitung sejak servis selesai, NULL jika belum selesai / tanpa garansi.
+const garansiSampaiSQL = `CASE
+			WHEN ds.garansi_hari > 0 AND s.status_servis IN ('selesai', 'siap_diambil', 'diambil')
+			THEN DATE_ADD(DATE(s.tanggal_selesai), INTERVAL ds.garansi_hari DAY)
+		END`
+
+// Helper: isi garansi_hari detail yang kosong dari barang.garansi_hari (0 untuk item non-barang)
+func assignGaransi(q queryer, details []models.DetailServis) error {
+	for i := range details {
+		d := &details[i]
+		if d.GaransiHari != nil {
+			continue
+		}
+		hari := 0
+		if d.IDBarang != nil {
+			err := q.QueryRow(`SELECT garansi_hari FROM barang WHERE id_barang = ?`, *d.IDBarang).Scan(&hari)
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("%w (id %d)", errBarangTidakDitemukan, *d.IDBarang)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		d.GaransiHari = &hari
+	}
+	return nil
+}
+
+// Helper: format tanggal habis garansi hasil garansiSampaiSQL
+func formatGaransiSampai(t sql.NullTime) *string {
+	if !t.Valid {
+		return nil
+	}
+	s := t.Time.Format("2006-01-02")
+	return &s
+}
+
+// Helper: garansi tiap baris detail servis yang punya garansi
+func loadGaransi(q queryer, idServis int) ([]models.GaransiItem, error) {
+	rows, err := q.Query(`
+		SELECT ds.id_detail, COALESCE(ds.deskripsi, ''), ds.garansi_hari,
+			`+garansiSampaiSQL+`,
+			COALESCE(`+garansiSampaiSQL+` >= CURDATE(), 0)
+		FROM detail_servis ds
+		INNER JOIN servis s ON s.id_servis = ds.id_servis
+		WHERE ds.id_servis = ? AND ds.garansi_hari > 0
+		ORDER BY ds.id_detail ASC
+	`, idServis)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.GaransiItem{}
+	for rows.Next() {
+		var g models.GaransiItem
+		var sampai sql.NullTime
+		if err := rows.Scan(&g.IDDetail, &g.Deskripsi, &g.GaransiHari, &sampai, &g.Aktif); err != nil {
+			return nil, err
+		}
+		g.GaransiSampai = formatGaransiSampai(sampai)
+		list = append(list, g)
+	}
+	return list, rows.Err()
+}
+
+// modalKlaimSQL - modal barang per servis, untuk LEFT JOIN ke servis klaim (alias c)
+const modalKlaimSQL = `LEFT JOIN (
+			SELECT id_servis, SUM(jumlah * harga_modal) AS modal
+			FROM detail_servis GROUP BY id_servis
+		) m ON m.id_servis = c.id_servis`
+
+// Helper: rekap klaim garansi yang masuk dalam rentang tanggal.
+// Biaya klaim = modal barang servis klaim - biaya_total yang tetap ditagih ke pelanggan.
+// Per barang: modal baris - harga baris tsb; per teknisi: dikelompokkan ke teknisi servis asal.
+func rekapGaransi(q queryer, awal, akhir string) (models.RekapGaransi, error) {
+	rekap := models.RekapGaransi{
+		TanggalAwal:  awal,
+		TanggalAkhir: akhir,
+		PerBarang:    []models.BiayaGaransi{},
+		PerTeknisi:   []models.BiayaGaransi{},
+	}
+
+	err := q.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(COALESCE(m.modal, 0) - c.biaya_total), 0)
+		FROM servis c
+		`+modalKlaimSQL+`
+		WHERE c.id_servis_asal IS NOT NULL AND DATE(c.tanggal_masuk) BETWEEN ? AND ?
+	`, awal, akhir).Scan(&rekap.TotalKlaim, &rekap.TotalBiaya)
+	if err != nil {
+		return rekap, err
+	}
+	rekap.TotalBiaya = roundRupiah(rekap.TotalBiaya)
+
+	rows, err := q.Query(`
+		SELECT ds.id_barang, COALESCE(MAX(b.nama_barang), MAX(ds.deskripsi), ''), COUNT(DISTINCT c.id_servis),
+			SUM(ds.jumlah * ds.harga_modal - ds.biaya)
+		FROM servis c
+		INNER JOIN detail_servis ds ON ds.id_servis = c.id_servis
+		LEFT JOIN barang b ON b.id_barang = ds.id_barang
+		WHERE c.id_servis_asal IS NOT NULL AND DATE(c.tanggal_masuk) BETWEEN ? AND ?
+		GROUP BY ds.id_barang, CASE WHEN ds.id_barang IS NULL THEN ds.deskripsi END
+		ORDER BY 4 DESC, 2
+	`, awal, akhir)
+	if err != nil {
+		return rekap, err
+	}
+	rekap.PerBarang, err = scanBiayaGaransi(rows, models.GaransiPerBarang)
+	if err != nil {
+		return rekap, err
+	}
+
+	rows, err = q.Query(`
+		SELECT a.id_teknisi, COALESCE(p.nama_pegawai, 'Tanpa teknisi'), COUNT(*),
+			SUM(COALESCE(m.modal, 0) - c.biaya_total)
+		FROM servis c
+		INNER JOIN servis a ON a.id_servis = c.id_servis_asal
+		LEFT JOIN pegawai p ON p.id_pegawai = a.id_teknisi
+		`+modalKlaimSQL+`
+		WHERE DATE(c.tanggal_masuk) BETWEEN ? AND ?
+		GROUP BY a.id_teknisi, p.nama_pegawai
+		ORDER BY 4 DESC, 2
+	`, awal, akhir)
+	if err != nil {
+		return rekap, err
+	}
+	rekap.PerTeknisi, err = scanBiayaGaransi(rows, models.GaransiPerTeknisi)
+	return rekap, err
+}
+
+// Helper: scan baris (id_ref, nama, jumlah_klaim, biaya) rekap garansi
+func scanBiayaGaransi(rows *sql.Rows, kategori string) ([]models.BiayaGaransi, error) {
+	defer rows.Close()
+	list := []models.BiayaGaransi{}
+	for rows.Next() {
+		b := models.BiayaGaransi{Kategori: kategori}
+		var idRef sql.NullInt64
+		if err := rows.Scan(&idRef, &b.Nama, &b.JumlahKlaim, &b.Biaya); err != nil {
+			return nil, err
+		}
+		b.IDRef = nullIntPtr(idRef)
+		b.Biaya = roundRupiah(b.Biaya)
+		list = append(list, b)
+	}
+	return list, rows.Err()
+}
+
+// klaimError - klaim garansi ditolak (409)
+type klaimError struct {
+	pesan    string
+	idServis int // klaim yang masih berjalan, jika ada
+}
+
+func (e *klaimError) Error() string {
+	return e.pesan
+}
+
+// Helper: pastikan servis asal boleh diklaim: sudah diambil, masih ada garansi aktif,
+// dan belum ada klaim lain yang masih berjalan
+func validateKlaimGaransi(tx *sql.Tx, idAsal int, status string) error {
+	if status != models.StatusDiambil {
+		return &klaimError{pesan: "Klaim garansi hanya untuk servis yang sudah diambil pelanggan"}
+	}
+
+	garansi, err := loadGaransi(tx, idAsal)
+	if err != nil {
+		return err
+	}
+	aktif := false
+	for _, g := range garansi {
+		aktif = aktif || g.Aktif
+	}
+	if !aktif {
+		return &klaimError{pesan: "Servis ini tidak punya garansi yang masih berlaku"}
+	}
+
+	var idKlaim int
+	err = tx.QueryRow(`
+		SELECT id_servis FROM servis
+		WHERE id_servis_asal = ? AND status_servis NOT IN ('diambil', 'batal')
+		LIMIT 1
+	`, idAsal).Scan(&idKlaim)
+	if err == nil {
+		return &klaimError{pesan: "Masih ada klaim garansi yang belum selesai untuk servis ini", idServis: idKlaim}
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+	return nil
+}
+
+// =======================================================
+// KLAIM GARANSI (buka servis baru tertaut servis asal, biaya 0)
+// =======================================================
+func KlaimGaransi(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	idAsal, err := extractServisSubID(r.URL.Path, "klaim-garansi")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.KlaimGaransiRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+	req.Keluhan = strings.TrimSpace(req.Keluhan)
+	if req.Keluhan == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Keluhan klaim garansi wajib diisi"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var idPelanggan sql.NullInt64
+	var namaPelanggan, tipeHP, status string
+	var noWhatsapp sql.NullString
+	err = tx.QueryRow(`
+		SELECT id_pelanggan, nama_pelanggan, no_whatsapp, tipe_hp, status_servis
+		FROM servis WHERE id_servis = ? FOR UPDATE
+	`, idAsal).Scan(&idPelanggan, &namaPelanggan, &noWhatsapp, &tipeHP, &status)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Servis tidak ditemukan"})
+		return
+	}
+	if err != nil {
+		log.Println(" Error get servis asal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	if err := validateKlaimGaransi(tx, idAsal, status); err != nil {
+		var ke *klaimError
+		if errors.As(err, &ke) {
+			resp := map[string]interface{}{"error": ke.Error()}
+			if ke.idServis > 0 {
+				resp["id_servis_klaim"] = ke.idServis
+			}
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(resp)
+			return
+		}
+		log.Println(" Error cek garansi:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	if req.IDTeknisi != nil {
+		if err := validateTeknisi(tx, *req.IDTeknisi); err != nil {
+			writeTeknisiError(w, err)
+			return
+		}
+	}
+
+	kode, err := generateKodeTracking()
+	if err != nil {
+		log.Println(" Error generate kode tracking:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	res, err := tx.Exec(`
+		INSERT INTO servis (id_pelanggan, id_teknisi, id_servis_asal, kode_tracking, nama_pelanggan, no_whatsapp, tipe_hp, keluhan, status_servis, biaya_servis, biaya_total, tanggal_masuk)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 0, 0, NOW())
+	`, idPelanggan, req.IDTeknisi, idAsal, kode, namaPelanggan, noWhatsapp, tipeHP, req.Keluhan, models.StatusPending)
+	if err != nil {
+		log.Println(" Error insert klaim garansi:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal membuat klaim garansi"})
+		return
+	}
+	newID64, _ := res.LastInsertId()
+	newID := int(newID64)
+
+	keterangan := fmt.Sprintf("Klaim garansi servis #%d", idAsal)
+	if err := recordStatusHistory(tx, newID, nil, models.StatusPending, currentUserID(r), keterangan); err != nil {
+		log.Println(" Error insert riwayat status:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+	if req.IDTeknisi != nil {
+		if err := recordTeknisiHistory(tx, newID, nil, req.IDTeknisi, currentUserID(r), keterangan); err != nil {
+			log.Println(" Error insert riwayat teknisi:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+			return
+		}
+	}
+
+	// Identitas perangkat ikut dari servis asal agar riwayat IMEI tetap tersambung
+	if _, err := tx.Exec(`
+		INSERT INTO servis_intake (id_servis, merek, model, imei, serial_number, warna)
+		SELECT ?, merek, model, imei, serial_number, warna FROM servis_intake WHERE id_servis = ?
+	`, newID, idAsal); err != nil {
+		log.Println(" Error salin intake klaim garansi:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	if err := audit.Record(tx, r, audit.Entry{Aksi: audit.AksiCreate, Entitas: audit.EntitasServis, IDEntitas: newID, Sesudah: snapshotServis(tx, newID)}); err != nil {
+		writeAuditError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":        "Klaim garansi berhasil dibuat",
+		"id_servis":      newID,
+		"id_servis_asal": idAsal,
+		"kode_tracking":  kode,
+	})
+}
+
+// =======================================================
+// GET GARANSI SERVIS (garansi per item + daftar klaim)
+// =======================================================
+func GetGaransiServis(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractServisSubID(r.URL.Path, "garansi")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	var idAsal sql.NullInt64
+	err = database.DB.QueryRow(`SELECT id_servis_asal FROM servis WHERE id_servis = ?`, id).Scan(&idAsal)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Servis tidak ditemukan"})
+		return
+	}
+	if err != nil {
+		log.Println(" Error get servis:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	garansi, err := loadGaransi(database.DB, id)
+	if err != nil {
+		log.Println(" Error load garansi:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	rows, err := database.DB.Query(servisListQuery+`
+		WHERE s.id_servis_asal = ?
+		ORDER BY s.id_servis ASC
+	`, id)
+	if err != nil {
+		log.Println(" Error query klaim garansi:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+	defer rows.Close()
+
+	klaim := scanServisList(rows)
+	if klaim == nil {
+		klaim = []models.Servis{}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id_servis":      id,
+		"id_servis_asal": nullIntPtr(idAsal),
+		"garansi":        garansi,
+		"klaim":          klaim,
+	})
+}
+
+// =======================================================
+// REKAP BIAYA GARANSI per barang & per teknisi
+// ?dari=YYYY-MM-DD&sampai=YYYY-MM-DD, default bulan ini
+// =======================================================
+func GetRekapGaransi(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	now := time.Now()
+	awal := r.URL.Query().Get("dari")
+	akhir := r.URL.Query().Get("sampai")
+	if awal == "" {
+		awal = now.Format("2006-01") + "-01"
+	}
+	if akhir == "" {
+		akhir = now.Format("2006-01-02")
+	}
+	if err := validatePeriode(awal, akhir); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	rekap, err := rekapGaransi(database.DB, awal, akhir)
+	if err != nil {
+		log.Println(" Error rekap garansi:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal menghitung rekap garansi"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(rekap)
+}
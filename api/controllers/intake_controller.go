@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"service_hp/config"
+	"service_hp/database"
+	"service_hp/models"
+	"service_hp/nota"
+	"service_hp/routes/middleware"
+	"strings"
+	"time"
+)
+
+// Helper: IMEI hanya angka, spasi / tanda hubung dari input dibuang
+func normalizeIMEI(input string) string {
+	var b strings.Builder
+	for _, c := range input {
+		if c >= '0' && c <= '9' {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// Helper: cek digit terakhir IMEI (algoritma Luhn)
+func imeiValid(imei string) bool {
+	if len(imei) != 15 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 15; i++ {
+		d := int(imei[14-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// Helper: normalisasi & validasi checklist penerimaan perangkat
+func validateIntake(in *models.ServisIntake) error {
+	in.Merek = truncate(strings.TrimSpace(in.Merek), 50)
+	in.Model = truncate(strings.TrimSpace(in.Model), 100)
+	in.Warna = truncate(strings.TrimSpace(in.Warna), 30)
+	in.SerialNumber = truncate(strings.ToUpper(strings.TrimSpace(in.SerialNumber)), 50)
+	in.Kelengkapan.Lainnya = truncate(strings.TrimSpace(in.Kelengkapan.Lainnya), 255)
+	in.KondisiFisik = strings.TrimSpace(in.KondisiFisik)
+	in.Catatan = truncate(strings.TrimSpace(in.Catatan), 255)
+
+	if in.IMEI != "" {
+		in.IMEI = normalizeIMEI(in.IMEI)
+		if !imeiValid(in.IMEI) {
+			return errors.New("IMEI harus 15 digit angka yang valid")
+		}
+	}
+
+	in.KunciLayar = strings.ToLower(strings.TrimSpace(in.KunciLayar))
+	switch in.KunciLayar {
+	case "":
+		in.KunciLayar = models.KunciTidakAda
+	case models.KunciTidakAda, models.KunciPola, models.KunciPIN, models.KunciPassword:
+	default:
+		return errors.New("Kunci layar harus tidak_ada, pola, pin, atau password")
+	}
+
+	if in.KodeKunci != nil {
+		kode := strings.TrimSpace(*in.KodeKunci)
+		if len(kode) > 100 {
+			return errors.New("Kode kunci terlalu panjang")
+		}
+		in.KodeKunci = &kode
+	}
+	if in.KunciLayar == models.KunciTidakAda && in.KodeKunci != nil && *in.KodeKunci != "" {
+		return errors.New("Kode kunci diisi padahal kunci layar tidak_ada")
+	}
+	return nil
+}
+
+// Helper: simpan (insert / update) checklist penerimaan servis.
+// Kode kunci hanya diubah jika dikirim, dan selalu dihapus bila kunci layar tidak_ada.
+func saveIntake(tx *sql.Tx, idServis int, in *models.ServisIntake) error {
+	_, err := tx.Exec(`
+		INSERT INTO servis_intake (
+			id_servis, merek, model, imei, serial_number, warna,
+			ada_charger, ada_casing, ada_simcard, ada_memory_card, kelengkapan_lain,
+			kondisi_fisik, kunci_layar, setuju_risiko_data, setuju_buka_segel, setuju_tanpa_cek, catatan
+		) VALUES (?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			merek = VALUES(merek), model = VALUES(model), imei = VALUES(imei),
+			serial_number = VALUES(serial_number), warna = VALUES(warna),
+			ada_charger = VALUES(ada_charger), ada_casing = VALUES(ada_casing),
+			ada_simcard = VALUES(ada_simcard), ada_memory_card = VALUES(ada_memory_card),
+			kelengkapan_lain = VALUES(kelengkapan_lain), kondisi_fisik = VALUES(kondisi_fisik),
+			kunci_layar = VALUES(kunci_layar), setuju_risiko_data = VALUES(setuju_risiko_data),
+			setuju_buka_segel = VALUES(setuju_buka_segel), setuju_tanpa_cek = VALUES(setuju_tanpa_cek),
+			catatan = VALUES(catatan)
+	`, idServis, in.Merek, in.Model, in.IMEI, in.SerialNumber, in.Warna,
+		in.Kelengkapan.Charger, in.Kelengkapan.Casing, in.Kelengkapan.SimCard, in.Kelengkapan.MemoryCard, in.Kelengkapan.Lainnya,
+		in.KondisiFisik, in.KunciLayar, in.Persetujuan.RisikoData, in.Persetujuan.BukaSegel, in.Persetujuan.TanpaCek, in.Catatan)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case in.KunciLayar == models.KunciTidakAda:
+		_, err = tx.Exec(`UPDATE servis_intake SET kode_kunci = NULL WHERE id_servis = ?`, idServis)
+	case in.KodeKunci != nil:
+		_, err = tx.Exec(`UPDATE servis_intake SET kode_kunci = NULLIF(?, '') WHERE id_servis = ?`, *in.KodeKunci, idServis)
+	}
+	return err
+}
+
+// Helper: ambil checklist penerimaan servis, nil jika belum diisi.
+// Kode kunci hanya diisi jika tampilkanKode.
+func loadIntake(q queryer, idServis int, tampilkanKode bool) (*models.ServisIntake, error) {
+	var in models.ServisIntake
+	var kode sql.NullString
+	err := q.QueryRow(`
+		SELECT merek, model, COALESCE(imei, ''), COALESCE(serial_number, ''), warna,
+			ada_charger, ada_casing, ada_simcard, ada_memory_card, kelengkapan_lain,
+			COALESCE(kondisi_fisik, ''), kunci_layar, kode_kunci,
+			setuju_risiko_data, setuju_buka_segel, setuju_tanpa_cek, catatan
+		FROM servis_intake WHERE id_servis = ?
+	`, idServis).Scan(&in.Merek, &in.Model, &in.IMEI, &in.SerialNumber, &in.Warna,
+		&in.Kelengkapan.Charger, &in.Kelengkapan.Casing, &in.Kelengkapan.SimCard, &in.Kelengkapan.MemoryCard, &in.Kelengkapan.Lainnya,
+		&in.KondisiFisik, &in.KunciLayar, &kode,
+		&in.Persetujuan.RisikoData, &in.Persetujuan.BukaSegel, &in.Persetujuan.TanpaCek, &in.Catatan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	in.KodeKunciTersimpan = kode.Valid
+	if kode.Valid && tampilkanKode {
+		in.KodeKunci = &kode.String
+	}
+	return &in, nil
+}
+
+// Helper: kode kunci hanya untuk yang mengerjakan servis
+func bolehLihatKodeKunci(r *http.Request) bool {
+	return middleware.HasPermission(r.Context(), middleware.PermServisKerjakan)
+}
+
+// =======================================================
+// CETAK TANDA TERIMA SERVIS PDF
+// ?format=a4 (default) | 58 | 80
+// =======================================================
+func GetTandaTerimaPDF(w http.ResponseWriter, r *http.Request) {
+	id, err := extractServisSubID(r.URL.Path, "tanda-terima.pdf")
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = models.NotaA4
+	}
+	if !nota.FormatValid(format) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Format harus a4, 58, atau 80"})
+		return
+	}
+
+	d := models.TandaTerima{
+		NamaToko:    config.Cfg.Notif.NamaToko,
+		AlamatToko:  config.Cfg.Nota.Alamat,
+		TeleponToko: config.Cfg.Nota.Telepon,
+		CatatanKaki: config.Cfg.Nota.CatatanTerima,
+		IDServis:    id,
+	}
+	var masuk time.Time
+	err = database.DB.QueryRow(`
+		SELECT s.kode_tracking, s.tanggal_masuk, s.nama_pelanggan, COALESCE(s.no_whatsapp, ''), s.tipe_hp,
+			COALESCE(s.keluhan, ''), s.biaya_total, `+totalDibayarSQL+`
+		FROM servis s WHERE s.id_servis = ?
+	`, id).Scan(&d.KodeTracking, &masuk, &d.NamaPelanggan, &d.NoWhatsapp, &d.TipeHP,
+		&d.Keluhan, &d.BiayaTotal, &d.TotalDibayar)
+	if err == sql.ErrNoRows {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Servis tidak ditemukan"})
+		return
+	}
+	if err == nil {
+		d.TanggalMasuk = masuk.Format(formatTanggalNota)
+		d.Intake, err = loadIntake(database.DB, id, false)
+	}
+	var pdf []byte
+	if err == nil {
+		pdf, err = nota.TandaTerimaPDF(d, format)
+	}
+	if err != nil {
+		log.Println(" Error cetak tanda terima:", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal mencetak tanda terima"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="tanda-terima-%s.pdf"`, d.KodeTracking))
+	w.Write(pdf)
+}
@@ -0,0 +1,45 @@
+package controllers
+
+import "testing"
+
+func TestNormalizeIMEI(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"490154203237518", "490154203237518"},
+		{"49-015420-323751-8", "490154203237518"},
+		{" 4901 5420 3237 518 ", "490154203237518"},
+		{"IMEI: 35209900176148/1", "352099001761481"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := normalizeIMEI(tt.input); got != tt.want {
+				t.Errorf("normalizeIMEI(%q) = %q, seharusnya %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIMEIValid(t *testing.T) {
+	tests := []struct {
+		imei  string
+		valid bool
+	}{
+		{"490154203237518", true},
+		{"352099001761481", true},
+		{"490154203237519", false}, // digit cek salah
+		{"49015420323751", false},  // 14 digit
+		{"4901542032375180", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.imei, func(t *testing.T) {
+			if got := imeiValid(tt.imei); got != tt.valid {
+				t.Errorf("imeiValid(%q) = %v, seharusnya %v", tt.imei, got, tt.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,518 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"service_hp/audit"
+	"service_hp/database"
+	"service_hp/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Helper: validasi rentang tanggal YYYY-MM-DD (inklusif)
+func validatePeriode(awal, akhir string) error {
+	a, err := time.Parse("2006-01-02", awal)
+	if err != nil {
+		return errors.New("tanggal_awal harus format YYYY-MM-DD")
+	}
+	b, err := time.Parse("2006-01-02", akhir)
+	if err != nil {
+		return errors.New("tanggal_akhir harus format YYYY-MM-DD")
+	}
+	if b.Before(a) {
+		return errors.New("tanggal_akhir tidak boleh sebelum tanggal_awal")
+	}
+	return nil
+}
+
+// Helper: validasi aturan komisi
+func validateKomisiAturan(jenis string, nilai float64) error {
+	switch jenis {
+	case models.KomisiPersenBiaya, models.KomisiPersenLaba:
+		if nilai < 0 || nilai > 100 {
+			return errors.New("Persentase komisi harus 0 - 100")
+		}
+	case models.KomisiTetap:
+		if nilai < 0 {
+			return errors.New("Komisi tetap tidak boleh negatif")
+		}
+	default:
+		return errors.New("Jenis komisi harus persen_biaya, tetap, atau persen_laba")
+	}
+	return nil
+}
+
+// Helper: komisi satu servis. Laba negatif tidak memotong komisi.
+func nilaiKomisi(jenis string, nilai, biayaServis, laba float64) float64 {
+	var k float64
+	switch jenis {
+	case models.KomisiPersenBiaya:
+		k = biayaServis * nilai / 100
+	case models.KomisiTetap:
+		k = nilai
+	case models.KomisiPersenLaba:
+		k = math.Max(laba, 0) * nilai / 100
+	}
+	return math.Round(k*100) / 100
+}
+
+// Helper: komisi servis yang selesai (selesai, siap_diambil, diambil) dalam rentang tanggal_selesai.
+// Klaim garansi tidak berkomisi karena memperbaiki ulang pekerjaan yang sudah dibayar.
+// Servis yang sudah dikunci di periode komisi memakai nilai yang tersimpan saat dikunci,
+// sisanya dihitung dari aturan teknisi saat ini.
+func hitungKomisi(q queryer, awal, akhir string) ([]models.KomisiServis, error) {
+	rows, err := q.Query(`
+		SELECT s.id_servis, COALESCE(kd.id_pegawai, s.id_teknisi), COALESCE(kd.nama_teknisi, p.nama_pegawai, ''),
+			s.tanggal_selesai, s.biaya_servis,
+			s.biaya_total - COALESCE((
+				SELECT SUM(ds.jumlah * ds.harga_modal) FROM detail_servis ds WHERE ds.id_servis = s.id_servis
+			), 0),
+			COALESCE(a.jenis, ''), COALESCE(a.nilai, 0),
+			kd.id_periode, COALESCE(kd.biaya_servis, 0), COALESCE(kd.laba, 0),
+			COALESCE(kd.jenis, ''), COALESCE(kd.nilai, 0), COALESCE(kd.komisi, 0)
+		FROM servis s
+		LEFT JOIN komisi_detail kd ON kd.id_servis = s.id_servis
+		LEFT JOIN pegawai p ON p.id_pegawai = s.id_teknisi
+		LEFT JOIN komisi_aturan a ON a.id_pegawai = s.id_teknisi
+		WHERE (s.id_teknisi IS NOT NULL OR kd.id_servis IS NOT NULL)
+			AND (s.id_servis_asal IS NULL OR kd.id_servis IS NOT NULL)
+			AND s.status_servis IN ('selesai', 'siap_diambil', 'diambil')
+			AND DATE(s.tanggal_selesai) BETWEEN ? AND ?
+		ORDER BY s.tanggal_selesai, s.id_servis
+	`, awal, akhir)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.KomisiServis{}
+	for rows.Next() {
+		var k models.KomisiServis
+		var tglSelesai sql.NullString
+		var idPeriode sql.NullInt64
+		var kunci models.KomisiServis
+		if err := rows.Scan(&k.IDServis, &k.IDPegawai, &k.NamaTeknisi, &tglSelesai, &k.BiayaServis, &k.Laba,
+			&k.Jenis, &k.Nilai, &idPeriode, &kunci.BiayaServis, &kunci.Laba, &kunci.Jenis, &kunci.Nilai, &kunci.Komisi); err != nil {
+			return nil, err
+		}
+		if tglSelesai.Valid {
+			k.TanggalSelesai = &tglSelesai.String
+		}
+
+		if idPeriode.Valid {
+			k.IDPeriode = nullIntPtr(idPeriode)
+			k.BiayaServis, k.Laba, k.Jenis, k.Nilai, k.Komisi = kunci.BiayaServis, kunci.Laba, kunci.Jenis, kunci.Nilai, kunci.Komisi
+		} else {
+			k.Laba = math.Round(k.Laba*100) / 100
+			k.Komisi = nilaiKomisi(k.Jenis, k.Nilai, k.BiayaServis, k.Laba)
+		}
+		list = append(list, k)
+	}
+	return list, rows.Err()
+}
+
+// Helper: rekap komisi per teknisi (urut nama) dan totalnya
+func rekapKomisi(list []models.KomisiServis) ([]models.KomisiTeknisi, float64) {
+	idx := map[int]int{}
+	rekap := []models.KomisiTeknisi{}
+	var total float64
+	for _, k := range list {
+		i, ok := idx[k.IDPegawai]
+		if !ok {
+			i = len(rekap)
+			idx[k.IDPegawai] = i
+			rekap = append(rekap, models.KomisiTeknisi{IDPegawai: k.IDPegawai, NamaTeknisi: k.NamaTeknisi})
+		}
+		rekap[i].JumlahServis++
+		rekap[i].TotalKomisi = math.Round((rekap[i].TotalKomisi+k.Komisi)*100) / 100
+		total += k.Komisi
+	}
+	sort.Slice(rekap, func(a, b int) bool { return rekap[a].NamaTeknisi < rekap[b].NamaTeknisi })
+	return rekap, math.Round(total*100) / 100
+}
+
+// =======================================================
+// ATURAN KOMISI
+// =======================================================
+
+// GET: teknisi aktif (dan pegawai lain yang masih punya aturan) beserta aturan komisinya
+func GetKomisiAturan(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rows, err := database.DB.Query(`
+		SELECT p.id_pegawai, p.nama_pegawai, COALESCE(a.jenis, ''), COALESCE(a.nilai, 0), a.updated_at
+		FROM pegawai p
+		LEFT JOIN komisi_aturan a ON a.id_pegawai = p.id_pegawai
+		WHERE (LOWER(p.jabatan) = 'teknisi' AND p.status = 'aktif') OR a.id_pegawai IS NOT NULL
+		ORDER BY p.nama_pegawai
+	`)
+	if err != nil {
+		log.Println(" Error query aturan komisi:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	list := []models.KomisiAturan{}
+	for rows.Next() {
+		var a models.KomisiAturan
+		var updatedAt sql.NullString
+		if err := rows.Scan(&a.IDPegawai, &a.NamaTeknisi, &a.Jenis, &a.Nilai, &updatedAt); err != nil {
+			log.Println(" Error scan aturan komisi:", err)
+			continue
+		}
+		if updatedAt.Valid {
+			a.UpdatedAt = &updatedAt.String
+		}
+		list = append(list, a)
+	}
+
+	json.NewEncoder(w).Encode(list)
+}
+
+// PUT: atur komisi satu teknisi. Periode yang sudah dikunci tidak ikut berubah.
+func SetKomisiAturan(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractLastID(r.URL.Path)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.SetKomisiAturanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+	req.Jenis = strings.ToLower(strings.TrimSpace(req.Jenis))
+	if err := validateKomisiAturan(req.Jenis, req.Nilai); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	if err := validateTeknisi(database.DB, id); err != nil {
+		writeTeknisiError(w, err)
+		return
+	}
+
+	sebelum, _ := audit.Snapshot(database.DB, audit.EntitasKomisiAturan, id)
+
+	_, err = database.DB.Exec(`
+		INSERT INTO komisi_aturan (id_pegawai, jenis, nilai, id_user)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE jenis = VALUES(jenis), nilai = VALUES(nilai), id_user = VALUES(id_user)
+	`, id, req.Jenis, req.Nilai, currentUserID(r))
+	if err != nil {
+		log.Println(" Error simpan aturan komisi:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal menyimpan aturan komisi"})
+		return
+	}
+
+	aksi := audit.AksiUpdate
+	if sebelum == nil {
+		aksi = audit.AksiCreate
+	}
+	audit.Catat(database.DB, r, aksi, audit.EntitasKomisiAturan, id, sebelum)
+
+	json.NewEncoder(w).Encode(map[string]string{"message": "Aturan komisi berhasil disimpan"})
+}
+
+// DELETE: hapus aturan komisi, servis berikutnya dihitung tanpa komisi
+func DeleteKomisiAturan(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractLastID(r.URL.Path)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	sebelum, _ := audit.Snapshot(database.DB, audit.EntitasKomisiAturan, id)
+	if sebelum == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Aturan komisi tidak ditemukan"})
+		return
+	}
+
+	if _, err := database.DB.Exec(`DELETE FROM komisi_aturan WHERE id_pegawai = ?`, id); err != nil {
+		log.Println(" Error hapus aturan komisi:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal menghapus aturan komisi"})
+		return
+	}
+
+	audit.Catat(database.DB, r, audit.AksiDelete, audit.EntitasKomisiAturan, id, sebelum)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Aturan komisi berhasil dihapus"})
+}
+
+// =======================================================
+// PRATINJAU KOMISI (?dari=YYYY-MM-DD&sampai=YYYY-MM-DD)
+// =======================================================
+func GetKomisi(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	p := models.KomisiPeriode{
+		TanggalAwal:  r.URL.Query().Get("dari"),
+		TanggalAkhir: r.URL.Query().Get("sampai"),
+	}
+	if err := validatePeriode(p.TanggalAwal, p.TanggalAkhir); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	detail, err := hitungKomisi(database.DB, p.TanggalAwal, p.TanggalAkhir)
+	if err != nil {
+		log.Println(" Error hitung komisi:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal menghitung komisi"})
+		return
+	}
+	p.Detail = detail
+	p.Teknisi, p.TotalKomisi = rekapKomisi(detail)
+
+	json.NewEncoder(w).Encode(p)
+}
+
+// =======================================================
+// KUNCI PERIODE KOMISI
+// Nilai komisi servis yang belum dikunci disalin ke komisi_detail
+// =======================================================
+func KunciKomisi(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req models.KunciKomisiRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+	if err := validatePeriode(req.TanggalAwal, req.TanggalAkhir); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+	defer tx.Rollback()
+
+	// Periode tidak boleh tumpang tindih; FOR UPDATE menahan penguncian bersamaan
+	var bentrok int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM komisi_periode
+		WHERE tanggal_awal <= ? AND tanggal_akhir >= ?
+		FOR UPDATE
+	`, req.TanggalAkhir, req.TanggalAwal).Scan(&bentrok)
+	if err != nil {
+		log.Println(" Error cek periode komisi:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+	if bentrok > 0 {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Periode bertumpang tindih dengan periode komisi yang sudah dikunci"})
+		return
+	}
+
+	semua, err := hitungKomisi(tx, req.TanggalAwal, req.TanggalAkhir)
+	if err != nil {
+		log.Println(" Error hitung komisi:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal menghitung komisi"})
+		return
+	}
+	var detail []models.KomisiServis
+	for _, k := range semua {
+		if k.IDPeriode == nil {
+			detail = append(detail, k)
+		}
+	}
+	if len(detail) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Tidak ada servis selesai yang belum dikunci pada periode ini"})
+		return
+	}
+	_, total := rekapKomisi(detail)
+
+	res, err := tx.Exec(`
+		INSERT INTO komisi_periode (tanggal_awal, tanggal_akhir, total_komisi, keterangan, id_user)
+		VALUES (?, ?, ?, ?, ?)
+	`, req.TanggalAwal, req.TanggalAkhir, total, truncate(strings.TrimSpace(req.Keterangan), 255), currentUserID(r))
+	if err != nil {
+		log.Println(" Error insert periode komisi:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal mengunci periode komisi"})
+		return
+	}
+	idPeriode64, _ := res.LastInsertId()
+	idPeriode := int(idPeriode64)
+
+	for _, k := range detail {
+		_, err := tx.Exec(`
+			INSERT INTO komisi_detail (id_periode, id_servis, id_pegawai, nama_teknisi, tanggal_selesai, biaya_servis, laba, jenis, nilai, komisi)
+			VALUES (?, ?, ?, ?, (SELECT tanggal_selesai FROM servis WHERE id_servis = ?), ?, ?, ?, ?, ?)
+		`, idPeriode, k.IDServis, k.IDPegawai, k.NamaTeknisi, k.IDServis, k.BiayaServis, k.Laba, k.Jenis, k.Nilai, k.Komisi)
+		if err != nil {
+			log.Println(" Error insert detail komisi:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Gagal mengunci periode komisi"})
+			return
+		}
+	}
+
+	if err := audit.CatatTx(tx, r, audit.AksiCreate, audit.EntitasKomisiPeriode, idPeriode, nil); err != nil {
+		writeAuditError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":       "Periode komisi berhasil dikunci",
+		"id_periode":    idPeriode,
+		"jumlah_servis": len(detail),
+		"total_komisi":  total,
+	})
+}
+
+// =======================================================
+// GET ALL PERIODE KOMISI
+// =======================================================
+func GetAllKomisiPeriode(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rows, err := database.DB.Query(`
+		SELECT k.id_periode, k.tanggal_awal, k.tanggal_akhir, k.total_komisi, COALESCE(k.keterangan, ''),
+			k.id_user, COALESCE(u.nama, ''), k.dikunci_at
+		FROM komisi_periode k
+		LEFT JOIN user u ON k.id_user = u.id_user
+		ORDER BY k.tanggal_awal DESC
+	`)
+	if err != nil {
+		log.Println(" Error query periode komisi:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	list := []models.KomisiPeriode{}
+	for rows.Next() {
+		p, err := scanKomisiPeriode(rows)
+		if err != nil {
+			log.Println(" Error scan periode komisi:", err)
+			continue
+		}
+		list = append(list, p)
+	}
+
+	json.NewEncoder(w).Encode(list)
+}
+
+// rowScanner dipenuhi *sql.Row maupun *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// Helper: scan satu baris komisi_periode (tanpa detail)
+func scanKomisiPeriode(row rowScanner) (models.KomisiPeriode, error) {
+	var p models.KomisiPeriode
+	var idUser sql.NullInt64
+	var dikunciAt string
+	err := row.Scan(&p.IDPeriode, &p.TanggalAwal, &p.TanggalAkhir, &p.TotalKomisi, &p.Keterangan,
+		&idUser, &p.NamaUser, &dikunciAt)
+	p.IDUser = nullIntPtr(idUser)
+	p.DikunciAt = &dikunciAt
+	return p, err
+}
+
+// =======================================================
+// GET DETAIL PERIODE KOMISI (nilai saat dikunci)
+// =======================================================
+func GetKomisiPeriodeDetail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractLastID(r.URL.Path)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	p, err := scanKomisiPeriode(database.DB.QueryRow(`
+		SELECT k.id_periode, k.tanggal_awal, k.tanggal_akhir, k.total_komisi, COALESCE(k.keterangan, ''),
+			k.id_user, COALESCE(u.nama, ''), k.dikunci_at
+		FROM komisi_periode k
+		LEFT JOIN user u ON k.id_user = u.id_user
+		WHERE k.id_periode = ?
+	`, id))
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Periode komisi tidak ditemukan"})
+		return
+	} else if err != nil {
+		log.Println(" Error get periode komisi:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT id_servis, id_pegawai, nama_teknisi, tanggal_selesai, biaya_servis, laba,
+			COALESCE(jenis, ''), nilai, komisi
+		FROM komisi_detail
+		WHERE id_periode = ?
+		ORDER BY tanggal_selesai, id_servis
+	`, id)
+	if err != nil {
+		log.Println(" Error query detail komisi:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	p.Detail = []models.KomisiServis{}
+	for rows.Next() {
+		k := models.KomisiServis{IDPeriode: &p.IDPeriode}
+		var tglSelesai sql.NullString
+		if err := rows.Scan(&k.IDServis, &k.IDPegawai, &k.NamaTeknisi, &tglSelesai, &k.BiayaServis, &k.Laba,
+			&k.Jenis, &k.Nilai, &k.Komisi); err != nil {
+			log.Println(" Error scan detail komisi:", err)
+			continue
+		}
+		if tglSelesai.Valid {
+			k.TanggalSelesai = &tglSelesai.String
+		}
+		p.Detail = append(p.Detail, k)
+	}
+	p.Teknisi, _ = rekapKomisi(p.Detail)
+
+	json.NewEncoder(w).Encode(p)
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"service_hp/models"
+	"testing"
+)
+
+func TestValidatePeriode(t *testing.T) {
+	tests := []struct {
+		nama        string
+		awal, akhir string
+		valid       bool
+	}{
+		{"satu bulan", "2024-01-01", "2024-01-31", true},
+		{"satu hari", "2024-02-29", "2024-02-29", true},
+		{"akhir sebelum awal", "2024-02-01", "2024-01-31", false},
+		{"awal bukan tanggal", "01-01-2024", "2024-01-31", false},
+		{"akhir tidak ada", "2024-02-01", "2024-02-30", false},
+		{"kosong", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if err := validatePeriode(tt.awal, tt.akhir); (err == nil) != tt.valid {
+				t.Errorf("validatePeriode(%q, %q) error = %v, seharusnya valid = %v", tt.awal, tt.akhir, err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestNilaiKomisi(t *testing.T) {
+	tests := []struct {
+		nama               string
+		jenis              string
+		nilai, biaya, laba float64
+		want               float64
+	}{
+		{"persen biaya", models.KomisiPersenBiaya, 10, 150000, 90000, 15000},
+		{"persen biaya dibulatkan", models.KomisiPersenBiaya, 12.5, 33333, 0, 4166.63},
+		{"tetap", models.KomisiTetap, 25000, 150000, 90000, 25000},
+		{"persen laba", models.KomisiPersenLaba, 20, 150000, 90000, 18000},
+		{"laba negatif tidak memotong", models.KomisiPersenLaba, 20, 150000, -50000, 0},
+		{"jenis tidak dikenal", "bonus", 10, 150000, 90000, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := nilaiKomisi(tt.jenis, tt.nilai, tt.biaya, tt.laba); got != tt.want {
+				t.Errorf("nilaiKomisi = %v, seharusnya %v", got, tt.want)
+			}
+		})
+	}
+}
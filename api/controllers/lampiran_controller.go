@@ -0,0 +1,509 @@
+package controllers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"service_hp/audit"
+	"service_hp/config"
+	"service_hp/database"
+	"service_hp/lampiran"
+	"service_hp/models"
+	"service_hp/routes/middleware"
+	"strconv"
+	"strings"
+)
+
+// maksBerkasPerUpload - jumlah berkas maksimal dalam satu request upload
+const maksBerkasPerUpload = 10
+
+// lampiranQuery - kolom lampiran + nama pengunggah, lanjutkan dengan WHERE/ORDER BY
+const lampiranQuery = `
+	SELECT l.id_lampiran, l.id_servis, l.jenis, l.nama_file, l.content_type, l.ukuran, l.keterangan,
+		l.storage_key, l.thumb_key, l.id_user, COALESCE(u.nama, ''), l.created_at
+	FROM lampiran l
+	LEFT JOIN user u ON l.id_user = u.id_user
+`
+
+// Helper: scan satu baris lampiranQuery dan isi URL berkas & thumbnail
+func scanLampiran(row interface{ Scan(...interface{}) error }) (models.Lampiran, error) {
+	var l models.Lampiran
+	var thumbKey sql.NullString
+	var idUser sql.NullInt64
+	err := row.Scan(&l.IDLampiran, &l.IDServis, &l.Jenis, &l.NamaFile, &l.ContentType, &l.Ukuran, &l.Keterangan,
+		&l.StorageKey, &thumbKey, &idUser, &l.NamaUser, &l.CreatedAt)
+	if err != nil {
+		return l, err
+	}
+
+	l.IDUser = nullIntPtr(idUser)
+	l.URL = fmt.Sprintf("/api/pegawai/servis/%d/lampiran/%d", l.IDServis, l.IDLampiran)
+	if thumbKey.Valid {
+		l.ThumbKey = &thumbKey.String
+		thumb := l.URL + "/thumb"
+		l.ThumbURL = &thumb
+	}
+	return l, nil
+}
+
+// Helper: ambil id servis & id lampiran dari /api/pegawai/servis/{id}/lampiran/{idLampiran}[/thumb]
+func extractLampiranID(path string) (int, int, error) {
+	bagian := strings.Split(strings.Trim(strings.TrimPrefix(path, "/api/pegawai/servis/"), "/"), "/")
+	if len(bagian) < 3 || bagian[1] != "lampiran" {
+		return 0, 0, errors.New("path lampiran tidak valid")
+	}
+	idServis, err := strconv.Atoi(bagian[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	idLampiran, err := strconv.Atoi(bagian[2])
+	return idServis, idLampiran, err
+}
+
+// Helper: lampiran milik servis tertentu, sql.ErrNoRows jika tidak ada
+func loadLampiran(q queryer, idServis, idLampiran int) (models.Lampiran, error) {
+	return scanLampiran(q.QueryRow(lampiranQuery+`WHERE l.id_lampiran = ? AND l.id_servis = ?`, idLampiran, idServis))
+}
+
+// berkasUpload - satu berkas upload yang sudah lolos validasi, siap disimpan
+type berkasUpload struct {
+	nama        string
+	contentType string
+	ext         string
+	data        []byte
+	thumb       []byte
+}
+
+// lampiranError - berkas upload ditolak (ukuran / tipe / isi)
+type lampiranError struct {
+	status int
+	pesan  string
+}
+
+func (e *lampiranError) Error() string {
+	return e.pesan
+}
+
+// Helper: baca & validasi satu berkas multipart, buat thumbnail untuk gambar
+func bacaBerkasUpload(fh *multipart.FileHeader, maks int64) (*berkasUpload, error) {
+	nama := truncate(filepath.Base(strings.ReplaceAll(fh.Filename, "\\", "/")), 255)
+	terlaluBesar := &lampiranError{http.StatusRequestEntityTooLarge,
+		fmt.Sprintf("%s: ukuran berkas maksimal %d MB", nama, config.Cfg.Lampiran.MaxUkuranMB)}
+	if fh.Size > maks {
+		return nil, terlaluBesar
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maks+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maks {
+		return nil, terlaluBesar
+	}
+	if len(data) == 0 {
+		return nil, &lampiranError{http.StatusBadRequest, nama + ": berkas kosong"}
+	}
+
+	b := &berkasUpload{nama: nama, data: data}
+	b.contentType, b.ext, err = lampiran.Periksa(data)
+	if err != nil {
+		return nil, &lampiranError{http.StatusBadRequest, nama + ": " + err.Error()}
+	}
+	if lampiran.IsGambar(b.contentType) {
+		b.thumb, err = lampiran.Thumbnail(data, config.Cfg.Lampiran.ThumbPx)
+		if err != nil {
+			return nil, &lampiranError{http.StatusBadRequest, nama + ": " + err.Error()}
+		}
+	}
+	return b, nil
+}
+
+// =======================================================
+// GET LAMPIRAN SERVIS (daftar foto & dokumen)
+// ?jenis=sebelum|sesudah|dokumen
+// =======================================================
+func GetLampiranServis(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractServisSubID(r.URL.Path, "lampiran")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	where := `WHERE l.id_servis = ?`
+	args := []interface{}{id}
+	if jenis := r.URL.Query().Get("jenis"); jenis != "" {
+		where += ` AND l.jenis = ?`
+		args = append(args, jenis)
+	}
+
+	rows, err := database.DB.Query(lampiranQuery+where+` ORDER BY l.created_at ASC, l.id_lampiran ASC`, args...)
+	if err != nil {
+		log.Println(" Error query lampiran:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	list := []models.Lampiran{}
+	for rows.Next() {
+		l, err := scanLampiran(rows)
+		if err != nil {
+			log.Println(" Error scan lampiran:", err)
+			continue
+		}
+		list = append(list, l)
+	}
+
+	json.NewEncoder(w).Encode(list)
+}
+
+// =======================================================
+// UPLOAD LAMPIRAN SERVIS (multipart/form-data)
+// field: file (boleh lebih dari satu), jenis, keterangan
+// =======================================================
+func UploadLampiran(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractServisSubID(r.URL.Path, "lampiran")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	maks := int64(config.Cfg.Lampiran.MaxUkuranMB) << 20
+	r.Body = http.MaxBytesReader(w, r.Body, maksBerkasPerUpload*maks+1<<20)
+	if err := r.ParseMultipartForm(8 << 20); err != nil {
+		var terlaluBesar *http.MaxBytesError
+		if errors.As(err, &terlaluBesar) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Ukuran upload terlalu besar"})
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Upload harus multipart/form-data"})
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	jenis := strings.ToLower(strings.TrimSpace(r.FormValue("jenis")))
+	switch jenis {
+	case models.LampiranSebelum, models.LampiranSesudah, models.LampiranDokumen:
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Jenis lampiran harus sebelum, sesudah, atau dokumen"})
+		return
+	}
+	keterangan := truncate(strings.TrimSpace(r.FormValue("keterangan")), 255)
+
+	files := r.MultipartForm.File["file"]
+	if len(files) == 0 || len(files) > maksBerkasPerUpload {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("Kirim 1 sampai %d berkas di field file", maksBerkasPerUpload)})
+		return
+	}
+
+	var ada bool
+	if err := database.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM servis WHERE id_servis = ?)`, id).Scan(&ada); err != nil {
+		log.Println(" Error cek servis:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+	if !ada {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Servis tidak ditemukan"})
+		return
+	}
+
+	// Semua berkas divalidasi dulu, satu yang ditolak membatalkan seluruh upload
+	var berkas []*berkasUpload
+	for _, fh := range files {
+		b, err := bacaBerkasUpload(fh, maks)
+		if err != nil {
+			var le *lampiranError
+			if errors.As(err, &le) {
+				w.WriteHeader(le.status)
+				json.NewEncoder(w).Encode(map[string]string{"error": le.pesan})
+				return
+			}
+			log.Println(" Error baca upload lampiran:", err)
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Gagal membaca berkas upload"})
+			return
+		}
+		berkas = append(berkas, b)
+	}
+
+	// Simpan isi berkas dulu; jika transaksi gagal, berkas yang sudah tersimpan dihapus lagi
+	var tersimpan []string
+	bersihkan := func() {
+		for _, key := range tersimpan {
+			if err := lampiran.Store.Delete(key); err != nil {
+				log.Println(" Error hapus berkas lampiran:", err)
+			}
+		}
+	}
+	keys := make([]string, len(berkas))
+	thumbKeys := make([]*string, len(berkas))
+	for i, b := range berkas {
+		acak, err := randomToken(16)
+		if err == nil {
+			keys[i] = fmt.Sprintf("servis/%d/%s%s", id, acak, b.ext)
+			err = lampiran.Store.Put(keys[i], bytes.NewReader(b.data))
+		}
+		if err == nil {
+			tersimpan = append(tersimpan, keys[i])
+			if b.thumb != nil {
+				thumb := fmt.Sprintf("servis/%d/%s_thumb.jpg", id, acak)
+				err = lampiran.Store.Put(thumb, bytes.NewReader(b.thumb))
+				if err == nil {
+					tersimpan = append(tersimpan, thumb)
+					thumbKeys[i] = &thumb
+				}
+			}
+		}
+		if err != nil {
+			bersihkan()
+			log.Println(" Error simpan berkas lampiran:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Gagal menyimpan berkas"})
+			return
+		}
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		bersihkan()
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	ids := make([]int, len(berkas))
+	for i, b := range berkas {
+		res, err := tx.Exec(`
+			INSERT INTO lampiran (id_servis, jenis, nama_file, content_type, ukuran, storage_key, thumb_key, keterangan, id_user)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, id, jenis, b.nama, b.contentType, len(b.data), keys[i], thumbKeys[i], keterangan, currentUserID(r))
+		if err != nil {
+			tx.Rollback()
+			bersihkan()
+			log.Println(" Error insert lampiran:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Gagal menyimpan lampiran"})
+			return
+		}
+		id64, _ := res.LastInsertId()
+		ids[i] = int(id64)
+		if err := audit.CatatTx(tx, r, audit.AksiCreate, audit.EntitasLampiran, ids[i], nil); err != nil {
+			tx.Rollback()
+			bersihkan()
+			writeAuditError(w, err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		bersihkan()
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
+	}
+
+	list := []models.Lampiran{}
+	for _, idLampiran := range ids {
+		if l, err := loadLampiran(database.DB, id, idLampiran); err == nil {
+			list = append(list, l)
+		}
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":  "Lampiran berhasil diunggah",
+		"lampiran": list,
+	})
+}
+
+// Helper: kirim isi berkas dari storage
+func kirimBerkas(w http.ResponseWriter, key, contentType, namaFile string) {
+	f, err := lampiran.Store.Open(key)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		if errors.Is(err, lampiran.ErrTidakAda) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Berkas lampiran tidak ditemukan"})
+			return
+		}
+		log.Println(" Error buka berkas lampiran:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal membuka berkas"})
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", namaFile))
+	if _, err := io.Copy(w, f); err != nil {
+		log.Println(" Error kirim berkas lampiran:", err)
+	}
+}
+
+// Helper: lampiran dari path untuk handler berkas / thumbnail, false jika response sudah ditulis
+func lampiranDariPath(w http.ResponseWriter, r *http.Request) (models.Lampiran, bool) {
+	idServis, idLampiran, err := extractLampiranID(r.URL.Path)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return models.Lampiran{}, false
+	}
+
+	l, err := loadLampiran(database.DB, idServis, idLampiran)
+	if err == sql.ErrNoRows {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Lampiran tidak ditemukan"})
+		return l, false
+	}
+	if err != nil {
+		log.Println(" Error get lampiran:", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return l, false
+	}
+	return l, true
+}
+
+// =======================================================
+// GET BERKAS LAMPIRAN (isi asli)
+// =======================================================
+func GetBerkasLampiran(w http.ResponseWriter, r *http.Request) {
+	l, ok := lampiranDariPath(w, r)
+	if !ok {
+		return
+	}
+	kirimBerkas(w, l.StorageKey, l.ContentType, l.NamaFile)
+}
+
+// =======================================================
+// GET THUMBNAIL LAMPIRAN (hanya untuk gambar)
+// =======================================================
+func GetThumbLampiran(w http.ResponseWriter, r *http.Request) {
+	l, ok := lampiranDariPath(w, r)
+	if !ok {
+		return
+	}
+	if l.ThumbKey == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Lampiran ini tidak punya thumbnail"})
+		return
+	}
+	kirimBerkas(w, *l.ThumbKey, "image/jpeg", "thumb-"+strings.TrimSuffix(l.NamaFile, filepath.Ext(l.NamaFile))+".jpg")
+}
+
+// =======================================================
+// DELETE LAMPIRAN
+// Pengunggah boleh menghapus lampirannya sendiri, selain itu butuh izin hapus servis
+// =======================================================
+func DeleteLampiran(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	idServis, idLampiran, err := extractLampiranID(r.URL.Path)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	l, err := loadLampiran(tx, idServis, idLampiran)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Lampiran tidak ditemukan"})
+		return
+	}
+	if err != nil {
+		tx.Rollback()
+		log.Println(" Error get lampiran:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	idUser := currentUserID(r)
+	milikSendiri := idUser != nil && l.IDUser != nil && *idUser == *l.IDUser
+	if !milikSendiri && !middleware.HasPermission(r.Context(), middleware.PermServisDelete) {
+		tx.Rollback()
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Hanya pengunggah atau yang berizin hapus servis yang boleh menghapus lampiran"})
+		return
+	}
+
+	sebelum, _ := audit.Snapshot(tx, audit.EntitasLampiran, idLampiran)
+	if _, err := tx.Exec(`DELETE FROM lampiran WHERE id_lampiran = ?`, idLampiran); err != nil {
+		tx.Rollback()
+		log.Println(" Error delete lampiran:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal menghapus lampiran"})
+		return
+	}
+	if err := audit.Record(tx, r, audit.Entry{Aksi: audit.AksiDelete, Entitas: audit.EntitasLampiran, IDEntitas: idLampiran, Sebelum: sebelum}); err != nil {
+		tx.Rollback()
+		writeAuditError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
+	}
+
+	// Berkas dihapus setelah commit; kalau gagal hanya tersisa berkas yatim, data tetap konsisten
+	keys := []string{l.StorageKey}
+	if l.ThumbKey != nil {
+		keys = append(keys, *l.ThumbKey)
+	}
+	for _, key := range keys {
+		if err := lampiran.Store.Delete(key); err != nil {
+			log.Println(" Error hapus berkas lampiran:", err)
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Lampiran berhasil dihapus",
+	})
+}
@@ -1,11 +1,14 @@
 package controllers
 
 import (
+	"database/sql"
 	"encoding/json"
 	"log"
 	"net/http"
+	"service_hp/audit"
 	"service_hp/database"
 	"service_hp/models"
+	"service_hp/routes/middleware"
 	"strconv"
 	"strings"
 	"time"
@@ -18,6 +21,25 @@ func extractLaporanID(path string) (int, error) {
 	return strconv.Atoi(idStr)
 }
 
+// Helper: kosongkan modal & laba untuk user tanpa izin laporan.view_profit
+func redactLaporan(r *http.Request, l *models.Laporan) {
+	if middleware.HasPermission(r.Context(), middleware.PermLaporanProfit) {
+		return
+	}
+	l.TotalModal = 0
+	l.TotalBiayaKlaim = 0
+	l.KlaimBarang = nil
+	l.KlaimTeknisi = nil
+	l.TotalPembelian = 0
+	l.TotalKomisi = 0
+	l.KomisiTeknisi = nil
+	l.LabaBersih = 0
+	for i := range l.DetailServis {
+		l.DetailServis[i].LabaServis = 0
+	}
+	l.ProfitDisembunyikan = true
+}
+
 // =======================================================
 // GET ALL LAPORAN
 // =======================================================
@@ -28,7 +50,8 @@ func GetAllLaporan(w http.ResponseWriter, r *http.Request) {
 		SELECT 
 			id_laporan, judul_laporan, jenis_laporan,
 			tanggal_awal, tanggal_akhir,
-			total_servis, total_pendapatan, total_modal, laba_bersih,
+			total_servis, total_pendapatan, total_diterima, total_piutang, total_modal, total_klaim_garansi, total_biaya_garansi,
+			total_pembelian, total_komisi, laba_bersih,
 			COALESCE(keterangan, ''), created_at
 		FROM laporan
 		ORDER BY created_at DESC
@@ -48,13 +71,14 @@ func GetAllLaporan(w http.ResponseWriter, r *http.Request) {
 		err := rows.Scan(
 			&l.IDLaporan, &l.JudulLaporan, &l.JenisLaporan,
 			&l.TanggalAwal, &l.TanggalAkhir,
-			&l.TotalServis, &l.TotalPendapatan, &l.TotalModal, &l.LabaBersih,
+			&l.TotalServis, &l.TotalPendapatan, &l.TotalDiterima, &l.TotalPiutang, &l.TotalModal, &l.TotalKlaim, &l.TotalBiayaKlaim, &l.TotalPembelian, &l.TotalKomisi, &l.LabaBersih,
 			&l.Keterangan, &l.CreatedAt,
 		)
 		if err != nil {
 			log.Println(" Error scan:", err)
 			continue
 		}
+		redactLaporan(r, &l)
 		list = append(list, l)
 	}
 
@@ -80,13 +104,14 @@ func GetLaporanDetail(w http.ResponseWriter, r *http.Request) {
 		SELECT 
 			id_laporan, judul_laporan, jenis_laporan,
 			tanggal_awal, tanggal_akhir,
-			total_servis, total_pendapatan, total_modal, laba_bersih,
+			total_servis, total_pendapatan, total_diterima, total_piutang, total_modal, total_klaim_garansi, total_biaya_garansi,
+			total_pembelian, total_komisi, laba_bersih,
 			COALESCE(keterangan, ''), created_at
 		FROM laporan WHERE id_laporan = ?
 	`, id).Scan(
 		&l.IDLaporan, &l.JudulLaporan, &l.JenisLaporan,
 		&l.TanggalAwal, &l.TanggalAkhir,
-		&l.TotalServis, &l.TotalPendapatan, &l.TotalModal, &l.LabaBersih,
+		&l.TotalServis, &l.TotalPendapatan, &l.TotalDiterima, &l.TotalPiutang, &l.TotalModal, &l.TotalKlaim, &l.TotalBiayaKlaim, &l.TotalPembelian, &l.TotalKomisi, &l.LabaBersih,
 		&l.Keterangan, &l.CreatedAt,
 	)
 
@@ -103,6 +128,7 @@ func GetLaporanDetail(w http.ResponseWriter, r *http.Request) {
 			ds_detail.id_detail,
 			ds_detail.id_laporan,
 			ds_detail.id_servis,
+			ds_detail.id_servis_asal,
 			ds_detail.nama_pelanggan,
 			ds_detail.tipe_hp,
     COALESCE(s.status_servis, 'unknown') as status_servis,
@@ -120,18 +146,62 @@ func GetLaporanDetail(w http.ResponseWriter, r *http.Request) {
 		for rowsServis.Next() {
 			var ds models.DetailLaporanServis
 			var modalServis float64
+			var idAsal sql.NullInt64
 			err := rowsServis.Scan(
-				&ds.IDDetail, &ds.IDLaporan, &ds.IDServis,
+				&ds.IDDetail, &ds.IDLaporan, &ds.IDServis, &idAsal,
 				&ds.NamaPelanggan, &ds.TipeHP,
 				&ds.StatusServis,
 				&ds.BiayaTotal, &modalServis, &ds.LabaServis,
 			)
 			if err == nil {
+				ds.IDServisAsal = nullIntPtr(idAsal)
 				l.DetailServis = append(l.DetailServis, ds)
 			}
 		}
 	}
 
+	// Rekap komisi per teknisi
+	rowsKomisi, err := database.DB.Query(`
+		SELECT id_pegawai, nama_teknisi, jumlah_servis, total_komisi
+		FROM detail_laporan_komisi
+		WHERE id_laporan = ?
+		ORDER BY nama_teknisi
+	`, id)
+	if err == nil {
+		defer rowsKomisi.Close()
+		for rowsKomisi.Next() {
+			var k models.KomisiTeknisi
+			if err := rowsKomisi.Scan(&k.IDPegawai, &k.NamaTeknisi, &k.JumlahServis, &k.TotalKomisi); err == nil {
+				l.KomisiTeknisi = append(l.KomisiTeknisi, k)
+			}
+		}
+	}
+
+	// Rekap biaya klaim garansi per barang & per teknisi
+	rowsGaransi, err := database.DB.Query(`
+		SELECT kategori, id_ref, nama, jumlah_klaim, biaya
+		FROM detail_laporan_garansi
+		WHERE id_laporan = ?
+		ORDER BY kategori, biaya DESC, nama
+	`, id)
+	if err == nil {
+		defer rowsGaransi.Close()
+		for rowsGaransi.Next() {
+			var g models.BiayaGaransi
+			var idRef sql.NullInt64
+			if err := rowsGaransi.Scan(&g.Kategori, &idRef, &g.Nama, &g.JumlahKlaim, &g.Biaya); err != nil {
+				continue
+			}
+			g.IDRef = nullIntPtr(idRef)
+			if g.Kategori == models.GaransiPerBarang {
+				l.KlaimBarang = append(l.KlaimBarang, g)
+			} else {
+				l.KlaimTeknisi = append(l.KlaimTeknisi, g)
+			}
+		}
+	}
+
+	redactLaporan(r, &l)
 	json.NewEncoder(w).Encode(l)
 }
 
@@ -161,7 +231,7 @@ func GenerateLaporan(w http.ResponseWriter, r *http.Request) {
 	// Buat judul otomatis
 	judul := "Laporan " + strings.Title(req.JenisLaporan) + " - " + req.TanggalAwal + " s/d " + req.TanggalAkhir
 
-	// Hitung total servis dan pendapatan
+	// Hitung total servis dan pendapatan (klaim garansi dihitung terpisah)
 	var totalServis int
 	var totalPendapatan float64
 
@@ -170,7 +240,7 @@ func GenerateLaporan(w http.ResponseWriter, r *http.Request) {
 			COUNT(*), 
 			COALESCE(SUM(biaya_total), 0)
 		FROM servis
-		WHERE DATE(tanggal_masuk) BETWEEN ? AND ?
+		WHERE id_servis_asal IS NULL AND status_servis <> 'batal' AND DATE(tanggal_masuk) BETWEEN ? AND ?
 	`, req.TanggalAwal, req.TanggalAkhir).Scan(&totalServis, &totalPendapatan)
 
 	if err != nil {
@@ -179,15 +249,40 @@ func GenerateLaporan(w http.ResponseWriter, r *http.Request) {
 		totalPendapatan = 0
 	}
 
-	// Modal = harga beli barang yang digunakan untuk servis
+	// Uang yang benar-benar diterima dalam periode (bersih setelah refund)
+	var totalDiterima float64
+
+	err = database.DB.QueryRow(uangDiterimaSQL+`
+		WHERE DATE(tanggal) BETWEEN ? AND ?
+	`, req.TanggalAwal, req.TanggalAkhir).Scan(&totalDiterima)
+
+	if err != nil {
+		log.Println(" Error hitung pembayaran diterima:", err)
+		totalDiterima = 0
+	}
+
+	// Piutang: sisa tagihan servis periode ini yang belum dibayar (servis batal tidak ditagih)
+	var totalPiutang float64
+
+	err = database.DB.QueryRow(`
+		SELECT COALESCE(SUM(GREATEST(s.biaya_total - `+totalDibayarSQL+`, 0)), 0)
+		FROM servis s
+		WHERE s.status_servis <> 'batal' AND DATE(s.tanggal_masuk) BETWEEN ? AND ?
+	`, req.TanggalAwal, req.TanggalAkhir).Scan(&totalPiutang)
+
+	if err != nil {
+		log.Println(" Error hitung piutang:", err)
+		totalPiutang = 0
+	}
+
+	// Modal = harga modal barang yang tercatat saat dipakai di detail servis
 	var totalModal float64
 	
 	err = database.DB.QueryRow(`
-		SELECT COALESCE(SUM(ds.jumlah * COALESCE(b.harga_modal, 0)), 0)
+		SELECT COALESCE(SUM(ds.jumlah * ds.harga_modal), 0)
 		FROM detail_servis ds
 		INNER JOIN servis s ON ds.id_servis = s.id_servis
-		LEFT JOIN barang b ON ds.id_barang = b.id_barang
-		WHERE DATE(s.tanggal_masuk) BETWEEN ? AND ?
+		WHERE s.id_servis_asal IS NULL AND s.status_servis <> 'batal' AND DATE(s.tanggal_masuk) BETWEEN ? AND ?
 	`, req.TanggalAwal, req.TanggalAkhir).Scan(&totalModal)
 
 	if err != nil {
@@ -195,21 +290,55 @@ func GenerateLaporan(w http.ResponseWriter, r *http.Request) {
 		totalModal = 0
 	}
 
+	// Belanja pembelian barang yang diterima dalam periode
+	var totalPembelian float64
+
+	err = database.DB.QueryRow(`
+		SELECT COALESCE(SUM(total_pembelian), 0)
+		FROM pembelian
+		WHERE status = 'diterima' AND DATE(tanggal_diterima) BETWEEN ? AND ?
+	`, req.TanggalAwal, req.TanggalAkhir).Scan(&totalPembelian)
+
+	if err != nil {
+		log.Println(" Error hitung pembelian:", err)
+		totalPembelian = 0
+	}
+
+	// Komisi teknisi dari servis yang selesai dalam periode (nilai terkunci jika periode komisi sudah dikunci)
+	var komisiTeknisi []models.KomisiTeknisi
+	var totalKomisi float64
+
+	komisi, err := hitungKomisi(database.DB, req.TanggalAwal, req.TanggalAkhir)
+	if err != nil {
+		log.Println(" Error hitung komisi:", err)
+	} else {
+		komisiTeknisi, totalKomisi = rekapKomisi(komisi)
+	}
+
+	// Klaim garansi: modal barang pengganti dikurangi yang tetap ditagih, per barang & per teknisi
+	garansi, err := rekapGaransi(database.DB, req.TanggalAwal, req.TanggalAkhir)
+	if err != nil {
+		log.Println(" Error hitung biaya garansi:", err)
+		garansi = models.RekapGaransi{}
+	}
+
 	//  Hitung laba bersih
-	labaBersih := totalPendapatan - totalModal
+	labaBersih := totalPendapatan - totalModal - garansi.TotalBiaya
 
-	log.Printf(" Generate Laporan: Servis=%d, Pendapatan=%.2f, Modal=%.2f, Laba=%.2f", 
-		totalServis, totalPendapatan, totalModal, labaBersih)
+	log.Printf(" Generate Laporan: Servis=%d, Pendapatan=%.2f, Diterima=%.2f, Piutang=%.2f, Modal=%.2f, Klaim=%d, BiayaGaransi=%.2f, Pembelian=%.2f, Komisi=%.2f, Laba=%.2f", 
+		totalServis, totalPendapatan, totalDiterima, totalPiutang, totalModal, garansi.TotalKlaim, garansi.TotalBiaya, totalPembelian, totalKomisi, labaBersih)
 
 	// Insert laporan
 	result, err := database.DB.Exec(`
 		INSERT INTO laporan (
 			judul_laporan, jenis_laporan, tanggal_awal, tanggal_akhir,
-			total_servis, total_pendapatan, total_modal, laba_bersih,
+			total_servis, total_pendapatan, total_diterima, total_piutang, total_modal, total_klaim_garansi, total_biaya_garansi,
+			total_pembelian, total_komisi, laba_bersih,
 			keterangan
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, judul, req.JenisLaporan, req.TanggalAwal, req.TanggalAkhir,
-		totalServis, totalPendapatan, totalModal, labaBersih, req.Keterangan)
+		totalServis, totalPendapatan, totalDiterima, totalPiutang, totalModal, garansi.TotalKlaim, garansi.TotalBiaya,
+		totalPembelian, totalKomisi, labaBersih, req.Keterangan)
 
 	if err != nil {
 		log.Println(" Error insert laporan:", err)
@@ -223,22 +352,22 @@ func GenerateLaporan(w http.ResponseWriter, r *http.Request) {
 	//  Insert detail servis 
 	_, err = database.DB.Exec(`
 		INSERT INTO detail_laporan_servis (
-	id_laporan, id_servis, nama_pelanggan, tipe_hp, status_servis,
+	id_laporan, id_servis, id_servis_asal, nama_pelanggan, tipe_hp, status_servis,
 	biaya_total, modal_servis, laba_servis
 )
 SELECT 
 	? as id_laporan,
 	s.id_servis,
+	s.id_servis_asal,
 	s.nama_pelanggan,
 	s.tipe_hp,
 	s.status_servis,
 	s.biaya_total,
-	COALESCE(SUM(ds.jumlah * COALESCE(b.harga_modal, 0)), 0) as modal_servis,
-	(s.biaya_total - COALESCE(SUM(ds.jumlah * COALESCE(b.harga_modal, 0)), 0)) as laba_servis
+	COALESCE(SUM(ds.jumlah * ds.harga_modal), 0) as modal_servis,
+	(s.biaya_total - COALESCE(SUM(ds.jumlah * ds.harga_modal), 0)) as laba_servis
 FROM servis s
 LEFT JOIN detail_servis ds ON s.id_servis = ds.id_servis
-LEFT JOIN barang b ON ds.id_barang = b.id_barang
-WHERE DATE(s.tanggal_masuk) BETWEEN ? AND ?
+WHERE s.status_servis <> 'batal' AND DATE(s.tanggal_masuk) BETWEEN ? AND ?
 GROUP BY s.id_servis
 	`, idLaporan, req.TanggalAwal, req.TanggalAkhir)
 
@@ -246,18 +375,59 @@ GROUP BY s.id_servis
 		log.Println(" Warning insert detail:", err)
 	}
 
+	for _, g := range append(garansi.PerBarang, garansi.PerTeknisi...) {
+		_, err := database.DB.Exec(`
+			INSERT INTO detail_laporan_garansi (id_laporan, kategori, id_ref, nama, jumlah_klaim, biaya)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, idLaporan, g.Kategori, g.IDRef, truncate(g.Nama, 150), g.JumlahKlaim, g.Biaya)
+		if err != nil {
+			log.Println(" Warning insert garansi laporan:", err)
+		}
+	}
+
+	for _, k := range komisiTeknisi {
+		_, err := database.DB.Exec(`
+			INSERT INTO detail_laporan_komisi (id_laporan, id_pegawai, nama_teknisi, jumlah_servis, total_komisi)
+			VALUES (?, ?, ?, ?, ?)
+		`, idLaporan, k.IDPegawai, k.NamaTeknisi, k.JumlahServis, k.TotalKomisi)
+		if err != nil {
+			log.Println(" Warning insert komisi laporan:", err)
+		}
+	}
+
+	audit.Catat(database.DB, r, audit.AksiCreate, audit.EntitasLaporan, int(idLaporan), nil)
+
+	summary := map[string]interface{}{
+		"total_servis":        totalServis,
+		"total_pendapatan":    totalPendapatan,
+		"total_diterima":      totalDiterima,
+		"total_piutang":       totalPiutang,
+		"total_klaim_garansi": garansi.TotalKlaim,
+	}
+	if middleware.HasPermission(r.Context(), middleware.PermLaporanProfit) {
+		summary["total_modal"] = totalModal
+		summary["total_biaya_garansi"] = garansi.TotalBiaya
+		summary["garansi_barang"] = garansi.PerBarang
+		summary["garansi_teknisi"] = garansi.PerTeknisi
+		summary["total_pembelian"] = totalPembelian
+		summary["total_komisi"] = totalKomisi
+		summary["komisi_teknisi"] = komisiTeknisi
+		summary["laba_bersih"] = labaBersih
+	}
+
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"message":    "Laporan berhasil dibuat",
 		"id_laporan": idLaporan,
-		"summary": map[string]interface{}{
-			"total_servis":     totalServis,
-			"total_pendapatan": totalPendapatan,
-			"total_modal":      totalModal,
-			"laba_bersih":      labaBersih,
-		},
+		"summary":    summary,
 	})
 }
 
+// uangDiterimaSQL - total pembayaran bersih setelah refund, lanjutkan dengan WHERE tanggal
+const uangDiterimaSQL = `
+		SELECT COALESCE(SUM(CASE WHEN jenis = 'refund' THEN -jumlah ELSE jumlah END), 0)
+		FROM pembayaran
+`
+
 // =======================================================
 // GET Data status
 // =======================================================
@@ -271,55 +441,71 @@ func GetDataStats(w http.ResponseWriter, r *http.Request) {
 	database.DB.QueryRow(`
 		SELECT COUNT(*), COALESCE(SUM(biaya_total), 0)
 		FROM servis
-		WHERE DATE(tanggal_masuk) = ?
+		WHERE status_servis <> 'batal' AND DATE(tanggal_masuk) = ?
 	`, today).Scan(&stats.HariIni.TotalServis, &stats.HariIni.TotalPendapatan)
 
 	var modalHariIni float64
 	database.DB.QueryRow(`
-		SELECT COALESCE(SUM(ds.jumlah * COALESCE(b.harga_modal, 0)), 0)
+		SELECT COALESCE(SUM(ds.jumlah * ds.harga_modal), 0)
 		FROM detail_servis ds
 		INNER JOIN servis s ON ds.id_servis = s.id_servis
-		LEFT JOIN barang b ON ds.id_barang = b.id_barang
-		WHERE DATE(s.tanggal_masuk) = ?
+		WHERE s.status_servis <> 'batal' AND DATE(s.tanggal_masuk) = ?
 	`, today).Scan(&modalHariIni)
 	stats.HariIni.LabaBersih = stats.HariIni.TotalPendapatan - modalHariIni
 
+	database.DB.QueryRow(uangDiterimaSQL+`
+		WHERE DATE(tanggal) = ?
+	`, today).Scan(&stats.HariIni.TotalDiterima)
+
 	//  Minggu ini
 	database.DB.QueryRow(`
 		SELECT COUNT(*), COALESCE(SUM(biaya_total), 0)
 		FROM servis
-		WHERE DATE(tanggal_masuk) >= DATE_SUB(CURDATE(), INTERVAL 7 DAY)
+		WHERE status_servis <> 'batal' AND DATE(tanggal_masuk) >= DATE_SUB(CURDATE(), INTERVAL 7 DAY)
 	`).Scan(&stats.MingguIni.TotalServis, &stats.MingguIni.TotalPendapatan)
 
 	var modalMinggu float64
 	database.DB.QueryRow(`
-		SELECT COALESCE(SUM(ds.jumlah * COALESCE(b.harga_modal, 0)), 0)
+		SELECT COALESCE(SUM(ds.jumlah * ds.harga_modal), 0)
 		FROM detail_servis ds
 		INNER JOIN servis s ON ds.id_servis = s.id_servis
-		LEFT JOIN barang b ON ds.id_barang = b.id_barang
-		WHERE DATE(s.tanggal_masuk) >= DATE_SUB(CURDATE(), INTERVAL 7 DAY)
+		WHERE s.status_servis <> 'batal' AND DATE(s.tanggal_masuk) >= DATE_SUB(CURDATE(), INTERVAL 7 DAY)
 	`).Scan(&modalMinggu)
 	stats.MingguIni.LabaBersih = stats.MingguIni.TotalPendapatan - modalMinggu
 
+	database.DB.QueryRow(uangDiterimaSQL+`
+		WHERE DATE(tanggal) >= DATE_SUB(CURDATE(), INTERVAL 7 DAY)
+	`).Scan(&stats.MingguIni.TotalDiterima)
+
 	//  Bulan ini
 	database.DB.QueryRow(`
 		SELECT COUNT(*), COALESCE(SUM(biaya_total), 0)
 		FROM servis
-		WHERE MONTH(tanggal_masuk) = MONTH(CURDATE()) 
+		WHERE status_servis <> 'batal' AND MONTH(tanggal_masuk) = MONTH(CURDATE()) 
 		AND YEAR(tanggal_masuk) = YEAR(CURDATE())
 	`).Scan(&stats.BulanIni.TotalServis, &stats.BulanIni.TotalPendapatan)
 
 	var modalBulan float64
 	database.DB.QueryRow(`
-		SELECT COALESCE(SUM(ds.jumlah * COALESCE(b.harga_modal, 0)), 0)
+		SELECT COALESCE(SUM(ds.jumlah * ds.harga_modal), 0)
 		FROM detail_servis ds
 		INNER JOIN servis s ON ds.id_servis = s.id_servis
-		LEFT JOIN barang b ON ds.id_barang = b.id_barang
-		WHERE MONTH(s.tanggal_masuk) = MONTH(CURDATE()) 
+		WHERE s.status_servis <> 'batal' AND MONTH(s.tanggal_masuk) = MONTH(CURDATE()) 
 		AND YEAR(s.tanggal_masuk) = YEAR(CURDATE())
 	`).Scan(&modalBulan)
 	stats.BulanIni.LabaBersih = stats.BulanIni.TotalPendapatan - modalBulan
 
+	database.DB.QueryRow(uangDiterimaSQL+`
+		WHERE MONTH(tanggal) = MONTH(CURDATE()) 
+		AND YEAR(tanggal) = YEAR(CURDATE())
+	`).Scan(&stats.BulanIni.TotalDiterima)
+
+	if !middleware.HasPermission(r.Context(), middleware.PermLaporanProfit) {
+		stats.HariIni.LabaBersih = 0
+		stats.MingguIni.LabaBersih = 0
+		stats.BulanIni.LabaBersih = 0
+		stats.ProfitDisembunyikan = true
+	}
 
 	json.NewEncoder(w).Encode(stats)
 }
@@ -337,6 +523,8 @@ func DeleteLaporan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	sebelum, _ := audit.Snapshot(database.DB, audit.EntitasLaporan, id)
+
 	_, err = database.DB.Exec("DELETE FROM laporan WHERE id_laporan=?", id)
 	if err != nil {
 		log.Println(" Error delete laporan:", err)
@@ -345,5 +533,8 @@ func DeleteLaporan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if sebelum != nil {
+		audit.Catat(database.DB, r, audit.AksiDelete, audit.EntitasLaporan, id, sebelum)
+	}
 	json.NewEncoder(w).Encode(map[string]string{"message": "Laporan berhasil dihapus"})
 }
\ No newline at end of file
@@ -0,0 +1,291 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"service_hp/audit"
+	"service_hp/config"
+	"service_hp/database"
+	"service_hp/models"
+	"service_hp/routes/middleware"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Pesan login gagal dibuat sama untuk username tidak ada maupun password salah
+const pesanLoginGagal = "Username atau password salah"
+
+const maxJedaLogin = 30 * time.Second
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// compareDummyPassword menyamakan waktu respon saat username tidak ada
+func compareDummyPassword(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+	})
+	bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+// Helper: hitung gagal login dalam window, kembalikan jumlah & detik sejak gagal terakhir.
+// Percobaan yang masih diproses ikut dihitung agar request paralel saling terlihat.
+func countLoginGagal(q queryer, where string, args ...interface{}) (int, int, error) {
+	window := int(config.Cfg.Login.Window.Seconds())
+	var n, sejak int
+	err := q.QueryRow(`
+		SELECT COUNT(*), COALESCE(TIMESTAMPDIFF(SECOND, MAX(created_at), NOW()), 0)
+		FROM login_attempt
+		WHERE berhasil = 0 AND dihitung = 1 AND alasan IN (?, ?, ?)
+			AND created_at > NOW() - INTERVAL ? SECOND AND `+where,
+		append([]interface{}{models.LoginUserTidakAda, models.LoginPasswordSalah, models.LoginProses, window}, args...)...,
+	).Scan(&n, &sejak)
+	return n, sejak, err
+}
+
+// Helper: lama tunggu jika login harus ditolak: lockout per IP, lockout per username,
+// atau jeda bertahap setelah beberapa kali gagal
+func hitungTungguLogin(q queryer, username, ip string) (time.Duration, error) {
+	cfg := config.Cfg.Login
+
+	n, sejak, err := countLoginGagal(q, "ip_address = ?", ip)
+	if err != nil {
+		return 0, err
+	}
+	if tunggu := tungguLockout(n, sejak, cfg.MaxGagalIP, cfg.Lockout.Duration); tunggu > 0 {
+		return tunggu, nil
+	}
+
+	n, sejak, err = countLoginGagal(q, "username = ?", username)
+	if err != nil {
+		return 0, err
+	}
+	if tunggu := tungguLockout(n, sejak, cfg.MaxGagalUser, cfg.Lockout.Duration); tunggu > 0 {
+		return tunggu, nil
+	}
+	return tungguJeda(n, sejak, cfg.DelayMulai), nil
+}
+
+// Helper: sisa lockout setelah n gagal (terakhir sejak detik lalu) mencapai batas maks
+func tungguLockout(n, sejak, maks int, lockout time.Duration) time.Duration {
+	detik := int(lockout.Seconds())
+	if n >= maks && sejak < detik {
+		return time.Duration(detik-sejak) * time.Second
+	}
+	return 0
+}
+
+// Helper: jeda bertahap 1, 2, 4, ... detik (maks 30 detik) mulai gagal ke-mulai,
+// dikurangi waktu yang sudah lewat sejak gagal terakhir
+func tungguJeda(n, sejak, mulai int) time.Duration {
+	if n < mulai {
+		return 0
+	}
+	jeda := maxJedaLogin
+	if n-mulai < 5 {
+		jeda = time.Duration(1<<uint(n-mulai)) * time.Second
+	}
+	if tunggu := jeda - time.Duration(sejak)*time.Second; tunggu > 0 {
+		return tunggu
+	}
+	return 0
+}
+
+// checkLoginAllowed mengecek lockout sebelum password dicek dan langsung mencatat percobaan ini
+// (status proses, atau diblokir jika ditolak). Hitung & catat berjalan dalam satu transaksi
+// dengan baris login_guard IP & username dikunci, jadi request paralel diproses bergantian.
+// Hasil akhir percobaan dicatat dengan recordLoginAttempt(idAttempt).
+func checkLoginAllowed(r *http.Request, username string) (int64, time.Duration, error) {
+	ip := middleware.ClientIP(r)
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	kunciIP, kunciUser := "ip:"+ip, "user:"+truncate(username, 50)
+	if _, err := tx.Exec(`INSERT IGNORE INTO login_guard (kunci) VALUES (?), (?)`, kunciIP, kunciUser); err != nil {
+		return 0, 0, err
+	}
+	rows, err := tx.Query(`SELECT kunci FROM login_guard WHERE kunci IN (?, ?) ORDER BY kunci FOR UPDATE`, kunciIP, kunciUser)
+	if err != nil {
+		return 0, 0, err
+	}
+	rows.Close()
+
+	tunggu, err := hitungTungguLogin(tx, username, ip)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	alasan := models.LoginProses
+	if tunggu > 0 {
+		alasan = models.LoginDiblokir
+	}
+	res, err := tx.Exec(`
+		INSERT INTO login_attempt (username, ip_address, user_agent, berhasil, alasan)
+		VALUES (?, ?, ?, 0, ?)
+	`, truncate(username, 50), ip, truncate(r.UserAgent(), 255), alasan)
+	if err != nil {
+		return 0, 0, err
+	}
+	idAttempt, err := res.LastInsertId()
+	if err != nil {
+		return 0, 0, err
+	}
+	return idAttempt, tunggu, tx.Commit()
+}
+
+// recordLoginAttempt mencatat hasil akhir percobaan login untuk audit & penghitungan lockout
+func recordLoginAttempt(idAttempt int64, username string, idUser *int, alasan string) {
+	berhasil := alasan == models.LoginBerhasil
+	_, err := database.DB.Exec(`
+		UPDATE login_attempt SET id_user = ?, berhasil = ?, alasan = ?
+		WHERE id_attempt = ?
+	`, idUser, berhasil, alasan, idAttempt)
+	if err != nil {
+		log.Println(" Error catat login attempt:", err)
+		return
+	}
+
+	// Login berhasil mereset hitungan gagal untuk username ini
+	if berhasil {
+		if _, err := database.DB.Exec(`
+			UPDATE login_attempt SET dihitung = 0
+			WHERE username = ? AND berhasil = 0 AND dihitung = 1
+		`, username); err != nil {
+			log.Println(" Error reset login attempt:", err)
+		}
+	}
+}
+
+// Helper: tulis respon login ditolak sementara
+func writeLoginLocked(w http.ResponseWriter, tunggu time.Duration) {
+	detik := int(math.Ceil(tunggu.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(detik))
+	http.Error(w, fmt.Sprintf("Terlalu banyak percobaan login, coba lagi dalam %d detik", detik), http.StatusTooManyRequests)
+}
+
+// =======================================================
+// BUKA KUNCI LOGIN USER (POST /api/admin/users/{id}/unlock)
+// =======================================================
+func UnlockUserLogin(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	idUser, err := extractUserSubID(r.URL.Path, "unlock")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	var username string
+	err = database.DB.QueryRow(`SELECT username FROM user WHERE id_user = ?`, idUser).Scan(&username)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "User tidak ditemukan"})
+		return
+	} else if err != nil {
+		log.Println(" Error select user:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	// Gagal dari IP yang baru-baru ini dipakai mencoba login ke user ini ikut dibuka,
+	// jika tidak lockout per IP tetap menahan user walau kuncinya sudah dibuka
+	res, err := database.DB.Exec(`
+		UPDATE login_attempt SET dihitung = 0
+		WHERE berhasil = 0 AND dihitung = 1
+			AND (username = ? OR ip_address IN (
+				SELECT ip_address FROM (
+					SELECT DISTINCT ip_address FROM login_attempt
+					WHERE username = ? AND berhasil = 0 AND created_at > NOW() - INTERVAL ? SECOND
+				) AS ip_user
+			))
+	`, username, username, int(config.Cfg.Login.Window.Seconds()))
+	if err != nil {
+		log.Println(" Error unlock user:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal membuka kunci user"})
+		return
+	}
+	n, _ := res.RowsAffected()
+	audit.Log(database.DB, r, audit.Entry{Aksi: "login.unlock", Entitas: audit.EntitasUser, IDEntitas: idUser})
+
+	log.Printf(" Login user %s dibuka oleh admin %v", username, *currentUserID(r))
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":       "Kunci login user berhasil dibuka",
+		"gagal_direset": n,
+	})
+}
+
+// =======================================================
+// AUDIT PERCOBAAN LOGIN (GET /api/admin/login-attempts)
+// Filter: ?username=&ip=&gagal=1&limit=
+// =======================================================
+func GetLoginAttempts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+	query := `
+		SELECT id_attempt, username, id_user, ip_address, COALESCE(user_agent, ''),
+			berhasil, COALESCE(alasan, ''), dihitung, created_at
+		FROM login_attempt
+		WHERE 1=1
+	`
+	var args []interface{}
+	if v := strings.TrimSpace(q.Get("username")); v != "" {
+		query += " AND username = ?"
+		args = append(args, v)
+	}
+	if v := strings.TrimSpace(q.Get("ip")); v != "" {
+		query += " AND ip_address = ?"
+		args = append(args, v)
+	}
+	if q.Get("gagal") == "1" {
+		query += " AND berhasil = 0"
+	}
+
+	limit := 200
+	if v, err := strconv.Atoi(q.Get("limit")); err == nil && v > 0 && v <= 1000 {
+		limit = v
+	}
+	query += " ORDER BY id_attempt DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		log.Println(" Error query login attempt:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	list := []models.LoginAttempt{}
+	for rows.Next() {
+		var a models.LoginAttempt
+		var idUser sql.NullInt64
+		if err := rows.Scan(&a.IDAttempt, &a.Username, &idUser, &a.IPAddress, &a.UserAgent,
+			&a.Berhasil, &a.Alasan, &a.Dihitung, &a.CreatedAt); err != nil {
+			log.Println(" Error scan login attempt:", err)
+			continue
+		}
+		a.IDUser = nullIntPtr(idUser)
+		list = append(list, a)
+	}
+
+	json.NewEncoder(w).Encode(list)
+}
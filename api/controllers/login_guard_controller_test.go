@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTungguLockout(t *testing.T) {
+	lockout := 15 * time.Minute
+
+	tests := []struct {
+		nama     string
+		n, sejak int
+		want     time.Duration
+	}{
+		{"belum mencapai batas", 4, 0, 0},
+		{"baru mencapai batas", 5, 0, 15 * time.Minute},
+		{"sebagian lockout lewat", 5, 60, 14 * time.Minute},
+		{"melebihi batas", 9, 300, 10 * time.Minute},
+		{"lockout selesai", 5, 900, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := tungguLockout(tt.n, tt.sejak, 5, lockout); got != tt.want {
+				t.Errorf("tungguLockout(%d, %d) = %v, seharusnya %v", tt.n, tt.sejak, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTungguJeda(t *testing.T) {
+	tests := []struct {
+		nama     string
+		n, sejak int
+		want     time.Duration
+	}{
+		{"sebelum jeda dimulai", 2, 0, 0},
+		{"gagal ke-3", 3, 0, time.Second},
+		{"gagal ke-4", 4, 0, 2 * time.Second},
+		{"gagal ke-7", 7, 0, 16 * time.Second},
+		{"dibatasi 30 detik", 8, 0, maxJedaLogin},
+		{"jauh di atas batas", 100, 0, maxJedaLogin},
+		{"sebagian jeda lewat", 5, 1, 3 * time.Second},
+		{"jeda sudah lewat", 5, 10, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := tungguJeda(tt.n, tt.sejak, 3); got != tt.want {
+				t.Errorf("tungguJeda(%d, %d) = %v, seharusnya %v", tt.n, tt.sejak, got, tt.want)
+			}
+		})
+	}
+}
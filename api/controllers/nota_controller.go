@@ -0,0 +1,347 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"service_hp/audit"
+	"service_hp/config"
+	"service_hp/database"
+	"service_hp/models"
+	"service_hp/nota"
+	"strings"
+	"time"
+)
+
+const formatTanggalNota = "02/01/2006 15:04"
+
+// Helper: nomor nota berikutnya untuk bulan terbit, misal INV/2026/10/0001.
+// Harus dipanggil di dalam transaksi penyimpanan nota: baris nota_urut terkunci sampai
+// commit, dan rollback ikut membatalkan kenaikan nomor sehingga urutan tidak berlubang.
+func nextNomorNota(tx *sql.Tx, terbit time.Time) (string, error) {
+	periode := terbit.Format("2006/01")
+	if _, err := tx.Exec(`
+		INSERT INTO nota_urut (periode, terakhir) VALUES (?, 1)
+		ON DUPLICATE KEY UPDATE terakhir = terakhir + 1
+	`, periode); err != nil {
+		return "", err
+	}
+
+	var urut int
+	if err := tx.QueryRow(`SELECT terakhir FROM nota_urut WHERE periode = ?`, periode).Scan(&urut); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s/%04d", config.Cfg.Nota.Prefix, periode, urut), nil
+}
+
+// Helper: susun salinan beku isi nota dari servis, detail, dan pembayaran saat ini
+func buildNotaData(q queryer, idServis int, nomor string, terbit time.Time) (models.NotaData, error) {
+	d := models.NotaData{
+		Nomor:         nomor,
+		TanggalTerbit: terbit.Format(formatTanggalNota),
+		NamaToko:      config.Cfg.Notif.NamaToko,
+		AlamatToko:    config.Cfg.Nota.Alamat,
+		TeleponToko:   config.Cfg.Nota.Telepon,
+		CatatanKaki:   config.Cfg.Nota.CatatanKaki,
+		IDServis:      idServis,
+		Item:          []models.NotaItem{},
+		Pembayaran:    []models.NotaPembayaran{},
+	}
+
+	var masuk time.Time
+	var selesai sql.NullTime
+	err := q.QueryRow(`
+		SELECT s.kode_tracking, s.nama_pelanggan, COALESCE(s.no_whatsapp, ''), s.tipe_hp,
+			COALESCE(s.keluhan, ''), s.status_servis, COALESCE(p.nama_pegawai, ''),
+			s.biaya_servis, s.tanggal_masuk, s.tanggal_selesai
+		FROM servis s
+		LEFT JOIN pegawai p ON s.id_teknisi = p.id_pegawai
+		WHERE s.id_servis = ?
+	`, idServis).Scan(&d.KodeTracking, &d.NamaPelanggan, &d.NoWhatsapp, &d.TipeHP,
+		&d.Keluhan, &d.StatusServis, &d.NamaTeknisi, &d.BiayaServis, &masuk, &selesai)
+	if err == sql.ErrNoRows {
+		return d, errServisTidakDitemukan
+	}
+	if err != nil {
+		return d, err
+	}
+	d.TanggalMasuk = masuk.Format(formatTanggalNota)
+	if selesai.Valid {
+		d.TanggalSelesai = selesai.Time.Format(formatTanggalNota)
+	}
+
+	rows, err := q.Query(`
+		SELECT deskripsi, jumlah, harga_satuan, biaya
+		FROM detail_servis WHERE id_servis = ?
+		ORDER BY id_detail ASC
+	`, idServis)
+	if err != nil {
+		return d, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var it models.NotaItem
+		if err := rows.Scan(&it.Deskripsi, &it.Jumlah, &it.HargaSatuan, &it.Biaya); err != nil {
+			return d, err
+		}
+		d.Item = append(d.Item, it)
+	}
+	if err := rows.Err(); err != nil {
+		return d, err
+	}
+
+	pRows, err := q.Query(pembayaranQuery+`
+		WHERE p.id_servis = ?
+		ORDER BY p.tanggal ASC, p.id_pembayaran ASC
+	`, idServis)
+	if err != nil {
+		return d, err
+	}
+	defer pRows.Close()
+	list, err := scanPembayaran(pRows)
+	if err != nil {
+		return d, err
+	}
+	for _, p := range list {
+		d.Pembayaran = append(d.Pembayaran, models.NotaPembayaran{
+			Tanggal: p.Tanggal.Format("02/01/2006"),
+			Jenis:   p.Jenis,
+			Metode:  p.Metode,
+			Jumlah:  p.Jumlah,
+		})
+	}
+
+	t, err := loadTagihan(q, idServis)
+	if err != nil {
+		return d, err
+	}
+	d.BiayaTotal = t.BiayaTotal
+	d.TotalDibayar = t.TotalDibayar
+	d.SisaTagihan = t.SisaTagihan
+	return d, nil
+}
+
+// Helper: nama file unduhan dari nomor nota (INV/2026/10/0001 -> INV-2026-10-0001.pdf)
+func namaFileNota(nomor string) string {
+	return strings.ReplaceAll(nomor, "/", "-") + ".pdf"
+}
+
+// =======================================================
+// TERBITKAN NOTA SERVIS (sekali per servis)
+// =======================================================
+func TerbitkanNota(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractServisSubID(r.URL.Path, "nota")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow(`SELECT status_servis FROM servis WHERE id_servis = ? FOR UPDATE`, id).Scan(&status)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Servis tidak ditemukan"})
+		return
+	}
+	if err != nil {
+		log.Println(" Error get servis:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	switch status {
+	case models.StatusSelesai, models.StatusSiapDiambil, models.StatusDiambil, models.StatusTidakBisaDiperbaiki:
+	default:
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Nota hanya bisa diterbitkan untuk servis yang sudah selesai dikerjakan"})
+		return
+	}
+
+	var nomorLama string
+	err = tx.QueryRow(`SELECT nomor FROM nota WHERE id_servis = ?`, id).Scan(&nomorLama)
+	if err == nil {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Nota servis ini sudah diterbitkan", "nomor": nomorLama})
+		return
+	}
+	if err != sql.ErrNoRows {
+		log.Println(" Error cek nota:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	terbit := time.Now()
+	nomor, err := nextNomorNota(tx, terbit)
+	if err != nil {
+		log.Println(" Error nomor nota:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal membuat nomor nota"})
+		return
+	}
+
+	data, err := buildNotaData(tx, id, nomor, terbit)
+	if err != nil {
+		log.Println(" Error susun nota:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal menyusun nota"})
+		return
+	}
+
+	dataJSON, _ := json.Marshal(data)
+	pdf, err := nota.PDF(data, models.NotaA4)
+	if err != nil {
+		log.Println(" Error render nota:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal membuat PDF nota"})
+		return
+	}
+
+	res, err := tx.Exec(`
+		INSERT INTO nota (id_servis, nomor, data, pdf, id_user, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, id, nomor, dataJSON, pdf, currentUserID(r), terbit)
+	if err != nil {
+		log.Println(" Error simpan nota:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal menyimpan nota"})
+		return
+	}
+	idNota, _ := res.LastInsertId()
+
+	if err := audit.CatatTx(tx, r, audit.AksiCreate, audit.EntitasNota, int(idNota), nil); err != nil {
+		writeAuditError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Nota berhasil diterbitkan",
+		"id_nota": idNota,
+		"nomor":   nomor,
+	})
+}
+
+// Helper: ambil nota terbit milik servis, pdf hanya dibaca jika diminta
+func loadNota(idServis int, denganPDF bool) (models.Nota, []byte, error) {
+	var n models.Nota
+	var idUser sql.NullInt64
+	var dataJSON, pdf []byte
+
+	kolomPDF := "NULL"
+	if denganPDF {
+		kolomPDF = "n.pdf"
+	}
+	err := database.DB.QueryRow(`
+		SELECT n.id_nota, n.id_servis, n.nomor, n.id_user, COALESCE(u.nama, ''), n.created_at, n.data, `+kolomPDF+`
+		FROM nota n
+		LEFT JOIN user u ON n.id_user = u.id_user
+		WHERE n.id_servis = ?
+	`, idServis).Scan(&n.IDNota, &n.IDServis, &n.Nomor, &idUser, &n.NamaUser, &n.TanggalTerbit, &dataJSON, &pdf)
+	if err != nil {
+		return n, nil, err
+	}
+	n.IDUser = nullIntPtr(idUser)
+	if err := json.Unmarshal(dataJSON, &n.Data); err != nil {
+		return n, nil, err
+	}
+	return n, pdf, nil
+}
+
+// =======================================================
+// GET NOTA SERVIS (metadata + salinan beku)
+// =======================================================
+func GetNota(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractServisSubID(r.URL.Path, "nota")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	n, _, err := loadNota(id, false)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Nota servis ini belum diterbitkan"})
+		return
+	}
+	if err != nil {
+		log.Println(" Error get nota:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(n)
+}
+
+// =======================================================
+// CETAK NOTA PDF
+// ?format=a4 (default, PDF asli yang disimpan) | 58 | 80 (struk thermal dari salinan beku)
+// =======================================================
+func GetNotaPDF(w http.ResponseWriter, r *http.Request) {
+	id, err := extractServisSubID(r.URL.Path, "nota.pdf")
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = models.NotaA4
+	}
+	if !nota.FormatValid(format) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Format harus a4, 58, atau 80"})
+		return
+	}
+
+	n, pdf, err := loadNota(id, format == models.NotaA4)
+	if err == sql.ErrNoRows {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Nota servis ini belum diterbitkan"})
+		return
+	}
+	if err == nil && format != models.NotaA4 {
+		pdf, err = nota.PDF(n.Data, format)
+	}
+	if err != nil {
+		log.Println(" Error cetak nota:", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal mencetak nota"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, namaFileNota(n.Nomor)))
+	w.Write(pdf)
+}
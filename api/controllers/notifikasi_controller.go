@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"service_hp/database"
+	"service_hp/models"
+)
+
+// =======================================================
+// GET LOG NOTIFIKASI PER SERVIS
+// =======================================================
+func GetNotifikasiServis(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractServisSubID(r.URL.Path, "notifikasi")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT id_notifikasi, id_servis, jenis, tujuan, pesan, status, percobaan,
+			COALESCE(error_terakhir, ''), COALESCE(gateway, ''), jadwal_kirim, terkirim_at, created_at
+		FROM notifikasi_outbox
+		WHERE id_servis = ?
+		ORDER BY id_notifikasi ASC
+	`, id)
+	if err != nil {
+		log.Println(" Error query notifikasi:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	list := []models.Notifikasi{}
+	for rows.Next() {
+		var n models.Notifikasi
+		var terkirim sql.NullTime
+
+		if err := rows.Scan(&n.IDNotifikasi, &n.IDServis, &n.Jenis, &n.Tujuan, &n.Pesan, &n.Status,
+			&n.Percobaan, &n.ErrorTerakhir, &n.Gateway, &n.JadwalKirim, &terkirim, &n.CreatedAt); err != nil {
+			log.Println(" Error scan notifikasi:", err)
+			continue
+		}
+		if terkirim.Valid {
+			n.TerkirimAt = &terkirim.Time
+		}
+		list = append(list, n)
+	}
+
+	json.NewEncoder(w).Encode(list)
+}
@@ -1,14 +1,16 @@
 package controllers
 
 import (
+	"database/sql"
 	"encoding/json"
 	"net/http"
+	"service_hp/audit"
 	"service_hp/database"
+	"service_hp/routes/middleware"
 	"strconv"
 	"strings"
 	"log"
 
-	"golang.org/x/crypto/bcrypt"
 )
 
 // GET: Ambil user yang belum jadi pegawai (role=pegawai tapi belum ada di tabel pegawai)
@@ -118,6 +120,17 @@ func GetAllPegawai(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(data)
 }
 
+// Helper: jabatan menentukan izin pegawai (lihat middleware.rolePermissions)
+func normalizeJabatan(input string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(input)) {
+	case middleware.AksesKasir:
+		return "Kasir", true
+	case middleware.AksesTeknisi:
+		return "Teknisi", true
+	}
+	return "", false
+}
+
 // POST: Tambah pegawai (pilih dari user yang sudah ada)
 func CreatePegawai(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -152,6 +165,14 @@ func CreatePegawai(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	jabatan, ok := normalizeJabatan(req.Jabatan)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Jabatan harus Kasir atau Teknisi"})
+		return
+	}
+	req.Jabatan = jabatan
+
 	// Ambil nama dari tabel user
 	var namaUser string
 	err := database.DB.QueryRow("SELECT nama FROM user WHERE id_user=?", req.IDUser).Scan(&namaUser)
@@ -186,7 +207,7 @@ func CreatePegawai(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Insert pegawai DENGAN nama_pegawai
-	_, err = database.DB.Exec(`
+	res, err := database.DB.Exec(`
 		INSERT INTO pegawai (id_user, nama_pegawai, jabatan, alamat, no_hp, tanggal_masuk, status)
 		VALUES (?, ?, ?, ?, ?, NOW(), ?)`,
 		req.IDUser, namaUser, req.Jabatan, req.Alamat, req.NoHP, req.Status)
@@ -198,6 +219,9 @@ func CreatePegawai(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	idPegawai, _ := res.LastInsertId()
+	audit.Catat(database.DB, r, audit.AksiCreate, audit.EntitasPegawai, int(idPegawai), nil)
+
 	log.Println(" Pegawai berhasil ditambahkan untuk user ID:", req.IDUser)
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Pegawai berhasil ditambahkan"})
@@ -241,6 +265,14 @@ func UpdatePegawai(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	jabatan, ok := normalizeJabatan(req.Jabatan)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Jabatan harus Kasir atau Teknisi"})
+		return
+	}
+	req.Jabatan = jabatan
+
 	// Ambil id_user dari pegawai
 	var idUser int
 	err = database.DB.QueryRow("SELECT id_user FROM pegawai WHERE id_pegawai=?", idPegawai).Scan(&idUser)
@@ -253,7 +285,25 @@ func UpdatePegawai(w http.ResponseWriter, r *http.Request) {
 
 	// Update password jika diisi
 	if req.Password != "" && strings.TrimSpace(req.Password) != "" {
-		hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		var username string
+		err := database.DB.QueryRow("SELECT username FROM user WHERE id_user=?", idUser).Scan(&username)
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "User pegawai tidak ditemukan"})
+			return
+		} else if err != nil {
+			log.Println(" Error select username:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+			return
+		}
+		if err := validatePassword(req.Password, username); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		hash, err := hashPassword(req.Password)
 		if err != nil {
 			log.Println(" Error hash password:", err)
 			w.WriteHeader(http.StatusInternalServerError)
@@ -261,7 +311,7 @@ func UpdatePegawai(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		
-		_, err = database.DB.Exec("UPDATE user SET password=? WHERE id_user=?", string(hash), idUser)
+		_, err = database.DB.Exec("UPDATE user SET password=? WHERE id_user=?", hash, idUser)
 		if err != nil {
 			log.Println(" Error update password:", err)
 			w.WriteHeader(http.StatusBadRequest)
@@ -269,8 +319,16 @@ func UpdatePegawai(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		log.Println(" Password updated")
+		audit.Log(database.DB, r, audit.Entry{Aksi: "password.reset_admin", Entitas: audit.EntitasUser, IDEntitas: idUser})
+
+		// Sesi lama dengan password sebelumnya dicabut
+		if err := revokeUserTokens(database.DB, idUser); err != nil {
+			log.Println(" Error revoke token:", err)
+		}
 	}
 
+	sebelum, _ := audit.Snapshot(database.DB, audit.EntitasPegawai, idPegawai)
+
 	// Update pegawai
 	_, err = database.DB.Exec(`
 		UPDATE pegawai 
@@ -285,6 +343,15 @@ func UpdatePegawai(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Pegawai dinonaktifkan langsung kehilangan akses
+	if !strings.EqualFold(req.Status, "aktif") {
+		if err := revokeUserTokens(database.DB, idUser); err != nil {
+			log.Println(" Error revoke token:", err)
+		}
+	}
+
+	audit.Catat(database.DB, r, audit.AksiUpdate, audit.EntitasPegawai, idPegawai, sebelum)
+
 	log.Println(" Pegawai berhasil diperbarui")
 	json.NewEncoder(w).Encode(map[string]string{"message": "Pegawai berhasil diperbarui"})
 }
@@ -312,6 +379,16 @@ func DeletePegawai(w http.ResponseWriter, r *http.Request) {
 
 	log.Println(" Delete pegawai ID:", idPegawai)
 
+	var idUser int
+	err = database.DB.QueryRow("SELECT id_user FROM pegawai WHERE id_pegawai=?", idPegawai).Scan(&idUser)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Pegawai tidak ditemukan"})
+		return
+	}
+
+	sebelum, _ := audit.Snapshot(database.DB, audit.EntitasPegawai, idPegawai)
+
 	// Hapus hanya dari tabel pegawai, user tetap ada
 	result, err := database.DB.Exec("DELETE FROM pegawai WHERE id_pegawai=?", idPegawai)
 	if err != nil {
@@ -328,6 +405,12 @@ func DeletePegawai(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := revokeUserTokens(database.DB, idUser); err != nil {
+		log.Println(" Error revoke token:", err)
+	}
+
+	audit.Catat(database.DB, r, audit.AksiDelete, audit.EntitasPegawai, idPegawai, sebelum)
+
 	log.Println(" Pegawai berhasil dihapus (user tetap ada)")
 	json.NewEncoder(w).Encode(map[string]string{"message": "Pegawai berhasil dihapus"})
 }
\ No newline at end of file
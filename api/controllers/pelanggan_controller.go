@@ -0,0 +1,661 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"service_hp/audit"
+	"service_hp/database"
+	"service_hp/models"
+	"strings"
+)
+
+// errPelangganTidakDitemukan dikembalikan saat id_pelanggan tidak ada
+var errPelangganTidakDitemukan = errors.New("Pelanggan tidak ditemukan")
+
+// pelangganError - kesalahan validasi data pelanggan
+type pelangganError struct {
+	Pesan string
+}
+
+func (e *pelangganError) Error() string {
+	return e.Pesan
+}
+
+// Helper: normalisasi nomor HP Indonesia ke format 62xxxx (08xx, +62, 62, 8xx)
+func normalizePhone(input string) (string, error) {
+	var digits strings.Builder
+	for _, c := range input {
+		if c >= '0' && c <= '9' {
+			digits.WriteRune(c)
+		}
+	}
+	no := digits.String()
+
+	switch {
+	case strings.HasPrefix(no, "62"):
+	case strings.HasPrefix(no, "0"):
+		no = "62" + strings.TrimPrefix(no, "0")
+	case strings.HasPrefix(no, "8"):
+		no = "62" + no
+	default:
+		return "", &pelangganError{Pesan: "Nomor WhatsApp tidak valid"}
+	}
+
+	// 62 + 8xx dengan panjang nomor lokal 9-13 digit
+	if len(no) < 11 || len(no) > 15 || no[2] != '8' {
+		return "", &pelangganError{Pesan: "Nomor WhatsApp tidak valid"}
+	}
+	return no, nil
+}
+
+// Helper: validasi & normalisasi data pelanggan dari request
+func validatePelanggan(p *models.Pelanggan) error {
+	p.NamaPelanggan = strings.TrimSpace(p.NamaPelanggan)
+	if p.NamaPelanggan == "" {
+		return &pelangganError{Pesan: "Nama pelanggan wajib diisi"}
+	}
+
+	no, err := normalizePhone(p.NoWhatsapp)
+	if err != nil {
+		return err
+	}
+	p.NoWhatsapp = no
+	return nil
+}
+
+// Helper: tentukan pelanggan untuk servis (id_pelanggan yang ada, atau data baru inline).
+// Data inline dengan nomor yang sudah terdaftar memakai pelanggan yang sama.
+// Mengisi req.IDPelanggan, req.NamaPelanggan dan req.NoWhatsapp.
+func resolvePelanggan(tx *sql.Tx, req *models.Servis) error {
+	if req.IDPelanggan != nil {
+		err := tx.QueryRow(`
+			SELECT nama_pelanggan, no_whatsapp FROM pelanggan WHERE id_pelanggan = ?
+		`, *req.IDPelanggan).Scan(&req.NamaPelanggan, &req.NoWhatsapp)
+		if err == sql.ErrNoRows {
+			return errPelangganTidakDitemukan
+		}
+		return err
+	}
+
+	p := models.Pelanggan{NamaPelanggan: req.NamaPelanggan, NoWhatsapp: req.NoWhatsapp}
+	if req.Pelanggan != nil {
+		p = *req.Pelanggan
+	}
+	if err := validatePelanggan(&p); err != nil {
+		return err
+	}
+
+	var id int
+	err := tx.QueryRow(`
+		SELECT id_pelanggan, nama_pelanggan FROM pelanggan WHERE no_whatsapp = ? FOR UPDATE
+	`, p.NoWhatsapp).Scan(&id, &p.NamaPelanggan)
+	if err == sql.ErrNoRows {
+		res, err := tx.Exec(`
+			INSERT INTO pelanggan (nama_pelanggan, no_whatsapp, alamat, catatan)
+			VALUES (?, ?, ?, ?)
+		`, p.NamaPelanggan, p.NoWhatsapp, p.Alamat, p.Catatan)
+		if err != nil {
+			return err
+		}
+		id64, _ := res.LastInsertId()
+		id = int(id64)
+	} else if err != nil {
+		return err
+	}
+
+	req.IDPelanggan = &id
+	req.NamaPelanggan = p.NamaPelanggan
+	req.NoWhatsapp = p.NoWhatsapp
+	return nil
+}
+
+// Helper: tulis response untuk error dari resolvePelanggan
+func writePelangganError(w http.ResponseWriter, err error) {
+	var validasi *pelangganError
+	switch {
+	case errors.As(err, &validasi):
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": validasi.Error()})
+	case errors.Is(err, errPelangganTidakDitemukan):
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	default:
+		log.Println(" Error pelanggan:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal memproses data pelanggan"})
+	}
+}
+
+// GET: Ambil semua pelanggan (?q= cari nama / nomor)
+func GetAllPelanggan(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := `
+		SELECT p.id_pelanggan, p.nama_pelanggan, p.no_whatsapp,
+			COALESCE(p.alamat, ''), COALESCE(p.catatan, ''), p.created_at,
+			COUNT(s.id_servis)
+		FROM pelanggan p
+		LEFT JOIN servis s ON s.id_pelanggan = p.id_pelanggan
+		WHERE 1=1
+	`
+	var args []interface{}
+
+	if q := strings.TrimSpace(r.URL.Query().Get("q")); q != "" {
+		query += " AND (LOWER(p.nama_pelanggan) LIKE ?"
+		args = append(args, "%"+strings.ToLower(q)+"%")
+		if no, err := normalizePhone(q); err == nil {
+			query += " OR p.no_whatsapp = ?"
+			args = append(args, no)
+		}
+		query += ")"
+	}
+	query += " GROUP BY p.id_pelanggan ORDER BY p.nama_pelanggan ASC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		log.Println(" Error query pelanggan:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	list := []models.Pelanggan{}
+	for rows.Next() {
+		var p models.Pelanggan
+		if err := rows.Scan(&p.IDPelanggan, &p.NamaPelanggan, &p.NoWhatsapp,
+			&p.Alamat, &p.Catatan, &p.CreatedAt, &p.TotalServis); err != nil {
+			log.Println(" Error scan pelanggan:", err)
+			continue
+		}
+		list = append(list, p)
+	}
+
+	json.NewEncoder(w).Encode(list)
+}
+
+// GET: Detail pelanggan + riwayat servis
+func GetPelangganDetail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractLastID(r.URL.Path)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	var p models.Pelanggan
+	err = database.DB.QueryRow(`
+		SELECT id_pelanggan, nama_pelanggan, no_whatsapp,
+			COALESCE(alamat, ''), COALESCE(catatan, ''), created_at
+		FROM pelanggan WHERE id_pelanggan = ?
+	`, id).Scan(&p.IDPelanggan, &p.NamaPelanggan, &p.NoWhatsapp, &p.Alamat, &p.Catatan, &p.CreatedAt)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Pelanggan tidak ditemukan"})
+		return
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT id_servis, tipe_hp, keluhan, status_servis, biaya_servis, biaya_total,
+			tanggal_masuk, tanggal_selesai
+		FROM servis
+		WHERE id_pelanggan = ?
+		ORDER BY tanggal_masuk DESC
+	`, id)
+	if err != nil {
+		log.Println(" Error query riwayat servis pelanggan:", err)
+	}
+
+	p.RiwayatServis = []models.Servis{}
+	if rows != nil {
+		for rows.Next() {
+			var s models.Servis
+			var tglSelesai sql.NullString
+			if err := rows.Scan(&s.IDServis, &s.TipeHP, &s.Keluhan, &s.StatusServis,
+				&s.BiayaServis, &s.BiayaTotal, &s.TanggalMasuk, &tglSelesai); err != nil {
+				continue
+			}
+			if tglSelesai.Valid {
+				s.TanggalSelesai = &tglSelesai.String
+			}
+			s.IDPelanggan = &p.IDPelanggan
+			s.NamaPelanggan = p.NamaPelanggan
+			s.NoWhatsapp = p.NoWhatsapp
+			p.RiwayatServis = append(p.RiwayatServis, s)
+		}
+		rows.Close()
+	}
+	p.TotalServis = len(p.RiwayatServis)
+
+	json.NewEncoder(w).Encode(p)
+}
+
+// POST: Tambah pelanggan
+func CreatePelanggan(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req models.Pelanggan
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	if err := validatePelanggan(&req); err != nil {
+		writePelangganError(w, err)
+		return
+	}
+
+	var existing int
+	err := database.DB.QueryRow(`SELECT id_pelanggan FROM pelanggan WHERE no_whatsapp = ?`, req.NoWhatsapp).Scan(&existing)
+	if err == nil {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":        "Nomor WhatsApp sudah terdaftar",
+			"id_pelanggan": existing,
+		})
+		return
+	} else if err != sql.ErrNoRows {
+		log.Println(" Error check pelanggan:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		INSERT INTO pelanggan (nama_pelanggan, no_whatsapp, alamat, catatan)
+		VALUES (?, ?, ?, ?)
+	`, req.NamaPelanggan, req.NoWhatsapp, req.Alamat, req.Catatan)
+	if err != nil {
+		log.Println(" Error insert pelanggan:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	lastID, _ := result.LastInsertId()
+	audit.Catat(database.DB, r, audit.AksiCreate, audit.EntitasPelanggan, int(lastID), nil)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":      "Pelanggan berhasil ditambahkan",
+		"id_pelanggan": lastID,
+		"no_whatsapp":  req.NoWhatsapp,
+	})
+}
+
+// PUT: Update pelanggan (salinan nama & nomor di servis ikut diperbarui)
+func UpdatePelanggan(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractLastID(r.URL.Path)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.Pelanggan
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	if err := validatePelanggan(&req); err != nil {
+		writePelangganError(w, err)
+		return
+	}
+
+	var existing int
+	err = database.DB.QueryRow(`
+		SELECT id_pelanggan FROM pelanggan WHERE no_whatsapp = ? AND id_pelanggan <> ?
+	`, req.NoWhatsapp, id).Scan(&existing)
+	if err == nil {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":        "Nomor WhatsApp sudah dipakai pelanggan lain, gunakan merge",
+			"id_pelanggan": existing,
+		})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	var lockedID int
+	if err := tx.QueryRow(`SELECT id_pelanggan FROM pelanggan WHERE id_pelanggan=? FOR UPDATE`, id).Scan(&lockedID); err != nil {
+		tx.Rollback()
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Pelanggan tidak ditemukan"})
+		return
+	}
+
+	sebelum, _ := audit.Snapshot(tx, audit.EntitasPelanggan, id)
+
+	_, err = tx.Exec(`
+		UPDATE pelanggan SET nama_pelanggan=?, no_whatsapp=?, alamat=?, catatan=?
+		WHERE id_pelanggan=?
+	`, req.NamaPelanggan, req.NoWhatsapp, req.Alamat, req.Catatan, id)
+	if err != nil {
+		tx.Rollback()
+		log.Println(" Error update pelanggan:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE servis SET nama_pelanggan=?, no_whatsapp=? WHERE id_pelanggan=?
+	`, req.NamaPelanggan, req.NoWhatsapp, id); err != nil {
+		tx.Rollback()
+		log.Println(" Error update salinan pelanggan di servis:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	if err := audit.CatatTx(tx, r, audit.AksiUpdate, audit.EntitasPelanggan, id, sebelum); err != nil {
+		tx.Rollback()
+		writeAuditError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"message": "Pelanggan berhasil diperbarui"})
+}
+
+// DELETE: Hapus pelanggan (hanya jika belum punya servis)
+func DeletePelanggan(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractLastID(r.URL.Path)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	var totalServis int
+	if err := database.DB.QueryRow(`SELECT COUNT(*) FROM servis WHERE id_pelanggan=?`, id).Scan(&totalServis); err != nil {
+		log.Println(" Error check servis pelanggan:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if totalServis > 0 {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Pelanggan sudah memiliki riwayat servis"})
+		return
+	}
+
+	sebelum, _ := audit.Snapshot(database.DB, audit.EntitasPelanggan, id)
+
+	result, err := database.DB.Exec(`DELETE FROM pelanggan WHERE id_pelanggan=?`, id)
+	if err != nil {
+		log.Println(" Error delete pelanggan:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Pelanggan tidak ditemukan"})
+		return
+	}
+
+	audit.Catat(database.DB, r, audit.AksiDelete, audit.EntitasPelanggan, id, sebelum)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Pelanggan berhasil dihapus"})
+}
+
+// =======================================================
+// SINKRON PELANGGAN DARI SERVIS LAMA (Admin)
+// =======================================================
+func SinkronPelanggan(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rows, err := database.DB.Query(`
+		SELECT id_servis, nama_pelanggan, no_whatsapp
+		FROM servis
+		WHERE id_pelanggan IS NULL
+		ORDER BY tanggal_masuk DESC
+	`)
+	if err != nil {
+		log.Println(" Error query servis tanpa pelanggan:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	var list []models.Servis
+	for rows.Next() {
+		var s models.Servis
+		if err := rows.Scan(&s.IDServis, &s.NamaPelanggan, &s.NoWhatsapp); err == nil {
+			list = append(list, s)
+		}
+	}
+	rows.Close()
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	// Servis terbaru diproses dulu sehingga nama terbaru dipakai untuk pelanggan baru
+	terhubung := 0
+	gagal := []int{}
+	for _, s := range list {
+		if err := resolvePelanggan(tx, &s); err != nil {
+			var validasi *pelangganError
+			if errors.As(err, &validasi) {
+				gagal = append(gagal, s.IDServis)
+				continue
+			}
+			tx.Rollback()
+			writePelangganError(w, err)
+			return
+		}
+
+		if _, err := tx.Exec(`
+			UPDATE servis SET id_pelanggan=?, nama_pelanggan=?, no_whatsapp=? WHERE id_servis=?
+		`, s.IDPelanggan, s.NamaPelanggan, s.NoWhatsapp, s.IDServis); err != nil {
+			tx.Rollback()
+			log.Println(" Error link servis ke pelanggan:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+			return
+		}
+		terhubung++
+	}
+
+	if err := audit.Record(tx, r, audit.Entry{
+		Aksi:    "pelanggan.sinkron",
+		Entitas: audit.EntitasPelanggan,
+		Sesudah: map[string]interface{}{"servis_terhubung": terhubung, "servis_nomor_salah": gagal},
+	}); err != nil {
+		tx.Rollback()
+		writeAuditError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":            "Sinkron pelanggan selesai",
+		"servis_terhubung":   terhubung,
+		"servis_nomor_salah": gagal,
+	})
+}
+
+// =======================================================
+// GET KANDIDAT PELANGGAN DUPLIKAT (Admin)
+// =======================================================
+func GetDuplikatPelanggan(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Nomor sudah unik, duplikat dicari dari nama yang sama dengan nomor berbeda
+	rows, err := database.DB.Query(`
+		SELECT p.id_pelanggan, p.nama_pelanggan, p.no_whatsapp,
+			COALESCE(p.alamat, ''), COALESCE(p.catatan, ''), p.created_at,
+			(SELECT COUNT(*) FROM servis s WHERE s.id_pelanggan = p.id_pelanggan)
+		FROM pelanggan p
+		JOIN (
+			SELECT LOWER(TRIM(nama_pelanggan)) as nama_key
+			FROM pelanggan
+			GROUP BY nama_key
+			HAVING COUNT(*) > 1
+		) d ON LOWER(TRIM(p.nama_pelanggan)) = d.nama_key
+		ORDER BY LOWER(TRIM(p.nama_pelanggan)), p.id_pelanggan
+	`)
+	if err != nil {
+		log.Println(" Error query duplikat pelanggan:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	groups := map[string][]models.Pelanggan{}
+	var order []string
+	for rows.Next() {
+		var p models.Pelanggan
+		if err := rows.Scan(&p.IDPelanggan, &p.NamaPelanggan, &p.NoWhatsapp,
+			&p.Alamat, &p.Catatan, &p.CreatedAt, &p.TotalServis); err != nil {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(p.NamaPelanggan))
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], p)
+	}
+
+	data := []map[string]interface{}{}
+	for _, key := range order {
+		data = append(data, map[string]interface{}{
+			"nama":      groups[key][0].NamaPelanggan,
+			"pelanggan": groups[key],
+		})
+	}
+
+	json.NewEncoder(w).Encode(data)
+}
+
+// =======================================================
+// MERGE PELANGGAN (Admin)
+// =======================================================
+func MergePelanggan(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req models.MergePelangganRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	if req.IDTujuan == 0 || len(req.IDSumber) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "id_tujuan dan id_sumber wajib diisi"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	var nama, no string
+	err = tx.QueryRow(`
+		SELECT nama_pelanggan, no_whatsapp FROM pelanggan WHERE id_pelanggan = ? FOR UPDATE
+	`, req.IDTujuan).Scan(&nama, &no)
+	if err != nil {
+		tx.Rollback()
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Pelanggan tujuan tidak ditemukan"})
+		return
+	}
+
+	digabung := 0
+	for _, idSumber := range req.IDSumber {
+		if idSumber == req.IDTujuan {
+			continue
+		}
+
+		sebelum, _ := audit.Snapshot(tx, audit.EntitasPelanggan, idSumber)
+
+		if _, err := tx.Exec(`
+			UPDATE servis SET id_pelanggan=?, nama_pelanggan=?, no_whatsapp=? WHERE id_pelanggan=?
+		`, req.IDTujuan, nama, no, idSumber); err != nil {
+			tx.Rollback()
+			log.Println(" Error pindah servis pelanggan:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+			return
+		}
+
+		result, err := tx.Exec(`DELETE FROM pelanggan WHERE id_pelanggan=?`, idSumber)
+		if err != nil {
+			tx.Rollback()
+			log.Println(" Error delete pelanggan sumber:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+			return
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected > 0 {
+			digabung++
+			if err := audit.Record(tx, r, audit.Entry{
+				Aksi:      "pelanggan.merge",
+				Entitas:   audit.EntitasPelanggan,
+				IDEntitas: idSumber,
+				Sebelum:   sebelum,
+				Sesudah:   map[string]int{"digabung_ke": req.IDTujuan},
+			}); err != nil {
+				tx.Rollback()
+				writeAuditError(w, err)
+				return
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":            "Pelanggan berhasil digabung",
+		"id_pelanggan":       req.IDTujuan,
+		"pelanggan_digabung": digabung,
+	})
+}
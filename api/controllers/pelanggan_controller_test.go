@@ -0,0 +1,34 @@
+package controllers
+
+import "testing"
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		valid bool
+	}{
+		{"081234567890", "6281234567890", true},
+		{"+62 812-3456-7890", "6281234567890", true},
+		{"6281234567890", "6281234567890", true},
+		{"81234567890", "6281234567890", true},
+		{"(0812) 345 678", "62812345678", true},
+		{"0812345678901234", "", false}, // terlalu panjang
+		{"0812345", "", false},          // terlalu pendek
+		{"0211234567", "", false},       // telepon rumah, bukan 08xx
+		{"12345678901", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := normalizePhone(tt.input)
+			if (err == nil) != tt.valid {
+				t.Fatalf("normalizePhone(%q) error = %v, seharusnya valid = %v", tt.input, err, tt.valid)
+			}
+			if got != tt.want {
+				t.Errorf("normalizePhone(%q) = %q, seharusnya %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
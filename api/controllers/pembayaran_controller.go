@@ -0,0 +1,327 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"service_hp/audit"
+	"service_hp/database"
+	"service_hp/models"
+	"strings"
+	"time"
+)
+
+// totalDibayarSQL - total dibayar bersih untuk servis alias s, refund mengurangi
+const totalDibayarSQL = `COALESCE((
+			SELECT SUM(CASE WHEN pb.jenis = 'refund' THEN -pb.jumlah ELSE pb.jumlah END)
+			FROM pembayaran pb WHERE pb.id_servis = s.id_servis
+		), 0)`
+
+// pembayaranError - pembayaran ditolak karena input / aturan tagihan (400)
+type pembayaranError struct {
+	pesan string
+}
+
+func (e *pembayaranError) Error() string {
+	return e.pesan
+}
+
+// tagihanError dikembalikan saat servis mau diambil padahal belum lunas
+type tagihanError struct {
+	Sisa float64
+}
+
+func (e *tagihanError) Error() string {
+	return fmt.Sprintf("Servis belum lunas, sisa tagihan Rp %.2f", e.Sisa)
+}
+
+func roundRupiah(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// Helper: normalisasi & validasi request pembayaran
+func validatePembayaran(req *models.PembayaranRequest) error {
+	req.Jenis = strings.ToLower(strings.TrimSpace(req.Jenis))
+	req.Metode = strings.ToLower(strings.TrimSpace(req.Metode))
+	req.Referensi = truncate(strings.TrimSpace(req.Referensi), 100)
+	req.Keterangan = truncate(strings.TrimSpace(req.Keterangan), 255)
+	req.Jumlah = roundRupiah(req.Jumlah)
+
+	switch req.Jenis {
+	case models.PembayaranDP, models.PembayaranPelunasan, models.PembayaranRefund:
+	default:
+		return &pembayaranError{"Jenis pembayaran harus dp, pelunasan, atau refund"}
+	}
+	switch req.Metode {
+	case models.MetodeTunai, models.MetodeTransfer, models.MetodeQRIS:
+	default:
+		return &pembayaranError{"Metode pembayaran harus tunai, transfer, atau qris"}
+	}
+	if req.Jumlah <= 0 {
+		return &pembayaranError{"Jumlah pembayaran harus lebih dari 0"}
+	}
+	return nil
+}
+
+// Helper: tagihan satu servis (biaya_total, total dibayar bersih, sisa)
+func loadTagihan(q queryer, idServis int) (models.Tagihan, error) {
+	var t models.Tagihan
+	err := q.QueryRow(`SELECT s.biaya_total, `+totalDibayarSQL+` FROM servis s WHERE s.id_servis = ?`, idServis).
+		Scan(&t.BiayaTotal, &t.TotalDibayar)
+	if err == sql.ErrNoRows {
+		return t, errServisTidakDitemukan
+	}
+	t.SisaTagihan = roundRupiah(t.BiayaTotal - t.TotalDibayar)
+	return t, err
+}
+
+// Helper: catat pembayaran servis dalam transaksi. DP boleh melebihi tagihan karena biaya
+// bisa belum final saat servis diterima; pelunasan tidak boleh melebihi sisa tagihan dan
+// refund tidak boleh melebihi total yang sudah dibayar.
+func recordPembayaran(tx *sql.Tx, idServis int, req models.PembayaranRequest, idUser *int) (int, models.Tagihan, error) {
+	var status string
+	err := tx.QueryRow(`SELECT status_servis FROM servis WHERE id_servis = ? FOR UPDATE`, idServis).Scan(&status)
+	if err == sql.ErrNoRows {
+		return 0, models.Tagihan{}, errServisTidakDitemukan
+	}
+	if err != nil {
+		return 0, models.Tagihan{}, err
+	}
+
+	t, err := loadTagihan(tx, idServis)
+	if err != nil {
+		return 0, t, err
+	}
+
+	switch req.Jenis {
+	case models.PembayaranDP, models.PembayaranPelunasan:
+		if status == models.StatusBatal {
+			return 0, t, &pembayaranError{"Servis batal tidak bisa menerima pembayaran"}
+		}
+		if req.Jenis == models.PembayaranPelunasan && req.Jumlah > t.SisaTagihan {
+			return 0, t, &pembayaranError{fmt.Sprintf("Jumlah melebihi sisa tagihan Rp %.2f", math.Max(t.SisaTagihan, 0))}
+		}
+		t.TotalDibayar += req.Jumlah
+	case models.PembayaranRefund:
+		if req.Jumlah > t.TotalDibayar {
+			return 0, t, &pembayaranError{fmt.Sprintf("Refund melebihi total dibayar Rp %.2f", t.TotalDibayar)}
+		}
+		t.TotalDibayar -= req.Jumlah
+	}
+	t.TotalDibayar = roundRupiah(t.TotalDibayar)
+	t.SisaTagihan = roundRupiah(t.BiayaTotal - t.TotalDibayar)
+
+	res, err := tx.Exec(`
+		INSERT INTO pembayaran (id_servis, jenis, metode, jumlah, referensi, keterangan, id_user)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, idServis, req.Jenis, req.Metode, req.Jumlah, req.Referensi, req.Keterangan, idUser)
+	if err != nil {
+		return 0, t, err
+	}
+	id, _ := res.LastInsertId()
+	return int(id), t, nil
+}
+
+// Helper: tulis response untuk error pembayaran
+func writePembayaranError(w http.ResponseWriter, err error) {
+	var pe *pembayaranError
+	switch {
+	case errors.Is(err, errServisTidakDitemukan):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	case errors.As(err, &pe):
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": pe.Error()})
+	default:
+		log.Println(" Error pembayaran:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal mencatat pembayaran"})
+	}
+}
+
+// Helper: scan baris pembayaran (lihat kolom di pembayaranQuery)
+func scanPembayaran(rows *sql.Rows) ([]models.Pembayaran, error) {
+	list := []models.Pembayaran{}
+	for rows.Next() {
+		var p models.Pembayaran
+		var idUser sql.NullInt64
+		if err := rows.Scan(&p.IDPembayaran, &p.IDServis, &p.Jenis, &p.Metode, &p.Jumlah,
+			&p.Referensi, &p.Keterangan, &idUser, &p.NamaUser, &p.Tanggal); err != nil {
+			return nil, err
+		}
+		p.IDUser = nullIntPtr(idUser)
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+// pembayaranQuery - kolom untuk scanPembayaran, lanjutkan dengan WHERE/ORDER BY
+const pembayaranQuery = `
+		SELECT p.id_pembayaran, p.id_servis, p.jenis, p.metode, p.jumlah,
+			COALESCE(p.referensi, ''), COALESCE(p.keterangan, ''), p.id_user, COALESCE(u.nama, ''), p.tanggal
+		FROM pembayaran p
+		LEFT JOIN user u ON p.id_user = u.id_user
+`
+
+// =======================================================
+// GET PEMBAYARAN SERVIS (tagihan + riwayat)
+// =======================================================
+func GetPembayaranServis(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractServisSubID(r.URL.Path, "pembayaran")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	t, err := loadTagihan(database.DB, id)
+	if err != nil {
+		writePembayaranError(w, err)
+		return
+	}
+
+	rows, err := database.DB.Query(pembayaranQuery+`
+		WHERE p.id_servis = ?
+		ORDER BY p.tanggal ASC, p.id_pembayaran ASC
+	`, id)
+	if err != nil {
+		log.Println(" Error query pembayaran:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	list, err := scanPembayaran(rows)
+	if err != nil {
+		log.Println(" Error scan pembayaran:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.PembayaranServis{Tagihan: t, IDServis: id, Pembayaran: list})
+}
+
+// =======================================================
+// CATAT PEMBAYARAN SERVIS (DP / pelunasan / refund)
+// =======================================================
+func CreatePembayaran(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractServisSubID(r.URL.Path, "pembayaran")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.PembayaranRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+	if err := validatePembayaran(&req); err != nil {
+		writePembayaranError(w, err)
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+	defer tx.Rollback()
+
+	idPembayaran, t, err := recordPembayaran(tx, id, req, currentUserID(r))
+	if err != nil {
+		writePembayaranError(w, err)
+		return
+	}
+
+	if err := audit.CatatTx(tx, r, audit.AksiCreate, audit.EntitasPembayaran, idPembayaran, nil); err != nil {
+		writeAuditError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":       "Pembayaran berhasil dicatat",
+		"id_pembayaran": idPembayaran,
+		"tagihan":       t,
+	})
+}
+
+// =======================================================
+// REKAP PEMBAYARAN (uang diterima per metode)
+// ?dari=YYYY-MM-DD&sampai=YYYY-MM-DD, default hari ini
+// =======================================================
+func GetRekapPembayaran(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	today := time.Now().Format("2006-01-02")
+	rekap := models.RekapPembayaran{
+		TanggalAwal:  r.URL.Query().Get("dari"),
+		TanggalAkhir: r.URL.Query().Get("sampai"),
+		PerMetode:    map[string]float64{models.MetodeTunai: 0, models.MetodeTransfer: 0, models.MetodeQRIS: 0},
+	}
+	if rekap.TanggalAwal == "" {
+		rekap.TanggalAwal = today
+	}
+	if rekap.TanggalAkhir == "" {
+		rekap.TanggalAkhir = rekap.TanggalAwal
+	}
+	if err := validatePeriode(rekap.TanggalAwal, rekap.TanggalAkhir); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	rows, err := database.DB.Query(pembayaranQuery+`
+		WHERE DATE(p.tanggal) BETWEEN ? AND ?
+		ORDER BY p.tanggal ASC, p.id_pembayaran ASC
+	`, rekap.TanggalAwal, rekap.TanggalAkhir)
+	if err != nil {
+		log.Println(" Error query rekap pembayaran:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	rekap.Pembayaran, err = scanPembayaran(rows)
+	if err != nil {
+		log.Println(" Error scan pembayaran:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	for _, p := range rekap.Pembayaran {
+		jumlah := p.Jumlah
+		if p.Jenis == models.PembayaranRefund {
+			jumlah = -jumlah
+			rekap.TotalRefund = roundRupiah(rekap.TotalRefund + p.Jumlah)
+		}
+		rekap.PerMetode[p.Metode] = roundRupiah(rekap.PerMetode[p.Metode] + jumlah)
+		rekap.TotalDiterima = roundRupiah(rekap.TotalDiterima + jumlah)
+	}
+
+	json.NewEncoder(w).Encode(rekap)
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"service_hp/models"
+	"strings"
+	"testing"
+)
+
+func TestValidatePembayaran(t *testing.T) {
+	tests := []struct {
+		nama  string
+		req   models.PembayaranRequest
+		want  models.PembayaranRequest
+		valid bool
+	}{
+		{
+			"dp tunai dinormalisasi",
+			models.PembayaranRequest{Jenis: " DP ", Metode: "Tunai", Jumlah: 50000.004, Referensi: " ", Keterangan: " uang muka "},
+			models.PembayaranRequest{Jenis: "dp", Metode: "tunai", Jumlah: 50000, Keterangan: "uang muka"},
+			true,
+		},
+		{
+			"pelunasan qris",
+			models.PembayaranRequest{Jenis: "pelunasan", Metode: "QRIS", Jumlah: 125000.5, Referensi: "TRX-1"},
+			models.PembayaranRequest{Jenis: "pelunasan", Metode: "qris", Jumlah: 125000.5, Referensi: "TRX-1"},
+			true,
+		},
+		{
+			"referensi dipotong",
+			models.PembayaranRequest{Jenis: "refund", Metode: "transfer", Jumlah: 1, Referensi: strings.Repeat("x", 120)},
+			models.PembayaranRequest{Jenis: "refund", Metode: "transfer", Jumlah: 1, Referensi: strings.Repeat("x", 100)},
+			true,
+		},
+		{"jenis tidak dikenal", models.PembayaranRequest{Jenis: "cicilan", Metode: "tunai", Jumlah: 1}, models.PembayaranRequest{}, false},
+		{"metode tidak dikenal", models.PembayaranRequest{Jenis: "dp", Metode: "kartu", Jumlah: 1}, models.PembayaranRequest{}, false},
+		{"jumlah nol", models.PembayaranRequest{Jenis: "dp", Metode: "tunai"}, models.PembayaranRequest{}, false},
+		{"jumlah negatif", models.PembayaranRequest{Jenis: "refund", Metode: "tunai", Jumlah: -1000}, models.PembayaranRequest{}, false},
+		{"dibulatkan jadi nol", models.PembayaranRequest{Jenis: "dp", Metode: "tunai", Jumlah: 0.001}, models.PembayaranRequest{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			req := tt.req
+			err := validatePembayaran(&req)
+			if (err == nil) != tt.valid {
+				t.Fatalf("validatePembayaran error = %v, seharusnya valid = %v", err, tt.valid)
+			}
+			if tt.valid && req != tt.want {
+				t.Errorf("validatePembayaran = %+v, seharusnya %+v", req, tt.want)
+			}
+		})
+	}
+}
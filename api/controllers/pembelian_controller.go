@@ -0,0 +1,634 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"service_hp/audit"
+	"service_hp/database"
+	"service_hp/models"
+	"strconv"
+	"strings"
+)
+
+// queryer dipenuhi oleh *sql.DB maupun *sql.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Helper: ambil id pembelian dari /api/pegawai/pembelian/{id}[/aksi]
+func extractPembelianID(path string) (int, error) {
+	idStr := strings.TrimPrefix(path, "/api/pegawai/pembelian/")
+	idStr = strings.Split(strings.TrimSuffix(idStr, "/"), "/")[0]
+	return strconv.Atoi(idStr)
+}
+
+// Helper: harga modal rata-rata tertimbang setelah barang masuk
+func weightedAverageCost(stok int, hargaModal float64, jumlah int, hargaBeli float64) float64 {
+	if stok <= 0 {
+		return hargaBeli
+	}
+	avg := (float64(stok)*hargaModal + float64(jumlah)*hargaBeli) / float64(stok+jumlah)
+	return math.Round(avg*100) / 100
+}
+
+// Helper: validasi baris pembelian dan hitung subtotal
+func validateDetailPembelian(details []models.DetailPembelian) (float64, error) {
+	if len(details) == 0 {
+		return 0, errors.New("Pembelian harus memiliki minimal 1 barang")
+	}
+
+	var total float64
+	for i := range details {
+		d := &details[i]
+		if d.IDBarang == 0 {
+			return 0, errors.New("Barang wajib dipilih")
+		}
+		if d.Jumlah <= 0 {
+			return 0, errors.New("Jumlah barang harus lebih dari 0")
+		}
+		if d.HargaBeli < 0 {
+			return 0, errors.New("Harga beli tidak boleh negatif")
+		}
+		d.Subtotal = float64(d.Jumlah) * d.HargaBeli
+		total += d.Subtotal
+	}
+	return total, nil
+}
+
+// Helper: ambil pembelian beserta detailnya
+func loadPembelian(q queryer, id int) (models.Pembelian, error) {
+	var p models.Pembelian
+	var idUser sql.NullInt64
+	var tglDiterima sql.NullString
+
+	err := q.QueryRow(`
+		SELECT
+			p.id_pembelian, p.id_supplier, s.nama_supplier,
+			COALESCE(p.no_faktur, ''), DATE_FORMAT(p.tanggal_pembelian, '%Y-%m-%d'),
+			p.status, p.total_pembelian, COALESCE(p.keterangan, ''),
+			p.id_user, p.tanggal_diterima, p.created_at
+		FROM pembelian p
+		JOIN supplier s ON p.id_supplier = s.id_supplier
+		WHERE p.id_pembelian = ?
+	`, id).Scan(
+		&p.IDPembelian, &p.IDSupplier, &p.NamaSupplier,
+		&p.NoFaktur, &p.TanggalPembelian,
+		&p.Status, &p.TotalPembelian, &p.Keterangan,
+		&idUser, &tglDiterima, &p.CreatedAt,
+	)
+	if err != nil {
+		return p, err
+	}
+	p.IDUser = nullIntPtr(idUser)
+	if tglDiterima.Valid {
+		p.TanggalDiterima = &tglDiterima.String
+	}
+
+	rows, err := q.Query(`
+		SELECT d.id_detail_pembelian, d.id_pembelian, d.id_barang, b.nama_barang,
+			d.jumlah, d.harga_beli, d.subtotal
+		FROM detail_pembelian d
+		JOIN barang b ON d.id_barang = b.id_barang
+		WHERE d.id_pembelian = ?
+		ORDER BY d.id_detail_pembelian ASC
+	`, id)
+	if err != nil {
+		return p, err
+	}
+	defer rows.Close()
+
+	p.Detail = []models.DetailPembelian{}
+	for rows.Next() {
+		var d models.DetailPembelian
+		if err := rows.Scan(&d.IDDetailPembelian, &d.IDPembelian, &d.IDBarang, &d.NamaBarang,
+			&d.Jumlah, &d.HargaBeli, &d.Subtotal); err != nil {
+			return p, err
+		}
+		p.Detail = append(p.Detail, d)
+	}
+	return p, rows.Err()
+}
+
+// Helper: simpan baris detail pembelian
+// Helper: catat audit pembelian beserta detailnya (sebelum nil untuk create)
+func auditPembelian(tx *sql.Tx, r *http.Request, aksi string, id int, sebelum interface{}) error {
+	sesudah, err := loadPembelian(tx, id)
+	if err != nil {
+		return err
+	}
+	return audit.Record(tx, r, audit.Entry{Aksi: aksi, Entitas: audit.EntitasPembelian, IDEntitas: id, Sebelum: sebelum, Sesudah: sesudah})
+}
+
+func insertDetailPembelian(tx *sql.Tx, idPembelian int, details []models.DetailPembelian) error {
+	for _, d := range details {
+		_, err := tx.Exec(`
+			INSERT INTO detail_pembelian (id_pembelian, id_barang, jumlah, harga_beli, subtotal)
+			VALUES (?, ?, ?, ?, ?)
+		`, idPembelian, d.IDBarang, d.Jumlah, d.HargaBeli, d.Subtotal)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// =======================================================
+// GET ALL PEMBELIAN
+// =======================================================
+func GetAllPembelian(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := `
+		SELECT
+			p.id_pembelian, p.id_supplier, s.nama_supplier,
+			COALESCE(p.no_faktur, ''), DATE_FORMAT(p.tanggal_pembelian, '%Y-%m-%d'),
+			p.status, p.total_pembelian, COALESCE(p.keterangan, ''),
+			p.id_user, p.tanggal_diterima, p.created_at
+		FROM pembelian p
+		JOIN supplier s ON p.id_supplier = s.id_supplier
+		WHERE 1=1
+	`
+	var args []interface{}
+
+	if status := r.URL.Query().Get("status"); status != "" {
+		query += " AND p.status = ?"
+		args = append(args, status)
+	}
+	if idSupplier := r.URL.Query().Get("id_supplier"); idSupplier != "" {
+		query += " AND p.id_supplier = ?"
+		args = append(args, idSupplier)
+	}
+	query += " ORDER BY p.tanggal_pembelian DESC, p.id_pembelian DESC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		log.Println(" Error query pembelian:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	list := []models.Pembelian{}
+	for rows.Next() {
+		var p models.Pembelian
+		var idUser sql.NullInt64
+		var tglDiterima sql.NullString
+
+		err := rows.Scan(
+			&p.IDPembelian, &p.IDSupplier, &p.NamaSupplier,
+			&p.NoFaktur, &p.TanggalPembelian,
+			&p.Status, &p.TotalPembelian, &p.Keterangan,
+			&idUser, &tglDiterima, &p.CreatedAt,
+		)
+		if err != nil {
+			log.Println(" Error scan pembelian:", err)
+			continue
+		}
+		p.IDUser = nullIntPtr(idUser)
+		if tglDiterima.Valid {
+			p.TanggalDiterima = &tglDiterima.String
+		}
+		list = append(list, p)
+	}
+
+	json.NewEncoder(w).Encode(list)
+}
+
+// =======================================================
+// GET PEMBELIAN DETAIL
+// =======================================================
+func GetPembelianDetail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractPembelianID(r.URL.Path)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	p, err := loadPembelian(database.DB, id)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Pembelian tidak ditemukan"})
+		return
+	} else if err != nil {
+		log.Println(" Error get pembelian:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(p)
+}
+
+// =======================================================
+// CREATE PEMBELIAN (status draft)
+// =======================================================
+func CreatePembelian(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req models.Pembelian
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	if req.IDSupplier == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Supplier wajib dipilih"})
+		return
+	}
+
+	total, err := validateDetailPembelian(req.Detail)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	var res sql.Result
+	if strings.TrimSpace(req.TanggalPembelian) == "" {
+		res, err = tx.Exec(`
+			INSERT INTO pembelian (id_supplier, no_faktur, tanggal_pembelian, status, total_pembelian, keterangan, id_user)
+			VALUES (?, ?, CURDATE(), 'draft', ?, ?, ?)
+		`, req.IDSupplier, req.NoFaktur, total, req.Keterangan, currentUserID(r))
+	} else {
+		res, err = tx.Exec(`
+			INSERT INTO pembelian (id_supplier, no_faktur, tanggal_pembelian, status, total_pembelian, keterangan, id_user)
+			VALUES (?, ?, ?, 'draft', ?, ?, ?)
+		`, req.IDSupplier, req.NoFaktur, req.TanggalPembelian, total, req.Keterangan, currentUserID(r))
+	}
+	if err != nil {
+		tx.Rollback()
+		log.Println(" Error insert pembelian:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	newID64, _ := res.LastInsertId()
+	newID := int(newID64)
+
+	if err := insertDetailPembelian(tx, newID, req.Detail); err != nil {
+		tx.Rollback()
+		log.Println(" Error insert detail pembelian:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	if err := auditPembelian(tx, r, audit.AksiCreate, newID, nil); err != nil {
+		tx.Rollback()
+		writeAuditError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":      "Pembelian berhasil dibuat",
+		"id_pembelian": newID,
+	})
+}
+
+// =======================================================
+// UPDATE PEMBELIAN (hanya draft)
+// =======================================================
+func UpdatePembelian(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractPembelianID(r.URL.Path)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.Pembelian
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	if req.IDSupplier == 0 || strings.TrimSpace(req.TanggalPembelian) == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Supplier dan tanggal pembelian wajib diisi"})
+		return
+	}
+
+	total, err := validateDetailPembelian(req.Detail)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	var status string
+	err = tx.QueryRow(`SELECT status FROM pembelian WHERE id_pembelian=? FOR UPDATE`, id).Scan(&status)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Pembelian tidak ditemukan"})
+		return
+	} else if err != nil {
+		tx.Rollback()
+		log.Println(" Error lock pembelian:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	if status != models.PembelianDraft {
+		tx.Rollback()
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Hanya pembelian berstatus draft yang bisa diubah"})
+		return
+	}
+
+	sebelum, _ := loadPembelian(tx, id)
+
+	_, err = tx.Exec(`
+		UPDATE pembelian SET id_supplier=?, no_faktur=?, tanggal_pembelian=?, total_pembelian=?, keterangan=?
+		WHERE id_pembelian=?
+	`, req.IDSupplier, req.NoFaktur, req.TanggalPembelian, total, req.Keterangan, id)
+	if err != nil {
+		tx.Rollback()
+		log.Println(" Error update pembelian:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	if _, err := tx.Exec(`DELETE FROM detail_pembelian WHERE id_pembelian=?`, id); err != nil {
+		tx.Rollback()
+		log.Println(" Error delete detail pembelian:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to delete old details"})
+		return
+	}
+
+	if err := insertDetailPembelian(tx, id, req.Detail); err != nil {
+		tx.Rollback()
+		log.Println(" Error insert detail pembelian:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	if err := auditPembelian(tx, r, audit.AksiUpdate, id, sebelum); err != nil {
+		tx.Rollback()
+		writeAuditError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"message": "Pembelian berhasil diperbarui"})
+}
+
+// =======================================================
+// TERIMA PEMBELIAN (stok masuk + hitung ulang harga_modal)
+// =======================================================
+func TerimaPembelian(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractPembelianID(r.URL.Path)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	var status string
+	err = tx.QueryRow(`SELECT status FROM pembelian WHERE id_pembelian=? FOR UPDATE`, id).Scan(&status)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Pembelian tidak ditemukan"})
+		return
+	} else if err != nil {
+		tx.Rollback()
+		log.Println(" Error lock pembelian:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	if status != models.PembelianDraft {
+		tx.Rollback()
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Pembelian sudah " + status})
+		return
+	}
+
+	p, err := loadPembelian(tx, id)
+	if err != nil {
+		tx.Rollback()
+		log.Println(" Error load pembelian:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	for _, d := range p.Detail {
+		var stok int
+		var hargaModal float64
+		err := tx.QueryRow(`
+			SELECT stok, COALESCE(harga_modal, 0) FROM barang WHERE id_barang = ? FOR UPDATE
+		`, d.IDBarang).Scan(&stok, &hargaModal)
+		if err != nil {
+			tx.Rollback()
+			log.Println(" Error lock barang:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+			return
+		}
+
+		modalBaru := weightedAverageCost(stok, hargaModal, d.Jumlah, d.HargaBeli)
+		if _, err := tx.Exec(`UPDATE barang SET harga_modal = ? WHERE id_barang = ?`, modalBaru, d.IDBarang); err != nil {
+			tx.Rollback()
+			log.Println(" Error update harga_modal:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Gagal memperbarui harga modal"})
+			return
+		}
+
+		err = recordStockMovement(tx, models.StockMovement{
+			IDBarang:    d.IDBarang,
+			Jenis:       models.MutasiPembelian,
+			Jumlah:      d.Jumlah,
+			IDPembelian: &id,
+			IDUser:      currentUserID(r),
+			Keterangan:  fmt.Sprintf("Pembelian dari %s %s", p.NamaSupplier, p.NoFaktur),
+		})
+		if err != nil {
+			tx.Rollback()
+			writeStokError(w, err)
+			return
+		}
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE pembelian SET status='diterima', tanggal_diterima=NOW() WHERE id_pembelian=?
+	`, id); err != nil {
+		tx.Rollback()
+		log.Println(" Error update status pembelian:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	if err := auditPembelian(tx, r, "pembelian.terima", id, p); err != nil {
+		tx.Rollback()
+		writeAuditError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"message": "Barang pembelian berhasil diterima"})
+}
+
+// =======================================================
+// BATAL PEMBELIAN (hanya draft)
+// =======================================================
+func BatalPembelian(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractPembelianID(r.URL.Path)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	sebelum, _ := audit.Snapshot(database.DB, audit.EntitasPembelian, id)
+
+	result, err := database.DB.Exec(`
+		UPDATE pembelian SET status='batal' WHERE id_pembelian=? AND status='draft'
+	`, id)
+	if err != nil {
+		log.Println(" Error batal pembelian:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Pembelian tidak ditemukan atau bukan draft"})
+		return
+	}
+
+	audit.Catat(database.DB, r, "pembelian.batal", audit.EntitasPembelian, id, sebelum)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Pembelian dibatalkan"})
+}
+
+// =======================================================
+// RIWAYAT PEMBELIAN PER BARANG
+// =======================================================
+func GetRiwayatPembelianBarang(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	idBarang, err := extractBarangSubID(r.URL.Path, "pembelian")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT
+			p.id_pembelian, s.nama_supplier, COALESCE(p.no_faktur, ''),
+			DATE_FORMAT(p.tanggal_pembelian, '%Y-%m-%d'), p.status,
+			d.jumlah, d.harga_beli, d.subtotal
+		FROM detail_pembelian d
+		JOIN pembelian p ON d.id_pembelian = p.id_pembelian
+		JOIN supplier s ON p.id_supplier = s.id_supplier
+		WHERE d.id_barang = ?
+		ORDER BY p.tanggal_pembelian DESC, p.id_pembelian DESC
+	`, idBarang)
+	if err != nil {
+		log.Println(" Error query riwayat pembelian:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	data := []map[string]interface{}{}
+	for rows.Next() {
+		var idPembelian, jumlah int
+		var namaSupplier, noFaktur, tanggal, status string
+		var hargaBeli, subtotal float64
+
+		if err := rows.Scan(&idPembelian, &namaSupplier, &noFaktur, &tanggal, &status,
+			&jumlah, &hargaBeli, &subtotal); err != nil {
+			log.Println(" Error scan row:", err)
+			continue
+		}
+
+		data = append(data, map[string]interface{}{
+			"id_pembelian":      idPembelian,
+			"nama_supplier":     namaSupplier,
+			"no_faktur":         noFaktur,
+			"tanggal_pembelian": tanggal,
+			"status":            status,
+			"jumlah":            jumlah,
+			"harga_beli":        hargaBeli,
+			"subtotal":          subtotal,
+		})
+	}
+
+	json.NewEncoder(w).Encode(data)
+}
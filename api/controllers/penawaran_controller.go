@@ -0,0 +1,717 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"service_hp/audit"
+	"service_hp/config"
+	"service_hp/database"
+	"service_hp/models"
+	"service_hp/notifikasi"
+	"service_hp/routes/middleware"
+	"strings"
+	"time"
+)
+
+// errBelumDisetujui - barang dipakai sebelum penawaran disetujui pelanggan
+var errBelumDisetujui = errors.New("Pemakaian barang menunggu persetujuan penawaran oleh pelanggan")
+
+// melebihiPenawaranError - barang dipakai melebihi jumlah pada penawaran yang disetujui
+type melebihiPenawaranError struct {
+	NamaBarang string
+	Disetujui  int
+	Dipakai    int
+}
+
+func (e *melebihiPenawaranError) Error() string {
+	return fmt.Sprintf("Pemakaian %s melebihi penawaran yang disetujui (disetujui %d, dipakai %d)", e.NamaBarang, e.Disetujui, e.Dipakai)
+}
+
+// penawaranError - penawaran ditolak karena input / status penawaran
+type penawaranError struct {
+	status int
+	pesan  string
+}
+
+func (e *penawaranError) Error() string {
+	return e.pesan
+}
+
+// statusPenawaranSQL - status efektif penawaran alias pw, menunggu yang lewat masa berlaku = kedaluwarsa
+const statusPenawaranSQL = `CASE WHEN pw.status = 'menunggu' AND pw.berlaku_sampai < NOW() THEN 'kedaluwarsa' ELSE pw.status END`
+
+// penawaranQuery - kolom penawaran + nama pembuat & pemutus, lanjutkan dengan WHERE/ORDER BY
+const penawaranQuery = `
+	SELECT pw.id_penawaran, pw.id_servis, ` + statusPenawaranSQL + `, pw.biaya_jasa, pw.item, pw.total, pw.catatan,
+		pw.berlaku_sampai, pw.kanal, pw.alasan, pw.diputuskan_at, pw.diputuskan_oleh, COALESCE(up.nama, ''),
+		pw.id_user, COALESCE(u.nama, ''), pw.created_at
+	FROM penawaran pw
+	LEFT JOIN user u ON pw.id_user = u.id_user
+	LEFT JOIN user up ON pw.diputuskan_oleh = up.id_user
+`
+
+// Helper: scan satu baris penawaranQuery
+func scanPenawaran(row interface{ Scan(...interface{}) error }) (models.Penawaran, error) {
+	var p models.Penawaran
+	var item []byte
+	var kanal sql.NullString
+	var diputuskan sql.NullTime
+	var pemutus, idUser sql.NullInt64
+	err := row.Scan(&p.IDPenawaran, &p.IDServis, &p.Status, &p.BiayaJasa, &item, &p.Total, &p.Catatan,
+		&p.BerlakuSampai, &kanal, &p.Alasan, &diputuskan, &pemutus, &p.NamaPemutus,
+		&idUser, &p.NamaUser, &p.CreatedAt)
+	if err != nil {
+		return p, err
+	}
+
+	if err := json.Unmarshal(item, &p.Item); err != nil {
+		return p, err
+	}
+	if kanal.Valid {
+		p.Kanal = &kanal.String
+	}
+	if diputuskan.Valid {
+		p.DiputuskanAt = &diputuskan.Time
+	}
+	p.DiputuskanOleh = nullIntPtr(pemutus)
+	p.IDUser = nullIntPtr(idUser)
+	return p, nil
+}
+
+// Helper: penawaran terakhir satu servis, nil jika belum ada
+func loadPenawaranTerakhir(q queryer, idServis int) (*models.Penawaran, error) {
+	p, err := scanPenawaran(q.QueryRow(penawaranQuery+`WHERE pw.id_servis = ? ORDER BY pw.id_penawaran DESC LIMIT 1`, idServis))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Helper: penawaran terakhir yang membatasi pemakaian barang servis. bebas=true jika
+// persetujuan tidak diwajibkan, ada override admin, atau servis klaim garansi (tidak ditagihkan).
+func penawaranPemakaian(q queryer, idServis int) (*models.Penawaran, bool, error) {
+	if !config.Cfg.Penawaran.WajibPersetujuan {
+		return nil, true, nil
+	}
+
+	var klaim, override bool
+	err := q.QueryRow(`
+		SELECT id_servis_asal IS NOT NULL, penawaran_override_at IS NOT NULL
+		FROM servis WHERE id_servis = ?
+	`, idServis).Scan(&klaim, &override)
+	if err == sql.ErrNoRows {
+		return nil, false, errServisTidakDitemukan
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if klaim || override {
+		return nil, true, nil
+	}
+
+	p, err := loadPenawaranTerakhir(q, idServis)
+	return p, false, err
+}
+
+// Helper: barang boleh dipakai jika penawaran terakhir disetujui atau pemakaian bebas
+func cekPersetujuan(q queryer, idServis int) error {
+	p, bebas, err := penawaranPemakaian(q, idServis)
+	if err != nil || bebas {
+		return err
+	}
+	if p == nil || p.Status != models.PenawaranDisetujui {
+		return errBelumDisetujui
+	}
+	return nil
+}
+
+// Helper: pemakaian barang servis (sesudah perubahan dicatat di detail_servis) tidak boleh
+// melebihi jumlah barang tersebut pada penawaran yang disetujui
+func cekPemakaianPenawaran(tx *sql.Tx, idServis int, barang []int) error {
+	p, bebas, err := penawaranPemakaian(tx, idServis)
+	if err != nil || bebas {
+		return err
+	}
+	if p == nil || p.Status != models.PenawaranDisetujui {
+		return errBelumDisetujui
+	}
+
+	disetujui := map[int]int{}
+	for _, it := range p.Item {
+		if it.IDBarang != nil {
+			disetujui[*it.IDBarang] += it.Jumlah
+		}
+	}
+	dipakai, err := loadStokUsage(tx, idServis)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range barang {
+		if dipakai[id] <= disetujui[id] {
+			continue
+		}
+		var nama string
+		if err := tx.QueryRow(`SELECT nama_barang FROM barang WHERE id_barang = ?`, id).Scan(&nama); err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		return &melebihiPenawaranError{NamaBarang: nama, Disetujui: disetujui[id], Dipakai: dipakai[id]}
+	}
+	return nil
+}
+
+// Helper: normalisasi & validasi request penawaran, hitung biaya item & total
+func validatePenawaran(q queryer, req *models.PenawaranRequest) (float64, error) {
+	req.Catatan = truncate(strings.TrimSpace(req.Catatan), 255)
+	req.BiayaJasa = roundRupiah(req.BiayaJasa)
+	if req.BerlakuHari == 0 {
+		req.BerlakuHari = config.Cfg.Penawaran.BerlakuHari
+	}
+
+	if req.BiayaJasa < 0 {
+		return 0, &penawaranError{http.StatusBadRequest, "Biaya jasa tidak boleh negatif"}
+	}
+	if req.BerlakuHari < 1 || req.BerlakuHari > 90 {
+		return 0, &penawaranError{http.StatusBadRequest, "Masa berlaku penawaran harus 1 sampai 90 hari"}
+	}
+	if len(req.Item) == 0 && req.BiayaJasa == 0 {
+		return 0, &penawaranError{http.StatusBadRequest, "Penawaran minimal berisi biaya jasa atau satu item"}
+	}
+
+	total := req.BiayaJasa
+	for i := range req.Item {
+		it := &req.Item[i]
+		it.Deskripsi = truncate(strings.TrimSpace(it.Deskripsi), 255)
+		if it.Jumlah <= 0 {
+			return 0, &penawaranError{http.StatusBadRequest, "Jumlah item penawaran harus lebih dari 0"}
+		}
+		if it.HargaSatuan < 0 {
+			return 0, &penawaranError{http.StatusBadRequest, "Harga item penawaran tidak boleh negatif"}
+		}
+
+		if it.IDBarang != nil {
+			var nama string
+			err := q.QueryRow(`SELECT nama_barang FROM barang WHERE id_barang = ?`, *it.IDBarang).Scan(&nama)
+			if err == sql.ErrNoRows {
+				return 0, &penawaranError{http.StatusBadRequest, fmt.Sprintf("Barang tidak ditemukan (id %d)", *it.IDBarang)}
+			}
+			if err != nil {
+				return 0, err
+			}
+			if it.Deskripsi == "" {
+				it.Deskripsi = nama
+			}
+		}
+		if it.Deskripsi == "" {
+			return 0, &penawaranError{http.StatusBadRequest, "Deskripsi item penawaran wajib diisi"}
+		}
+
+		it.HargaSatuan = roundRupiah(it.HargaSatuan)
+		it.Biaya = roundRupiah(float64(it.Jumlah) * it.HargaSatuan)
+		total += it.Biaya
+	}
+	return roundRupiah(total), nil
+}
+
+// Helper: catat keputusan pelanggan atas penawaran terakhir servis (dalam transaksi).
+// idUser = pegawai yang mencatat, nil jika pelanggan sendiri lewat link.
+func putuskanPenawaran(tx *sql.Tx, r *http.Request, idServis int, req models.KeputusanPenawaranRequest, idUser *int) (int, error) {
+	// Keputusan selalu tercatat beserta kanalnya (link, whatsapp, telepon, langsung)
+	if req.Kanal == "" {
+		return 0, &penawaranError{http.StatusBadRequest, "Kanal keputusan harus diisi"}
+	}
+
+	var statusServis string
+	err := tx.QueryRow(`SELECT status_servis FROM servis WHERE id_servis = ? FOR UPDATE`, idServis).Scan(&statusServis)
+	if err == sql.ErrNoRows {
+		return 0, errServisTidakDitemukan
+	}
+	if err != nil {
+		return 0, err
+	}
+	if statusServis != models.StatusPending && statusServis != models.StatusDalamPerbaikan {
+		return 0, &penawaranError{http.StatusConflict, "Servis sudah tidak dalam pengerjaan"}
+	}
+
+	p, err := loadPenawaranTerakhir(tx, idServis)
+	if err != nil {
+		return 0, err
+	}
+	if p == nil {
+		return 0, &penawaranError{http.StatusNotFound, "Belum ada penawaran untuk servis ini"}
+	}
+	switch p.Status {
+	case models.PenawaranMenunggu:
+	case models.PenawaranKedaluwarsa:
+		return 0, &penawaranError{http.StatusConflict, "Penawaran sudah kedaluwarsa, minta penawaran baru ke toko"}
+	default:
+		return 0, &penawaranError{http.StatusConflict, "Penawaran sudah " + p.Status}
+	}
+
+	status := models.PenawaranDitolak
+	if req.Setuju {
+		status = models.PenawaranDisetujui
+	}
+
+	sebelum, _ := audit.Snapshot(tx, audit.EntitasPenawaran, p.IDPenawaran)
+	_, err = tx.Exec(`
+		UPDATE penawaran SET status = ?, kanal = ?, alasan = ?, diputuskan_at = NOW(), diputuskan_oleh = ?, ip_keputusan = ?
+		WHERE id_penawaran = ?
+	`, status, req.Kanal, truncate(strings.TrimSpace(req.Alasan), 255), idUser, middleware.ClientIP(r), p.IDPenawaran)
+	if err != nil {
+		return 0, err
+	}
+	if err := audit.CatatTx(tx, r, audit.AksiUpdate, audit.EntitasPenawaran, p.IDPenawaran, sebelum); err != nil {
+		return 0, err
+	}
+	return p.IDPenawaran, nil
+}
+
+// Helper: tulis response untuk error penawaran
+func writePenawaranError(w http.ResponseWriter, err error) {
+	var pe *penawaranError
+	switch {
+	case errors.Is(err, errServisTidakDitemukan):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	case errors.As(err, &pe):
+		w.WriteHeader(pe.status)
+		json.NewEncoder(w).Encode(map[string]string{"error": pe.pesan})
+	default:
+		log.Println(" Error penawaran:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal memproses penawaran"})
+	}
+}
+
+// =======================================================
+// GET PENAWARAN SERVIS (status persetujuan + riwayat penawaran)
+// =======================================================
+func GetPenawaranServis(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractServisSubID(r.URL.Path, "penawaran")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	res := models.PersetujuanServis{IDServis: id, Penawaran: []models.Penawaran{}}
+	var overrideAt sql.NullTime
+	var overrideOleh sql.NullInt64
+	var overrideNama, overrideAlasan string
+	err = database.DB.QueryRow(`
+		SELECT s.penawaran_override_at, s.penawaran_override_oleh, COALESCE(u.nama, ''), s.penawaran_override_alasan
+		FROM servis s
+		LEFT JOIN user u ON s.penawaran_override_oleh = u.id_user
+		WHERE s.id_servis = ?
+	`, id).Scan(&overrideAt, &overrideOleh, &overrideNama, &overrideAlasan)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Servis tidak ditemukan"})
+		return
+	}
+	if err != nil {
+		log.Println(" Error get persetujuan servis:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+	if overrideAt.Valid {
+		res.Override = &models.Override{IDUser: nullIntPtr(overrideOleh), NamaUser: overrideNama, Waktu: overrideAt.Time, Alasan: overrideAlasan}
+	}
+
+	rows, err := database.DB.Query(penawaranQuery+`WHERE pw.id_servis = ? ORDER BY pw.id_penawaran DESC`, id)
+	if err != nil {
+		log.Println(" Error query penawaran:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		p, err := scanPenawaran(rows)
+		if err != nil {
+			log.Println(" Error scan penawaran:", err)
+			continue
+		}
+		res.Penawaran = append(res.Penawaran, p)
+	}
+	if len(res.Penawaran) > 0 {
+		res.StatusPenawaran = res.Penawaran[0].Status
+	}
+
+	switch err := cekPersetujuan(database.DB, id); {
+	case err == nil:
+		res.BolehPakaiBarang = true
+	case !errors.Is(err, errBelumDisetujui):
+		log.Println(" Error cek persetujuan:", err)
+	}
+
+	json.NewEncoder(w).Encode(res)
+}
+
+// =======================================================
+// CREATE PENAWARAN (menggantikan penawaran yang masih menunggu)
+// =======================================================
+func CreatePenawaran(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractServisSubID(r.URL.Path, "penawaran")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.PenawaranRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var statusServis string
+	err = tx.QueryRow(`SELECT status_servis FROM servis WHERE id_servis = ? FOR UPDATE`, id).Scan(&statusServis)
+	if err == sql.ErrNoRows {
+		writePenawaranError(w, errServisTidakDitemukan)
+		return
+	}
+	if err != nil {
+		writePenawaranError(w, err)
+		return
+	}
+	if statusServis != models.StatusPending && statusServis != models.StatusDalamPerbaikan {
+		writePenawaranError(w, &penawaranError{http.StatusConflict, "Penawaran hanya untuk servis pending atau dalam perbaikan"})
+		return
+	}
+
+	total, err := validatePenawaran(tx, &req)
+	if err != nil {
+		writePenawaranError(w, err)
+		return
+	}
+	if req.Item == nil {
+		req.Item = []models.ItemPenawaran{}
+	}
+	item, err := json.Marshal(req.Item)
+	if err != nil {
+		writePenawaranError(w, err)
+		return
+	}
+
+	// Penawaran lama yang belum diputuskan tidak berlaku lagi
+	rows, err := tx.Query(`SELECT id_penawaran FROM penawaran WHERE id_servis = ? AND status = ?`, id, models.PenawaranMenunggu)
+	if err != nil {
+		writePenawaranError(w, err)
+		return
+	}
+	var lama []int
+	for rows.Next() {
+		var idLama int
+		if err := rows.Scan(&idLama); err == nil {
+			lama = append(lama, idLama)
+		}
+	}
+	rows.Close()
+	for _, idLama := range lama {
+		sebelum, _ := audit.Snapshot(tx, audit.EntitasPenawaran, idLama)
+		if _, err := tx.Exec(`UPDATE penawaran SET status = ? WHERE id_penawaran = ?`, models.PenawaranDibatalkan, idLama); err != nil {
+			writePenawaranError(w, err)
+			return
+		}
+		if err := audit.CatatTx(tx, r, audit.AksiUpdate, audit.EntitasPenawaran, idLama, sebelum); err != nil {
+			writeAuditError(w, err)
+			return
+		}
+	}
+
+	berlaku := time.Now().AddDate(0, 0, req.BerlakuHari)
+	res, err := tx.Exec(`
+		INSERT INTO penawaran (id_servis, biaya_jasa, total, item, catatan, berlaku_sampai, id_user)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, id, req.BiayaJasa, total, item, req.Catatan, berlaku, currentUserID(r))
+	if err != nil {
+		writePenawaranError(w, err)
+		return
+	}
+	newID64, _ := res.LastInsertId()
+	newID := int(newID64)
+	if err := audit.CatatTx(tx, r, audit.AksiCreate, audit.EntitasPenawaran, newID, nil); err != nil {
+		writeAuditError(w, err)
+		return
+	}
+
+	// Kirim penawaran ke WhatsApp pelanggan
+	if err := notifikasi.Enqueue(tx, id, models.NotifPenawaran); err != nil {
+		writePenawaranError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":        "Penawaran berhasil dibuat",
+		"id_penawaran":   newID,
+		"total":          total,
+		"berlaku_sampai": berlaku,
+	})
+}
+
+// =======================================================
+// CATAT KEPUTUSAN PENAWARAN (kasir / admin, via WhatsApp / telepon / langsung)
+// Teknisi tidak boleh mencatat persetujuan atas servis yang dikerjakannya sendiri.
+// =======================================================
+func PutuskanPenawaran(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractServisSubID(r.URL.Path, "penawaran/keputusan")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.KeputusanPenawaranRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+	req.Kanal = strings.ToLower(strings.TrimSpace(req.Kanal))
+	switch req.Kanal {
+	case models.KanalWhatsapp, models.KanalTelepon, models.KanalLangsung:
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Kanal harus whatsapp, telepon, atau langsung"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+	defer tx.Rollback()
+
+	idPenawaran, err := putuskanPenawaran(tx, r, id, req, currentUserID(r))
+	if err != nil {
+		writePenawaranError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":      "Keputusan penawaran berhasil dicatat",
+		"id_penawaran": idPenawaran,
+	})
+}
+
+// =======================================================
+// OVERRIDE PERSETUJUAN (admin) - barang boleh dipakai tanpa persetujuan pelanggan
+// =======================================================
+func OverridePersetujuan(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractServisSubID(r.URL.Path, "penawaran/override")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.OverrideRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+	req.Alasan = truncate(strings.TrimSpace(req.Alasan), 255)
+	if req.Alasan == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Alasan override wajib diisi"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+	defer tx.Rollback()
+
+	sebelum := snapshotServis(tx, id)
+	if sebelum == nil {
+		writePenawaranError(w, errServisTidakDitemukan)
+		return
+	}
+	_, err = tx.Exec(`
+		UPDATE servis SET penawaran_override_oleh = ?, penawaran_override_at = NOW(), penawaran_override_alasan = ?
+		WHERE id_servis = ?
+	`, currentUserID(r), req.Alasan, id)
+	if err != nil {
+		writePenawaranError(w, err)
+		return
+	}
+	if err := audit.Record(tx, r, audit.Entry{Aksi: audit.AksiUpdate, Entitas: audit.EntitasServis, IDEntitas: id, Sebelum: sebelum, Sesudah: snapshotServis(tx, id)}); err != nil {
+		writeAuditError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Override persetujuan berhasil, barang boleh dipakai",
+	})
+}
+
+// Helper: id servis & tampilan publik penawaran terakhir dari kode tracking
+func loadPenawaranPublik(kode string) (int, *models.PenawaranPublik, error) {
+	var idServis int
+	var tipeHP string
+	err := database.DB.QueryRow(`SELECT id_servis, tipe_hp FROM servis WHERE kode_tracking = ?`, kode).Scan(&idServis, &tipeHP)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	p, err := loadPenawaranTerakhir(database.DB, idServis)
+	if err != nil || p == nil {
+		return idServis, nil, err
+	}
+	return idServis, &models.PenawaranPublik{
+		KodeTracking:  kode,
+		TipeHP:        tipeHP,
+		Status:        p.Status,
+		BiayaJasa:     p.BiayaJasa,
+		Item:          p.Item,
+		Total:         p.Total,
+		Catatan:       p.Catatan,
+		BerlakuSampai: p.BerlakuSampai,
+		DiputuskanAt:  p.DiputuskanAt,
+	}, nil
+}
+
+// Helper: kode tracking dari path /api/track/{kode}/penawaran
+func extractKodePenawaran(path string) string {
+	return normalizeKodeTracking(strings.TrimSuffix(strings.TrimPrefix(path, "/api/track/"), "/penawaran"))
+}
+
+// =======================================================
+// LIHAT PENAWARAN (PUBLIC - GET /api/track/{kode}/penawaran)
+// =======================================================
+func GetPenawaranPublik(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	_, p, err := loadPenawaranPublik(extractKodePenawaran(r.URL.Path))
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Servis tidak ditemukan"})
+		return
+	} else if err != nil {
+		log.Println(" Error get penawaran publik:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal mengambil penawaran"})
+		return
+	}
+	if p == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Belum ada penawaran untuk servis ini"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(p)
+}
+
+// =======================================================
+// SETUJU / TOLAK PENAWARAN (PUBLIC - POST /api/track/{kode}/penawaran)
+// =======================================================
+func PutuskanPenawaranPublik(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req models.KeputusanPenawaranRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+	req.Kanal = models.KanalLink
+
+	var idServis int
+	err := database.DB.QueryRow(`SELECT id_servis FROM servis WHERE kode_tracking = ?`, extractKodePenawaran(r.URL.Path)).Scan(&idServis)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Servis tidak ditemukan"})
+		return
+	} else if err != nil {
+		log.Println(" Error get servis penawaran publik:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal memproses penawaran"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := putuskanPenawaran(tx, r, idServis, req, nil); err != nil {
+		writePenawaranError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
+	}
+
+	pesan := "Terima kasih, penawaran disetujui. Perbaikan akan segera dilanjutkan."
+	if !req.Setuju {
+		pesan = "Penawaran ditolak. Toko akan menghubungi Anda."
+	}
+	json.NewEncoder(w).Encode(map[string]string{"message": pesan})
+}
@@ -0,0 +1,438 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"service_hp/audit"
+	"service_hp/config"
+	"service_hp/database"
+	"service_hp/models"
+	"service_hp/routes/middleware"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Helper: data profil untuk audit (tanpa password)
+type profilAudit struct {
+	Nama   string `json:"nama"`
+	Alamat string `json:"alamat,omitempty"`
+	NoHP   string `json:"no_hp,omitempty"`
+}
+
+// Helper: ambil profil user beserta data pegawai (jika ada)
+func loadProfil(q execQueryer, idUser int) (models.Profil, error) {
+	var p models.Profil
+	var idPegawai sql.NullInt64
+	err := q.QueryRow(`
+		SELECT u.id_user, u.nama, u.username, u.role,
+			p.id_pegawai, COALESCE(p.jabatan, ''), COALESCE(p.alamat, ''), COALESCE(p.no_hp, ''),
+			COALESCE(DATE_FORMAT(p.tanggal_masuk, '%Y-%m-%d'), ''), COALESCE(p.status, '')
+		FROM user u
+		LEFT JOIN pegawai p ON p.id_user = u.id_user
+		WHERE u.id_user = ?
+	`, idUser).Scan(&p.IDUser, &p.Nama, &p.Username, &p.Role,
+		&idPegawai, &p.Jabatan, &p.Alamat, &p.NoHP, &p.TanggalMasuk, &p.Status)
+	if err != nil {
+		return p, err
+	}
+	if idPegawai.Valid {
+		v := int(idPegawai.Int64)
+		p.IDPegawai = &v
+	}
+	return p, nil
+}
+
+// =======================================================
+// PROFIL SENDIRI (GET /api/me)
+// =======================================================
+func GetProfil(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	idUser := *currentUserID(r)
+	p, err := loadProfil(database.DB, idUser)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "User tidak ditemukan"})
+		return
+	} else if err != nil {
+		log.Println(" Error select profil:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	p.Akses, _ = r.Context().Value(middleware.AksesKey).(string)
+	p.Permissions = middleware.PermissionsOf(p.Akses)
+	json.NewEncoder(w).Encode(p)
+}
+
+// =======================================================
+// UBAH PROFIL SENDIRI (PUT /api/me)
+// =======================================================
+func UpdateProfil(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req models.UpdateProfilRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	idUser := *currentUserID(r)
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+
+	lama, err := loadProfil(tx, idUser)
+	if err != nil {
+		log.Println(" Error select profil:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	baru := lama
+	if req.Nama != nil {
+		baru.Nama = strings.TrimSpace(*req.Nama)
+		if err := validateNama(baru.Nama); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	if req.Alamat != nil {
+		baru.Alamat = strings.TrimSpace(*req.Alamat)
+	}
+	if req.NoHP != nil {
+		baru.NoHP = strings.TrimSpace(*req.NoHP)
+		if len(baru.NoHP) > 20 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "No HP maksimal 20 karakter"})
+			return
+		}
+	}
+	if lama.IDPegawai == nil && (req.Alamat != nil || req.NoHP != nil) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Alamat dan no HP hanya untuk akun pegawai"})
+		return
+	}
+
+	if _, err := tx.Exec(`UPDATE user SET nama = ? WHERE id_user = ?`, baru.Nama, idUser); err != nil {
+		log.Println(" Error update user:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if lama.IDPegawai != nil {
+		_, err = tx.Exec(`
+			UPDATE pegawai SET nama_pegawai = ?, alamat = ?, no_hp = ? WHERE id_pegawai = ?
+		`, baru.Nama, baru.Alamat, baru.NoHP, *lama.IDPegawai)
+		if err != nil {
+			log.Println(" Error update pegawai:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	err = audit.Record(tx, r, audit.Entry{
+		Aksi:      "profil.ubah",
+		Entitas:   audit.EntitasUser,
+		IDEntitas: idUser,
+		Sebelum:   profilAudit{lama.Nama, lama.Alamat, lama.NoHP},
+		Sesudah:   profilAudit{baru.Nama, baru.Alamat, baru.NoHP},
+	})
+	if err != nil {
+		log.Println(" Error audit:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	baru.Akses, _ = r.Context().Value(middleware.AksesKey).(string)
+	baru.Permissions = middleware.PermissionsOf(baru.Akses)
+	json.NewEncoder(w).Encode(baru)
+}
+
+// =======================================================
+// GANTI PASSWORD SENDIRI (PUT /api/me/password)
+// Semua sesi lama dicabut, sesi ini diganti token baru
+// =======================================================
+func ChangePassword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req models.GantiPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	idUser := *currentUserID(r)
+	var user models.User
+	err := database.DB.QueryRow(`SELECT id_user, nama, username, password, role FROM user WHERE id_user = ?`, idUser).
+		Scan(&user.ID, &user.Nama, &user.Username, &user.Password, &user.Role)
+	if err != nil {
+		log.Println(" Error select user:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.PasswordLama)); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Password lama salah"})
+		return
+	}
+	if req.PasswordBaru == req.PasswordLama {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Password baru harus berbeda dari password lama"})
+		return
+	}
+	if err := validatePassword(req.PasswordBaru, user.Username); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	if err := setPassword(r, idUser, req.PasswordBaru, audit.Entry{
+		Aksi:      "password.ubah",
+		Entitas:   audit.EntitasUser,
+		IDEntitas: idUser,
+	}); err != nil {
+		log.Println(" Error ganti password:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal mengganti password"})
+		return
+	}
+
+	// Sesi yang dipakai sekarang tetap login dengan token baru
+	sesi, _, err := middleware.LoadSesi(idUser)
+	if err != nil {
+		log.Println(" Error load sesi:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+	tokens, err := issueTokens(r, user, sesi.TokenVersion)
+	if err != nil {
+		log.Println(" Error token:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":       "Password berhasil diganti, sesi di perangkat lain telah dikeluarkan",
+		"token":         tokens.Token,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+// Helper: simpan hash password baru, cabut semua sesi, dan catat audit dalam satu transaksi.
+// extra dijalankan di transaksi yang sama (mis. menandai token reset terpakai).
+func setPassword(r *http.Request, idUser int, password string, entry audit.Entry, extra ...func(*sql.Tx) error) error {
+	hashed, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE user SET password = ? WHERE id_user = ?`, hashed, idUser); err != nil {
+		return err
+	}
+	if err := revokeUserTokens(tx, idUser); err != nil {
+		return err
+	}
+	for _, fn := range extra {
+		if err := fn(tx); err != nil {
+			return err
+		}
+	}
+	if err := audit.Record(tx, r, entry); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// =======================================================
+// BUAT TOKEN RESET PASSWORD (POST /api/admin/users/{id}/reset-password)
+// Token hanya ditampilkan sekali, diserahkan admin ke pegawai secara langsung
+// =======================================================
+func CreatePasswordReset(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	idUser, err := extractUserSubID(r.URL.Path, "reset-password")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	var username string
+	err = database.DB.QueryRow(`SELECT username FROM user WHERE id_user = ?`, idUser).Scan(&username)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "User tidak ditemukan"})
+		return
+	} else if err != nil {
+		log.Println(" Error select user:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	token, err := randomToken(24)
+	if err != nil {
+		log.Println(" Error generate token reset:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+	expiresAt := time.Now().Add(config.Cfg.JWT.ResetTTL.Duration)
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+	defer tx.Rollback()
+
+	// Hanya token terbaru yang berlaku
+	_, err = tx.Exec(`
+		UPDATE password_reset SET expires_at = NOW()
+		WHERE id_user = ? AND dipakai_at IS NULL AND expires_at > NOW()
+	`, idUser)
+	if err == nil {
+		_, err = tx.Exec(`
+			INSERT INTO password_reset (id_user, token_hash, dibuat_oleh, expires_at)
+			VALUES (?, ?, ?, ?)
+		`, idUser, hashToken(token), currentUserID(r), expiresAt)
+	}
+	if err == nil {
+		err = audit.Record(tx, r, audit.Entry{
+			Aksi:      "password.reset_dibuat",
+			Entitas:   audit.EntitasUser,
+			IDEntitas: idUser,
+			Sesudah:   map[string]interface{}{"expires_at": expiresAt},
+		})
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Println(" Error simpan token reset:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal membuat token reset"})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":    "Token reset dibuat, berikan ke " + username + ". Token hanya ditampilkan sekali",
+		"token":      token,
+		"expires_at": expiresAt,
+	})
+}
+
+// =======================================================
+// RESET PASSWORD DENGAN TOKEN (POST /api/password/reset)
+// =======================================================
+func ResetPassword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req models.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	var idReset, idUser int
+	var username string
+	err := database.DB.QueryRow(`
+		SELECT pr.id_reset, pr.id_user, u.username
+		FROM password_reset pr
+		JOIN user u ON u.id_user = pr.id_user
+		WHERE pr.token_hash = ? AND pr.dipakai_at IS NULL AND pr.expires_at > NOW()
+	`, hashToken(strings.TrimSpace(req.Token))).Scan(&idReset, &idUser, &username)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Token reset tidak valid atau sudah kedaluwarsa"})
+		return
+	} else if err != nil {
+		log.Println(" Error select token reset:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	if err := validatePassword(req.PasswordBaru, username); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	errTokenTerpakai := sql.ErrNoRows
+	err = setPassword(r, idUser, req.PasswordBaru, audit.Entry{
+		Aksi:      "password.reset",
+		Entitas:   audit.EntitasUser,
+		IDEntitas: idUser,
+		IDUser:    &idUser,
+	}, func(tx *sql.Tx) error {
+		// Klaim token di transaksi yang sama agar tidak bisa dipakai dua kali
+		res, err := tx.Exec(`
+			UPDATE password_reset SET dipakai_at = NOW()
+			WHERE id_reset = ? AND dipakai_at IS NULL AND expires_at > NOW()
+		`, idReset)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return errTokenTerpakai
+		}
+		// Kunci login akibat lupa password ikut dibuka
+		_, err = tx.Exec(`
+			UPDATE login_attempt SET dihitung = 0
+			WHERE username = ? AND berhasil = 0 AND dihitung = 1
+		`, username)
+		return err
+	})
+	if err == errTokenTerpakai {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Token reset tidak valid atau sudah kedaluwarsa"})
+		return
+	} else if err != nil {
+		log.Println(" Error reset password:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal mereset password"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"message": "Password berhasil direset, silakan login"})
+}
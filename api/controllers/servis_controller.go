@@ -3,191 +3,264 @@ package controllers
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"service_hp/audit"
 	"service_hp/database"
 	"service_hp/models"
+	"service_hp/routes/middleware"
 	"strconv"
 	"strings"
 )
 
-// normalisasi status servis agar cocok enum DB
-func normalizeStatus(input string) string {
-	m := map[string]string{
-		"pending":          "pending",
-		"dalam_perbaikan":  "dalam_perbaikan",
-		"selesai":          "selesai",
-		"siap_diambil":     "siap_diambil",
-		"dalam perbaikan":  "dalam_perbaikan",
-		"siap diambil":     "siap_diambil",
-		"belum dikerjakan": "pending",
-		"pending ":         "pending",
+// Helper: Calculate total biaya (detail + servis)
+func calculateTotalBiaya(detailBiaya float64, biayadLayanan float64) float64 {
+	return detailBiaya + biayadLayanan
+}
+
+// Helper: validasi jumlah item yang memakai barang & garansi item
+func validateDetailJumlah(details []models.DetailServis) error {
+	for _, d := range details {
+		if d.IDBarang != nil && d.Jumlah <= 0 {
+			return errors.New("Jumlah barang harus lebih dari 0")
+		}
+		if d.GaransiHari != nil && *d.GaransiHari < 0 {
+			return errors.New("Garansi tidak boleh negatif")
+		}
 	}
+	return nil
+}
 
-	key := strings.ToLower(strings.TrimSpace(input))
-	if v, ok := m[key]; ok {
-		return v
+// Helper: total pemakaian stok per id_barang dari daftar detail
+func calculateStokUsage(details []models.DetailServis) map[int]int {
+	usage := map[int]int{}
+	for _, d := range details {
+		if d.IDBarang != nil {
+			usage[*d.IDBarang] += d.Jumlah
+		}
 	}
-	return "pending"
+	return usage
 }
 
-// Helper: Calculate total biaya (detail + servis)
-func calculateTotalBiaya(detailBiaya float64, biayadLayanan float64) float64 {
-	return detailBiaya + biayadLayanan
+// Helper: selisih pemakaian stok per id_barang (baru - lama). Positif berarti barang
+// tambahan dipakai, negatif berarti barang dikembalikan ke stok.
+func selisihStok(baru, lama map[int]int) map[int]int {
+	delta := map[int]int{}
+	for id, jumlah := range baru {
+		delta[id] += jumlah
+	}
+	for id, jumlah := range lama {
+		delta[id] -= jumlah
+	}
+	return delta
 }
 
-// =======================================================
-// SEARCH SERVIS (PUBLIC - untuk Landing Page)
-// =======================================================
-func SearchServis(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+// Helper: pemakaian stok yang sudah tercatat untuk satu servis
+func loadStokUsage(tx *sql.Tx, idServis int) (map[int]int, error) {
+	rows, err := tx.Query(`
+		SELECT id_barang, SUM(jumlah)
+		FROM detail_servis
+		WHERE id_servis = ? AND id_barang IS NOT NULL
+		GROUP BY id_barang
+	`, idServis)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	// Get query parameters
-	name := r.URL.Query().Get("name")
-	phone := r.URL.Query().Get("phone")
+	usage := map[int]int{}
+	for rows.Next() {
+		var idBarang, jumlah int
+		if err := rows.Scan(&idBarang, &jumlah); err != nil {
+			return nil, err
+		}
+		usage[idBarang] = jumlah
+	}
+	return usage, rows.Err()
+}
 
-	// Validate - at least one parameter must be provided
-	if name == "" && phone == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Nama atau nomor WhatsApp harus diisi",
-		})
-		return
+// Helper: snapshot servis beserta item detailnya untuk audit log
+func snapshotServis(tx *sql.Tx, idServis int) map[string]interface{} {
+	data, err := audit.Snapshot(tx, audit.EntitasServis, idServis)
+	if err != nil || data == nil {
+		if err != nil {
+			log.Println(" Error snapshot servis:", err)
+		}
+		return data
 	}
 
-	// Build dynamic query
-	query := `
-		SELECT 
-			s.id_servis,
-			s.nama_pelanggan,
-			s.no_whatsapp,
-			s.tipe_hp,
-			s.keluhan,
-			s.status_servis,
-			s.biaya_servis,
-			s.biaya_total,
-			s.tanggal_masuk,
-			s.tanggal_selesai
-		FROM servis s
-		WHERE 1=1
-	`
-	
-	var args []interface{}
+	rows, err := tx.Query(`
+		SELECT id_detail, id_barang, COALESCE(deskripsi, ''), jumlah, harga_satuan, biaya, garansi_hari
+		FROM detail_servis WHERE id_servis = ? ORDER BY id_detail
+	`, idServis)
+	if err != nil {
+		log.Println(" Error snapshot detail servis:", err)
+		return data
+	}
+	defer rows.Close()
 
-	if name != "" {
-		query += " AND LOWER(s.nama_pelanggan) LIKE ?"
-		args = append(args, "%"+strings.ToLower(name)+"%")
+	details := []models.DetailServis{}
+	for rows.Next() {
+		var d models.DetailServis
+		var idBarang sql.NullInt64
+		var garansi int
+		if err := rows.Scan(&d.IDDetail, &idBarang, &d.Deskripsi, &d.Jumlah, &d.HargaSatuan, &d.Biaya, &garansi); err != nil {
+			log.Println(" Error scan detail servis:", err)
+			continue
+		}
+		d.IDServis = idServis
+		d.IDBarang = nullIntPtr(idBarang)
+		d.GaransiHari = &garansi
+		details = append(details, d)
 	}
+	data["detail"] = details
 
-	if phone != "" {
-		query += " AND s.no_whatsapp LIKE ?"
-		args = append(args, "%"+phone+"%")
+	intake, err := audit.Snapshot(tx, audit.EntitasServisIntake, idServis)
+	if err != nil {
+		log.Println(" Error snapshot intake servis:", err)
 	}
+	if intake != nil {
+		data["intake"] = intake
+	}
+	return data
+}
 
-	query += " ORDER BY s.tanggal_masuk DESC"
+// snapshotModal - jumlah & total modal yang sudah tercatat untuk satu barang
+type snapshotModal struct {
+	Jumlah int
+	Total  float64
+}
 
-	rows, err := database.DB.Query(query, args...)
+// Helper: snapshot modal per barang yang sudah tercatat untuk satu servis
+func loadSnapshotModal(tx *sql.Tx, idServis int) (map[int]snapshotModal, error) {
+	rows, err := tx.Query(`
+		SELECT id_barang, SUM(jumlah), SUM(jumlah * harga_modal)
+		FROM detail_servis
+		WHERE id_servis = ? AND id_barang IS NOT NULL
+		GROUP BY id_barang
+	`, idServis)
 	if err != nil {
-		log.Println(" Error search servis:", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Gagal mencari data servis",
-		})
-		return
+		return nil, err
 	}
 	defer rows.Close()
 
-	var services []models.Servis
-
+	snapshot := map[int]snapshotModal{}
 	for rows.Next() {
-		var s models.Servis
-		var tanggalSelesai sql.NullString
+		var idBarang int
+		var s snapshotModal
+		if err := rows.Scan(&idBarang, &s.Jumlah, &s.Total); err != nil {
+			return nil, err
+		}
+		snapshot[idBarang] = s
+	}
+	return snapshot, rows.Err()
+}
 
-		err := rows.Scan(
-			&s.IDServis,
-			&s.NamaPelanggan,
-			&s.NoWhatsapp,
-			&s.TipeHP,
-			&s.Keluhan,
-			&s.StatusServis,
-			&s.BiayaServis,
-			&s.BiayaTotal,
-			&s.TanggalMasuk,
-			&tanggalSelesai,
-		)
+// Helper: isi harga_modal snapshot tiap detail.
+// Jumlah yang sudah tercatat sebelumnya mempertahankan modal lamanya,
+// hanya tambahan jumlah yang memakai barang.harga_modal saat ini.
+func assignHargaModal(tx *sql.Tx, details []models.DetailServis, lama map[int]snapshotModal) error {
+	sisa := map[int]snapshotModal{}
+	for id, s := range lama {
+		sisa[id] = s
+	}
+	current := map[int]float64{}
 
-		if err != nil {
-			log.Println(" Error scan servis:", err)
+	for i := range details {
+		d := &details[i]
+		if d.IDBarang == nil {
+			d.HargaModal = 0
 			continue
 		}
+		idBarang := *d.IDBarang
 
-		if tanggalSelesai.Valid {
-			s.TanggalSelesai = &tanggalSelesai.String
-		} else {
-			s.TanggalSelesai = nil
-		}
-
-		// Get detail servis for each service
-		detailQuery := `
-			SELECT id_detail, id_servis, id_barang, deskripsi, jumlah, harga_satuan, biaya
-			FROM detail_servis
-			WHERE id_servis = ?
-		`
-		
-		detailRows, err := database.DB.Query(detailQuery, s.IDServis)
-		if err == nil {
-			var details []models.DetailServis
-			
-			for detailRows.Next() {
-				var d models.DetailServis
-				var idBarang sql.NullInt64
-
-				err := detailRows.Scan(
-					&d.IDDetail,
-					&d.IDServis,
-					&idBarang,
-					&d.Deskripsi,
-					&d.Jumlah,
-					&d.HargaSatuan,
-					&d.Biaya,
-				)
-
-				if err == nil {
-					if idBarang.Valid {
-						tempID := int(idBarang.Int64)
-						d.IDBarang = &tempID
-					} else {
-						d.IDBarang = nil
-					}
-					details = append(details, d)
-				}
+		modal, ok := current[idBarang]
+		if !ok {
+			err := tx.QueryRow(`SELECT COALESCE(harga_modal, 0) FROM barang WHERE id_barang = ?`, idBarang).Scan(&modal)
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("%w (id %d)", errBarangTidakDitemukan, idBarang)
 			}
-			detailRows.Close()
-			s.Detail = details
+			if err != nil {
+				return err
+			}
+			current[idBarang] = modal
 		}
 
-		services = append(services, s)
-	}
+		var total float64
+		s := sisa[idBarang]
+		pakaiLama := s.Jumlah
+		if pakaiLama > d.Jumlah {
+			pakaiLama = d.Jumlah
+		}
+		if pakaiLama > 0 {
+			avg := s.Total / float64(s.Jumlah)
+			total += avg * float64(pakaiLama)
+			s.Jumlah -= pakaiLama
+			s.Total -= avg * float64(pakaiLama)
+			sisa[idBarang] = s
+		}
+		total += modal * float64(d.Jumlah-pakaiLama)
 
-	// Return empty array if no results
-	if len(services) == 0 {
-		json.NewEncoder(w).Encode([]models.Servis{})
-		return
+		d.HargaModal = math.Round(total/float64(d.Jumlah)*100) / 100
 	}
-
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(services)
+	return nil
 }
 
 // =======================================================
-// GET ALL SERVIS (Protected - untuk Pegawai)
+// SEARCH SERVIS (PUBLIC - untuk Landing Page)
+// Wajib nomor WhatsApp + kode tracking yang cocok persis
 // =======================================================
-func GetAllServis(w http.ResponseWriter, r *http.Request) {
+func SearchServis(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	rows, err := database.DB.Query(`
+	phone := r.URL.Query().Get("phone")
+	kode := normalizeKodeTracking(r.URL.Query().Get("kode"))
+
+	if phone == "" || kode == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Nomor WhatsApp dan kode tracking harus diisi",
+		})
+		return
+	}
+
+	noWhatsapp, err := normalizePhone(phone)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	t, _, err := loadTracking(kode, noWhatsapp)
+	if err == sql.ErrNoRows {
+		// Response sama untuk kode salah maupun nomor salah
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Servis tidak ditemukan"})
+		return
+	} else if err != nil {
+		log.Println(" Error search servis:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Gagal mencari data servis",
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(t)
+}
+
+// servisListQuery - kolom daftar servis (tanpa detail), lanjutkan dengan WHERE/ORDER BY
+const servisListQuery = `
 		SELECT 
 			s.id_servis,
+			s.id_pelanggan,
+			s.id_teknisi,
+			COALESCE(p.nama_pegawai, ''),
+			s.id_servis_asal,
+			s.kode_tracking,
 			s.nama_pelanggan,
 			s.no_whatsapp,
 			s.tipe_hp,
@@ -195,27 +268,29 @@ func GetAllServis(w http.ResponseWriter, r *http.Request) {
 			s.status_servis,
 			s.biaya_servis,
 			s.biaya_total,
+			`+totalDibayarSQL+`,
 			s.tanggal_masuk,
 			s.tanggal_selesai
 		FROM servis s
-		ORDER BY s.id_servis DESC
-	`)
-	if err != nil {
-		log.Println(" Error query servis:", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
-		return
-	}
-	defer rows.Close()
+		LEFT JOIN pegawai p ON s.id_teknisi = p.id_pegawai
+`
 
+// Helper: scan hasil servisListQuery
+func scanServisList(rows *sql.Rows) []models.Servis {
 	var list []models.Servis
 
 	for rows.Next() {
 		var s models.Servis
 		var tglSelesai sql.NullString
+		var idPelanggan, idTeknisi, idAsal sql.NullInt64
 
 		err := rows.Scan(
 			&s.IDServis,
+			&idPelanggan,
+			&idTeknisi,
+			&s.NamaTeknisi,
+			&idAsal,
+			&s.KodeTracking,
 			&s.NamaPelanggan,
 			&s.NoWhatsapp,
 			&s.TipeHP,
@@ -223,12 +298,17 @@ func GetAllServis(w http.ResponseWriter, r *http.Request) {
 			&s.StatusServis,
 			&s.BiayaServis,
 			&s.BiayaTotal,
+			&s.TotalDibayar,
 			&s.TanggalMasuk,
 			&tglSelesai,
 		)
 		if err != nil {
 			continue
 		}
+		s.IDPelanggan = nullIntPtr(idPelanggan)
+		s.IDTeknisi = nullIntPtr(idTeknisi)
+		s.IDServisAsal = nullIntPtr(idAsal)
+		s.SisaTagihan = roundRupiah(s.BiayaTotal - s.TotalDibayar)
 
 		if tglSelesai.Valid {
 			s.TanggalSelesai = &tglSelesai.String
@@ -238,8 +318,55 @@ func GetAllServis(w http.ResponseWriter, r *http.Request) {
 
 		list = append(list, s)
 	}
+	return list
+}
+
+// =======================================================
+// GET ALL SERVIS (Protected - untuk Pegawai)
+// ?id_teknisi= untuk servis satu teknisi
+// ?imei= / ?serial= untuk semua riwayat servis satu perangkat
+// =======================================================
+func GetAllServis(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var kondisi []string
+	var args []interface{}
+	if v := r.URL.Query().Get("id_teknisi"); v != "" {
+		idTeknisi, err := strconv.Atoi(v)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "id_teknisi tidak valid"})
+			return
+		}
+		kondisi = append(kondisi, "s.id_teknisi = ?")
+		args = append(args, idTeknisi)
+	}
+	if v := r.URL.Query().Get("imei"); v != "" {
+		kondisi = append(kondisi, "s.id_servis IN (SELECT id_servis FROM servis_intake WHERE imei = ?)")
+		args = append(args, normalizeIMEI(v))
+	}
+	if v := strings.TrimSpace(r.URL.Query().Get("serial")); v != "" {
+		kondisi = append(kondisi, "s.id_servis IN (SELECT id_servis FROM servis_intake WHERE serial_number = ?)")
+		args = append(args, strings.ToUpper(v))
+	}
+
+	where := ""
+	if len(kondisi) > 0 {
+		where = "WHERE " + strings.Join(kondisi, " AND ")
+	}
+
+	rows, err := database.DB.Query(servisListQuery+where+`
+		ORDER BY s.id_servis DESC
+	`, args...)
+	if err != nil {
+		log.Println(" Error query servis:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
 
-	json.NewEncoder(w).Encode(list)
+	json.NewEncoder(w).Encode(scanServisList(rows))
 }
 
 // =======================================================
@@ -255,8 +382,42 @@ func CreateServis(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Normalisasi status
-	req.StatusServis = normalizeStatus(req.StatusServis)
+	// Servis baru selalu mulai dari pending, tanggal_selesai diisi oleh transisi status
+	if strings.TrimSpace(req.StatusServis) != "" {
+		if status, ok := normalizeStatus(req.StatusServis); !ok || status != models.StatusPending {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Servis baru harus berstatus pending"})
+			return
+		}
+	}
+	req.StatusServis = models.StatusPending
+
+	if err := validateDetailJumlah(req.Detail); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if req.Intake != nil {
+		if err := validateIntake(req.Intake); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	// DP saat servis diterima, hanya untuk yang boleh menerima pembayaran
+	if req.DP != nil {
+		if !middleware.HasPermission(r.Context(), middleware.PermPembayaran) {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Anda tidak punya izin menerima pembayaran"})
+			return
+		}
+		req.DP.Jenis = models.PembayaranDP
+		if err := validatePembayaran(req.DP); err != nil {
+			writePembayaranError(w, err)
+			return
+		}
+	}
 
 	// Start transaction
 	tx, err := database.DB.Begin()
@@ -267,12 +428,40 @@ func CreateServis(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Pelanggan lama (id_pelanggan) atau pelanggan baru dari data inline
+	if err := resolvePelanggan(tx, &req); err != nil {
+		tx.Rollback()
+		writePelangganError(w, err)
+		return
+	}
+
+	// Teknisi boleh langsung ditugaskan saat servis diterima
+	if req.IDTeknisi != nil {
+		if err := validateTeknisi(tx, *req.IDTeknisi); err != nil {
+			tx.Rollback()
+			writeTeknisiError(w, err)
+			return
+		}
+	}
+
+	req.KodeTracking, err = generateKodeTracking()
+	if err != nil {
+		tx.Rollback()
+		log.Println(" Error generate kode tracking:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
 	var res sql.Result
 	if strings.TrimSpace(req.TanggalMasuk) == "" {
 		res, err = tx.Exec(`
-			INSERT INTO servis (nama_pelanggan, no_whatsapp, tipe_hp, keluhan, status_servis, biaya_servis, biaya_total, tanggal_masuk, tanggal_selesai)
-			VALUES (?, ?, ?, ?, ?, ?, ?, NOW(), ?)
+			INSERT INTO servis (id_pelanggan, id_teknisi, kode_tracking, nama_pelanggan, no_whatsapp, tipe_hp, keluhan, status_servis, biaya_servis, biaya_total, tanggal_masuk)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW())
 		`,
+			req.IDPelanggan,
+			req.IDTeknisi,
+			req.KodeTracking,
 			req.NamaPelanggan,
 			req.NoWhatsapp,
 			req.TipeHP,
@@ -280,13 +469,15 @@ func CreateServis(w http.ResponseWriter, r *http.Request) {
 			req.StatusServis,
 			req.BiayaServis,
 			0,
-			req.TanggalSelesai,
 		)
 	} else {
 		res, err = tx.Exec(`
-			INSERT INTO servis (nama_pelanggan, no_whatsapp, tipe_hp, keluhan, status_servis, biaya_servis, biaya_total, tanggal_masuk, tanggal_selesai)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			INSERT INTO servis (id_pelanggan, id_teknisi, kode_tracking, nama_pelanggan, no_whatsapp, tipe_hp, keluhan, status_servis, biaya_servis, biaya_total, tanggal_masuk)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		`,
+			req.IDPelanggan,
+			req.IDTeknisi,
+			req.KodeTracking,
 			req.NamaPelanggan,
 			req.NoWhatsapp,
 			req.TipeHP,
@@ -295,7 +486,6 @@ func CreateServis(w http.ResponseWriter, r *http.Request) {
 			req.BiayaServis,
 			0,
 			req.TanggalMasuk,
-			req.TanggalSelesai,
 		)
 	}
 	if err != nil {
@@ -309,12 +499,53 @@ func CreateServis(w http.ResponseWriter, r *http.Request) {
 	newID64, _ := res.LastInsertId()
 	newID := int(newID64)
 
+	if err := recordStatusHistory(tx, newID, nil, req.StatusServis, currentUserID(r), "Servis diterima"); err != nil {
+		tx.Rollback()
+		log.Println(" Error insert riwayat status:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	if req.IDTeknisi != nil {
+		if err := recordTeknisiHistory(tx, newID, nil, req.IDTeknisi, currentUserID(r), "Teknisi awal"); err != nil {
+			tx.Rollback()
+			log.Println(" Error insert riwayat teknisi:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+			return
+		}
+	}
+
+	// Checklist penerimaan perangkat
+	if req.Intake != nil {
+		if err := saveIntake(tx, newID, req.Intake); err != nil {
+			tx.Rollback()
+			log.Println(" Error simpan intake servis:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+			return
+		}
+	}
+
+	// Bekukan harga modal barang saat ini ke tiap detail
+	if err := assignHargaModal(tx, req.Detail, nil); err != nil {
+		tx.Rollback()
+		writeStokError(w, err)
+		return
+	}
+	if err := assignGaransi(tx, req.Detail); err != nil {
+		tx.Rollback()
+		writeStokError(w, err)
+		return
+	}
+
 	var totalDetailBiaya float64 = 0
 	for _, d := range req.Detail {
-		_, err := tx.Exec(`
-			INSERT INTO detail_servis (id_servis, id_barang, deskripsi, jumlah, harga_satuan, biaya)
-			VALUES (?, ?, ?, ?, ?, ?)
-		`, newID, d.IDBarang, d.Deskripsi, d.Jumlah, d.HargaSatuan, d.Biaya)
+		resDetail, err := tx.Exec(`
+			INSERT INTO detail_servis (id_servis, id_barang, deskripsi, jumlah, harga_satuan, biaya, harga_modal, garansi_hari)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, newID, d.IDBarang, d.Deskripsi, d.Jumlah, d.HargaSatuan, d.Biaya, d.HargaModal, d.GaransiHari)
 		if err != nil {
 			tx.Rollback()
 			log.Println(" Error insert detail:", err)
@@ -323,6 +554,16 @@ func CreateServis(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		totalDetailBiaya += d.Biaya
+
+		// Kurangi stok barang yang dipakai
+		detailID64, _ := resDetail.LastInsertId()
+		detailID := int(detailID64)
+		ref := stokRef{IDServis: &newID, IDDetail: &detailID, IDUser: currentUserID(r)}
+		if err := applyStokDelta(tx, calculateStokUsage([]models.DetailServis{d}), ref); err != nil {
+			tx.Rollback()
+			writeStokError(w, err)
+			return
+		}
 	}
 
 	// Calculate total: detail biaya + biaya_servis
@@ -337,6 +578,27 @@ func CreateServis(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := audit.Record(tx, r, audit.Entry{Aksi: audit.AksiCreate, Entitas: audit.EntitasServis, IDEntitas: newID, Sesudah: snapshotServis(tx, newID)}); err != nil {
+		tx.Rollback()
+		writeAuditError(w, err)
+		return
+	}
+
+	var idDP int
+	if req.DP != nil {
+		idDP, _, err = recordPembayaran(tx, newID, *req.DP, currentUserID(r))
+		if err != nil {
+			tx.Rollback()
+			writePembayaranError(w, err)
+			return
+		}
+		if err := audit.CatatTx(tx, r, audit.AksiCreate, audit.EntitasPembayaran, idDP, nil); err != nil {
+			tx.Rollback()
+			writeAuditError(w, err)
+			return
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		log.Println(" Error commit tx:", err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -345,8 +607,10 @@ func CreateServis(w http.ResponseWriter, r *http.Request) {
 	}
 
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message":   "Servis berhasil ditambahkan",
-		"id_servis": newID,
+		"message":       "Servis berhasil ditambahkan",
+		"id_servis":     newID,
+		"kode_tracking": req.KodeTracking,
+		"id_pembayaran": idDP,
 	})
 }
 
@@ -366,14 +630,23 @@ func GetServisDetail(w http.ResponseWriter, r *http.Request) {
 
 	var s models.Servis
 	var tglSelesai sql.NullString
+	var idPelanggan, idTeknisi, idAsal sql.NullInt64
 
 	err = database.DB.QueryRow(`
 		SELECT 
-			id_servis, nama_pelanggan, no_whatsapp, tipe_hp, keluhan,
-			status_servis, biaya_servis, biaya_total, tanggal_masuk, tanggal_selesai
-		FROM servis WHERE id_servis = ?
+			s.id_servis, s.id_pelanggan, s.id_teknisi, COALESCE(p.nama_pegawai, ''), s.id_servis_asal, s.kode_tracking,
+			s.nama_pelanggan, s.no_whatsapp, s.tipe_hp, s.keluhan,
+			s.status_servis, s.biaya_servis, s.biaya_total, `+totalDibayarSQL+`, s.tanggal_masuk, s.tanggal_selesai
+		FROM servis s
+		LEFT JOIN pegawai p ON s.id_teknisi = p.id_pegawai
+		WHERE s.id_servis = ?
 	`, id).Scan(
 		&s.IDServis,
+		&idPelanggan,
+		&idTeknisi,
+		&s.NamaTeknisi,
+		&idAsal,
+		&s.KodeTracking,
 		&s.NamaPelanggan,
 		&s.NoWhatsapp,
 		&s.TipeHP,
@@ -381,6 +654,7 @@ func GetServisDetail(w http.ResponseWriter, r *http.Request) {
 		&s.StatusServis,
 		&s.BiayaServis,
 		&s.BiayaTotal,
+		&s.TotalDibayar,
 		&s.TanggalMasuk,
 		&tglSelesai,
 	)
@@ -391,13 +665,20 @@ func GetServisDetail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.IDPelanggan = nullIntPtr(idPelanggan)
+	s.IDTeknisi = nullIntPtr(idTeknisi)
+	s.IDServisAsal = nullIntPtr(idAsal)
+	s.SisaTagihan = roundRupiah(s.BiayaTotal - s.TotalDibayar)
 	if tglSelesai.Valid {
 		s.TanggalSelesai = &tglSelesai.String
 	}
 
 	rows, err := database.DB.Query(`
-		SELECT id_detail, id_servis, id_barang, deskripsi, jumlah, harga_satuan, biaya
-		FROM detail_servis WHERE id_servis = ?
+		SELECT ds.id_detail, ds.id_servis, ds.id_barang, ds.deskripsi, ds.jumlah, ds.harga_satuan, ds.biaya, ds.harga_modal,
+			ds.garansi_hari, `+garansiSampaiSQL+`
+		FROM detail_servis ds
+		INNER JOIN servis s ON s.id_servis = ds.id_servis
+		WHERE ds.id_servis = ?
 	`, id)
 	if err != nil {
 		log.Println(" Error get detail servis:", err)
@@ -408,6 +689,8 @@ func GetServisDetail(w http.ResponseWriter, r *http.Request) {
 		for rows.Next() {
 			var d models.DetailServis
 			var idBarang sql.NullInt64
+			var garansi int
+			var garansiSampai sql.NullTime
 
 			err := rows.Scan(
 				&d.IDDetail,
@@ -417,9 +700,14 @@ func GetServisDetail(w http.ResponseWriter, r *http.Request) {
 				&d.Jumlah,
 				&d.HargaSatuan,
 				&d.Biaya,
+				&d.HargaModal,
+				&garansi,
+				&garansiSampai,
 			)
 
 			if err == nil {
+				d.GaransiHari = &garansi
+				d.GaransiSampai = formatGaransiSampai(garansiSampai)
 				if idBarang.Valid {
 					tempID := int(idBarang.Int64)
 					d.IDBarang = &tempID
@@ -433,6 +721,15 @@ func GetServisDetail(w http.ResponseWriter, r *http.Request) {
 	}
 	s.Detail = det
 
+	s.Intake, err = loadIntake(database.DB, id, bolehLihatKodeKunci(r))
+	if err != nil {
+		log.Println(" Error get intake servis:", err)
+	}
+	s.Penawaran, err = loadPenawaranTerakhir(database.DB, id)
+	if err != nil {
+		log.Println(" Error get penawaran servis:", err)
+	}
+
 	json.NewEncoder(w).Encode(s)
 }
 
@@ -452,7 +749,29 @@ func UpdateServis(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	req.StatusServis = normalizeStatus(req.StatusServis)
+	// Status kosong berarti tidak diubah, selain itu harus lewat state machine
+	if strings.TrimSpace(req.StatusServis) != "" {
+		status, ok := normalizeStatus(req.StatusServis)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Status tidak dikenal: " + req.StatusServis})
+			return
+		}
+		req.StatusServis = status
+	}
+
+	if err := validateDetailJumlah(req.Detail); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if req.Intake != nil {
+		if err := validateIntake(req.Intake); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+	}
 
 	tx, err := database.DB.Begin()
 	if err != nil {
@@ -462,20 +781,53 @@ func UpdateServis(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Kunci servis agar sinkronisasi detail & stok tidak balapan
+	var statusLama string
+	err = tx.QueryRow(`SELECT status_servis FROM servis WHERE id_servis=? FOR UPDATE`, id).Scan(&statusLama)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Servis tidak ditemukan"})
+		return
+	} else if err != nil {
+		tx.Rollback()
+		log.Println(" Error lock servis:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	sebelum := snapshotServis(tx, id)
+
+	// Pemakaian stok sebelum detail disinkronkan
+	oldUsage, err := loadStokUsage(tx, id)
+	if err != nil {
+		tx.Rollback()
+		log.Println(" Error load pemakaian stok:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	if err := resolvePelanggan(tx, &req); err != nil {
+		tx.Rollback()
+		writePelangganError(w, err)
+		return
+	}
+
 	_, err = tx.Exec(`
 		UPDATE servis SET
-			nama_pelanggan=?, no_whatsapp=?, tipe_hp=?, keluhan=?, 
-			status_servis=?, biaya_servis=?, tanggal_masuk=?, tanggal_selesai=?
+			id_pelanggan=?, nama_pelanggan=?, no_whatsapp=?, tipe_hp=?, keluhan=?, 
+			biaya_servis=?, tanggal_masuk=?
 		WHERE id_servis=?
 	`,
+		req.IDPelanggan,
 		req.NamaPelanggan,
 		req.NoWhatsapp,
 		req.TipeHP,
 		req.Keluhan,
-		req.StatusServis,
 		req.BiayaServis,
 		req.TanggalMasuk,
-		req.TanggalSelesai,
 		id,
 	)
 	if err != nil {
@@ -486,6 +838,37 @@ func UpdateServis(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Checklist penerimaan hanya diganti jika dikirim
+	if req.Intake != nil {
+		if err := saveIntake(tx, id, req.Intake); err != nil {
+			tx.Rollback()
+			log.Println(" Error simpan intake servis:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+			return
+		}
+	}
+
+	// Modal yang sudah tercatat dipertahankan untuk jumlah yang tidak berubah
+	oldModal, err := loadSnapshotModal(tx, id)
+	if err != nil {
+		tx.Rollback()
+		log.Println(" Error load snapshot modal:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+	if err := assignHargaModal(tx, req.Detail, oldModal); err != nil {
+		tx.Rollback()
+		writeStokError(w, err)
+		return
+	}
+	if err := assignGaransi(tx, req.Detail); err != nil {
+		tx.Rollback()
+		writeStokError(w, err)
+		return
+	}
+
 	// Sync detail: delete old, insert new
 	if _, err := tx.Exec(`DELETE FROM detail_servis WHERE id_servis=?`, id); err != nil {
 		tx.Rollback()
@@ -498,9 +881,9 @@ func UpdateServis(w http.ResponseWriter, r *http.Request) {
 	var totalDetailBiaya float64 = 0
 	for _, d := range req.Detail {
 		_, err := tx.Exec(`
-			INSERT INTO detail_servis (id_servis, id_barang, deskripsi, jumlah, harga_satuan, biaya)
-			VALUES (?, ?, ?, ?, ?, ?)
-		`, id, d.IDBarang, d.Deskripsi, d.Jumlah, d.HargaSatuan, d.Biaya)
+			INSERT INTO detail_servis (id_servis, id_barang, deskripsi, jumlah, harga_satuan, biaya, harga_modal, garansi_hari)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, id, d.IDBarang, d.Deskripsi, d.Jumlah, d.HargaSatuan, d.Biaya, d.HargaModal, d.GaransiHari)
 		if err != nil {
 			tx.Rollback()
 			log.Println(" Error insert detail (update):", err)
@@ -511,6 +894,15 @@ func UpdateServis(w http.ResponseWriter, r *http.Request) {
 		totalDetailBiaya += d.Biaya
 	}
 
+	// Hanya selisih bersih yang mengubah stok, bukan hapus + insert ulang
+	delta := selisihStok(calculateStokUsage(req.Detail), oldUsage)
+	ref := stokRef{IDServis: &id, IDUser: currentUserID(r), Keterangan: "Sinkronisasi detail servis"}
+	if err := applyStokDelta(tx, delta, ref); err != nil {
+		tx.Rollback()
+		writeStokError(w, err)
+		return
+	}
+
 	// Calculate total: detail biaya + biaya_servis
 	totalBiaya := calculateTotalBiaya(totalDetailBiaya, req.BiayaServis)
 
@@ -523,6 +915,25 @@ func UpdateServis(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Status diubah setelah biaya_total baru tersimpan agar cek lunas memakai tagihan terbaru
+	if req.StatusServis != "" && req.StatusServis != statusLama {
+		if !checkStatusPermission(w, r, req.StatusServis) {
+			tx.Rollback()
+			return
+		}
+		if _, err := changeServisStatus(tx, id, req.StatusServis, currentUserID(r), ""); err != nil {
+			tx.Rollback()
+			writeStatusError(w, err)
+			return
+		}
+	}
+
+	if err := audit.Record(tx, r, audit.Entry{Aksi: audit.AksiUpdate, Entitas: audit.EntitasServis, IDEntitas: id, Sebelum: sebelum, Sesudah: snapshotServis(tx, id)}); err != nil {
+		tx.Rollback()
+		writeAuditError(w, err)
+		return
+	}
+
 	if err := tx.Commit(); err != nil {
 		log.Println(" Error commit tx:", err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -552,6 +963,58 @@ func DeleteServis(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Servis yang sudah ada pembayaran / nota / klaim garansi / lampiran dibatalkan + refund, bukan dihapus
+	var jumlahPembayaran int
+	if err := tx.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM pembayaran WHERE id_servis = ?) + (SELECT COUNT(*) FROM nota WHERE id_servis = ?)
+			+ (SELECT COUNT(*) FROM servis WHERE id_servis_asal = ?) + (SELECT COUNT(*) FROM lampiran WHERE id_servis = ?)
+	`, id, id, id, id).Scan(&jumlahPembayaran); err != nil {
+		tx.Rollback()
+		log.Println(" Error cek pembayaran:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+	if jumlahPembayaran > 0 {
+		tx.Rollback()
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Servis sudah punya pembayaran, nota, klaim garansi, atau lampiran, batalkan servis dan catat refund"})
+		return
+	}
+
+	sebelum := snapshotServis(tx, id)
+
+	// Kembalikan stok barang yang dipakai servis ini, kecuali servis batal (sudah dikembalikan)
+	var status string
+	if err := tx.QueryRow(`SELECT status_servis FROM servis WHERE id_servis = ?`, id).Scan(&status); err != nil && err != sql.ErrNoRows {
+		tx.Rollback()
+		log.Println(" Error cek status servis:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+	usage := map[int]int{}
+	if status != models.StatusBatal {
+		usage, err = loadStokUsage(tx, id)
+	}
+	if err != nil {
+		tx.Rollback()
+		log.Println(" Error load pemakaian stok:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+	restore := map[int]int{}
+	for idBarang, jumlah := range usage {
+		restore[idBarang] = -jumlah
+	}
+	ref := stokRef{IDServis: &id, IDUser: currentUserID(r), Keterangan: "Servis dihapus"}
+	if err := applyStokDelta(tx, restore, ref); err != nil {
+		tx.Rollback()
+		writeStokError(w, err)
+		return
+	}
+
 	if _, err := tx.Exec(`DELETE FROM detail_servis WHERE id_servis=?`, id); err != nil {
 		tx.Rollback()
 		log.Println(" Error delete detail_servis:", err)
@@ -568,6 +1031,14 @@ func DeleteServis(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if sebelum != nil {
+		if err := audit.Record(tx, r, audit.Entry{Aksi: audit.AksiDelete, Entitas: audit.EntitasServis, IDEntitas: id, Sebelum: sebelum}); err != nil {
+			tx.Rollback()
+			writeAuditError(w, err)
+			return
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		log.Println(" Error commit tx:", err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -593,11 +1064,40 @@ func AddDetailServis(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := database.DB.Exec(`
-		INSERT INTO detail_servis (id_servis, id_barang, deskripsi, jumlah, harga_satuan, biaya)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, d.IDServis, d.IDBarang, d.Deskripsi, d.Jumlah, d.HargaSatuan, d.Biaya)
+	if err := validateDetailJumlah([]models.DetailServis{d}); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	// Bekukan harga modal barang saat ini
+	details := []models.DetailServis{d}
+	if err := assignHargaModal(tx, details, nil); err != nil {
+		tx.Rollback()
+		writeStokError(w, err)
+		return
+	}
+	if err := assignGaransi(tx, details); err != nil {
+		tx.Rollback()
+		writeStokError(w, err)
+		return
+	}
+	d = details[0]
+
+	result, err := tx.Exec(`
+		INSERT INTO detail_servis (id_servis, id_barang, deskripsi, jumlah, harga_satuan, biaya, harga_modal, garansi_hari)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, d.IDServis, d.IDBarang, d.Deskripsi, d.Jumlah, d.HargaSatuan, d.Biaya, d.HargaModal, d.GaransiHari)
 	if err != nil {
+		tx.Rollback()
 		log.Println(" Error insert detail:", err)
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
@@ -606,9 +1106,35 @@ func AddDetailServis(w http.ResponseWriter, r *http.Request) {
 
 	newID, _ := result.LastInsertId()
 
+	// Kurangi stok barang yang dipakai
+	detailID := int(newID)
+	ref := stokRef{IDServis: &d.IDServis, IDDetail: &detailID, IDUser: currentUserID(r)}
+	if err := applyStokDelta(tx, calculateStokUsage([]models.DetailServis{d}), ref); err != nil {
+		tx.Rollback()
+		writeStokError(w, err)
+		return
+	}
+
 	// Update biaya_total: add detail biaya only (biaya_servis sudah ada)
-	if _, err := database.DB.Exec(`UPDATE servis SET biaya_total = COALESCE(biaya_total,0) + ? WHERE id_servis = ?`, d.Biaya, d.IDServis); err != nil {
+	if _, err := tx.Exec(`UPDATE servis SET biaya_total = COALESCE(biaya_total,0) + ? WHERE id_servis = ?`, d.Biaya, d.IDServis); err != nil {
+		tx.Rollback()
 		log.Println(" Error update biaya_total after add detail:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to update total biaya"})
+		return
+	}
+
+	if err := audit.CatatTx(tx, r, audit.AksiCreate, audit.EntitasDetailServis, detailID, nil); err != nil {
+		tx.Rollback()
+		writeAuditError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
 	}
 
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -630,32 +1156,103 @@ func UpdateDetailServis(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var oldBiaya float64
-	err := database.DB.QueryRow(`SELECT biaya FROM detail_servis WHERE id_detail = ?`, id).Scan(&oldBiaya)
-	if err != nil && err != sql.ErrNoRows {
-		log.Println(" Error select old biaya:", err)
+	if err := validateDetailJumlah([]models.DetailServis{d}); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	var old models.DetailServis
+	var oldBarang sql.NullInt64
+	err = tx.QueryRow(`
+		SELECT id_servis, id_barang, jumlah, biaya, harga_modal FROM detail_servis WHERE id_detail = ? FOR UPDATE
+	`, id).Scan(&old.IDServis, &oldBarang, &old.Jumlah, &old.Biaya, &old.HargaModal)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Detail tidak ditemukan"})
+		return
+	} else if err != nil {
+		tx.Rollback()
+		log.Println(" Error select old detail:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
 	}
+	if oldBarang.Valid {
+		tempID := int(oldBarang.Int64)
+		old.IDBarang = &tempID
+	}
+
+	sebelum, _ := audit.Snapshot(tx, audit.EntitasDetailServis, id)
 
-	_, err = database.DB.Exec(`
-		UPDATE detail_servis SET id_barang=?, deskripsi=?, jumlah=?, harga_satuan=?, biaya=?
+	// Modal lama dipertahankan bila barangnya sama
+	oldModal := map[int]snapshotModal{}
+	if old.IDBarang != nil {
+		oldModal[*old.IDBarang] = snapshotModal{Jumlah: old.Jumlah, Total: float64(old.Jumlah) * old.HargaModal}
+	}
+	details := []models.DetailServis{d}
+	if err := assignHargaModal(tx, details, oldModal); err != nil {
+		tx.Rollback()
+		writeStokError(w, err)
+		return
+	}
+	if err := assignGaransi(tx, details); err != nil {
+		tx.Rollback()
+		writeStokError(w, err)
+		return
+	}
+	d = details[0]
+
+	_, err = tx.Exec(`
+		UPDATE detail_servis SET id_barang=?, deskripsi=?, jumlah=?, harga_satuan=?, biaya=?, harga_modal=?, garansi_hari=?
 		WHERE id_detail=?
-	`, d.IDBarang, d.Deskripsi, d.Jumlah, d.HargaSatuan, d.Biaya, id)
+	`, d.IDBarang, d.Deskripsi, d.Jumlah, d.HargaSatuan, d.Biaya, d.HargaModal, d.GaransiHari, id)
 	if err != nil {
+		tx.Rollback()
 		log.Println(" Error update detail:", err)
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
+	// Selisih stok: kembalikan jumlah lama, kurangi jumlah baru
+	delta := selisihStok(calculateStokUsage([]models.DetailServis{d}), calculateStokUsage([]models.DetailServis{old}))
+	ref := stokRef{IDServis: &old.IDServis, IDDetail: &id, IDUser: currentUserID(r)}
+	if err := applyStokDelta(tx, delta, ref); err != nil {
+		tx.Rollback()
+		writeStokError(w, err)
+		return
+	}
+
 	// Adjust biaya_total: kurangi old biaya, tambah new biaya
-	if oldBiaya != 0 {
-		if _, err := database.DB.Exec(`UPDATE servis SET biaya_total = COALESCE(biaya_total,0) - ? + ? WHERE id_servis = ?`, oldBiaya, d.Biaya, d.IDServis); err != nil {
-			log.Println(" Error adjust biaya_total after update detail:", err)
-		}
-	} else {
-		if _, err := database.DB.Exec(`UPDATE servis SET biaya_total = COALESCE(biaya_total,0) + ? WHERE id_servis = ?`, d.Biaya, d.IDServis); err != nil {
-			log.Println(" Error adjust biaya_total after update detail:", err)
-		}
+	if _, err := tx.Exec(`UPDATE servis SET biaya_total = COALESCE(biaya_total,0) - ? + ? WHERE id_servis = ?`, old.Biaya, d.Biaya, old.IDServis); err != nil {
+		tx.Rollback()
+		log.Println(" Error adjust biaya_total after update detail:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to update total biaya"})
+		return
+	}
+
+	if err := audit.CatatTx(tx, r, audit.AksiUpdate, audit.EntitasDetailServis, id, sebelum); err != nil {
+		tx.Rollback()
+		writeAuditError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
 	}
 
 	json.NewEncoder(w).Encode(map[string]string{"message": "Detail berhasil diperbarui"})
@@ -667,27 +1264,74 @@ func DeleteDetailServis(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/api/pegawai/detail-servis/")
 	id, _ := strconv.Atoi(idStr)
 
-	var idServis int
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	var idServis, jumlah int
+	var idBarang sql.NullInt64
 	var biaya float64
-	err := database.DB.QueryRow(`SELECT id_servis, biaya FROM detail_servis WHERE id_detail = ?`, id).Scan(&idServis, &biaya)
-	if err != nil && err != sql.ErrNoRows {
+	err = tx.QueryRow(`
+		SELECT id_servis, id_barang, jumlah, biaya FROM detail_servis WHERE id_detail = ? FOR UPDATE
+	`, id).Scan(&idServis, &idBarang, &jumlah, &biaya)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Detail tidak ditemukan"})
+		return
+	} else if err != nil {
+		tx.Rollback()
 		log.Println(" Error select detail before delete:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
 	}
 
-	_, err = database.DB.Exec(`DELETE FROM detail_servis WHERE id_detail=?`, id)
-	if err != nil {
+	sebelum, _ := audit.Snapshot(tx, audit.EntitasDetailServis, id)
+
+	if _, err := tx.Exec(`DELETE FROM detail_servis WHERE id_detail=?`, id); err != nil {
+		tx.Rollback()
 		log.Println(" Error delete detail:", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
-	// Update biaya_total: kurangi biaya detail
-	if idServis != 0 {
-		if _, err := database.DB.Exec(`UPDATE servis SET biaya_total = COALESCE(biaya_total,0) - ? WHERE id_servis = ?`, biaya, idServis); err != nil {
-			log.Println(" Error update biaya_total after delete detail:", err)
+	// Kembalikan stok barang
+	if idBarang.Valid {
+		ref := stokRef{IDServis: &idServis, IDDetail: &id, IDUser: currentUserID(r), Keterangan: "Detail servis dihapus"}
+		if err := applyStokDelta(tx, map[int]int{int(idBarang.Int64): -jumlah}, ref); err != nil {
+			tx.Rollback()
+			writeStokError(w, err)
+			return
 		}
 	}
 
+	// Update biaya_total: kurangi biaya detail
+	if _, err := tx.Exec(`UPDATE servis SET biaya_total = COALESCE(biaya_total,0) - ? WHERE id_servis = ?`, biaya, idServis); err != nil {
+		tx.Rollback()
+		log.Println(" Error update biaya_total after delete detail:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to update total biaya"})
+		return
+	}
+
+	if err := audit.CatatTx(tx, r, audit.AksiDelete, audit.EntitasDetailServis, id, sebelum); err != nil {
+		tx.Rollback()
+		writeAuditError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
+	}
+
 	json.NewEncoder(w).Encode(map[string]string{"message": "Detail berhasil dihapus"})
-}
\ No newline at end of file
+}
@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"reflect"
+	"service_hp/models"
+	"testing"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestCalculateStokUsage(t *testing.T) {
+	tests := []struct {
+		nama    string
+		details []models.DetailServis
+		want    map[int]int
+	}{
+		{"kosong", nil, map[int]int{}},
+		{"jasa tanpa barang", []models.DetailServis{{Jumlah: 1}}, map[int]int{}},
+		{"satu barang", []models.DetailServis{{IDBarang: intPtr(1), Jumlah: 2}}, map[int]int{1: 2}},
+		{
+			"barang sama dijumlahkan",
+			[]models.DetailServis{
+				{IDBarang: intPtr(1), Jumlah: 2},
+				{IDBarang: intPtr(2), Jumlah: 1},
+				{IDBarang: intPtr(1), Jumlah: 3},
+				{Jumlah: 5},
+			},
+			map[int]int{1: 5, 2: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := calculateStokUsage(tt.details); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("calculateStokUsage = %v, seharusnya %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelisihStok(t *testing.T) {
+	tests := []struct {
+		nama string
+		baru map[int]int
+		lama map[int]int
+		want map[int]int
+	}{
+		{"servis baru", map[int]int{1: 2}, nil, map[int]int{1: 2}},
+		{"detail dihapus", nil, map[int]int{1: 2}, map[int]int{1: -2}},
+		{"tidak berubah", map[int]int{1: 2}, map[int]int{1: 2}, map[int]int{1: 0}},
+		{"jumlah ditambah", map[int]int{1: 5}, map[int]int{1: 2}, map[int]int{1: 3}},
+		{"jumlah dikurangi", map[int]int{1: 1}, map[int]int{1: 4}, map[int]int{1: -3}},
+		{"barang diganti", map[int]int{2: 1}, map[int]int{1: 1}, map[int]int{1: -1, 2: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := selisihStok(tt.baru, tt.lama); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selisihStok = %v, seharusnya %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"service_hp/audit"
+	"service_hp/database"
+	"service_hp/models"
+	"service_hp/notifikasi"
+	"service_hp/routes/middleware"
+	"strconv"
+	"strings"
+)
+
+// transisiStatus - status tujuan yang boleh dicapai dari tiap status
+var transisiStatus = map[string][]string{
+	models.StatusPending:             {models.StatusDalamPerbaikan, models.StatusBatal},
+	models.StatusDalamPerbaikan:      {models.StatusSelesai, models.StatusTidakBisaDiperbaiki, models.StatusBatal},
+	models.StatusSelesai:             {models.StatusSiapDiambil, models.StatusDalamPerbaikan},
+	models.StatusSiapDiambil:         {models.StatusDiambil},
+	models.StatusTidakBisaDiperbaiki: {models.StatusDiambil},
+	models.StatusDiambil:             {},
+	models.StatusBatal:               {},
+}
+
+// izinStatus - permission yang dibutuhkan untuk memindahkan servis ke status tujuan.
+// Pengerjaan oleh teknisi, penyerahan & pembatalan oleh kasir.
+var izinStatus = map[string]string{
+	models.StatusDalamPerbaikan:      middleware.PermServisKerjakan,
+	models.StatusSelesai:             middleware.PermServisKerjakan,
+	models.StatusTidakBisaDiperbaiki: middleware.PermServisKerjakan,
+	models.StatusSiapDiambil:         middleware.PermServisEdit,
+	models.StatusDiambil:             middleware.PermServisSerahkan,
+	models.StatusBatal:               middleware.PermServisSerahkan,
+}
+
+// Helper: cek izin user untuk status tujuan, tulis 403 jika tidak boleh
+func checkStatusPermission(w http.ResponseWriter, r *http.Request, statusBaru string) bool {
+	perm, ok := izinStatus[statusBaru]
+	if ok && middleware.HasPermission(r.Context(), perm) {
+		return true
+	}
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error": "Anda tidak punya izin mengubah status ke " + statusBaru,
+	})
+	return false
+}
+
+// errServisTidakDitemukan dikembalikan saat id servis tidak ada
+var errServisTidakDitemukan = errors.New("Servis tidak ditemukan")
+
+// transisiError dikembalikan saat perubahan status tidak diizinkan
+type transisiError struct {
+	Dari string
+	Ke   string
+}
+
+func (e *transisiError) Error() string {
+	return fmt.Sprintf("Status tidak bisa diubah dari %s ke %s", e.Dari, e.Ke)
+}
+
+// normalisasi status servis agar cocok enum DB, false jika status tidak dikenal
+func normalizeStatus(input string) (string, bool) {
+	m := map[string]string{
+		"pending":               models.StatusPending,
+		"dalam_perbaikan":       models.StatusDalamPerbaikan,
+		"selesai":               models.StatusSelesai,
+		"siap_diambil":          models.StatusSiapDiambil,
+		"diambil":               models.StatusDiambil,
+		"batal":                 models.StatusBatal,
+		"tidak_bisa_diperbaiki": models.StatusTidakBisaDiperbaiki,
+		"dalam perbaikan":       models.StatusDalamPerbaikan,
+		"siap diambil":          models.StatusSiapDiambil,
+		"belum dikerjakan":      models.StatusPending,
+		"tidak bisa diperbaiki": models.StatusTidakBisaDiperbaiki,
+	}
+
+	key := strings.ToLower(strings.TrimSpace(input))
+	v, ok := m[key]
+	return v, ok
+}
+
+// Helper: cek apakah transisi status diizinkan
+func canTransition(dari, ke string) bool {
+	for _, s := range transisiStatus[dari] {
+		if s == ke {
+			return true
+		}
+	}
+	return false
+}
+
+// Helper: catat riwayat status servis & antrikan notifikasi WhatsApp untuk status baru
+func recordStatusHistory(tx *sql.Tx, idServis int, statusLama *string, statusBaru string, idUser *int, keterangan string) error {
+	_, err := tx.Exec(`
+		INSERT INTO servis_status_history (id_servis, status_lama, status_baru, id_user, keterangan)
+		VALUES (?, ?, ?, ?, ?)
+	`, idServis, statusLama, statusBaru, idUser, keterangan)
+	if err != nil {
+		return err
+	}
+	return notifikasi.EnqueueStatus(tx, idServis, statusBaru)
+}
+
+// Helper: ubah status servis dalam transaksi sesuai state machine.
+// tanggal_selesai diisi otomatis saat servis ditutup dan dikosongkan saat dikerjakan ulang.
+// Pembatalan mengembalikan stok barang yang sudah dipakai.
+func changeServisStatus(tx *sql.Tx, idServis int, statusBaru string, idUser *int, keterangan string) (string, error) {
+	var statusLama string
+	err := tx.QueryRow(`SELECT status_servis FROM servis WHERE id_servis = ? FOR UPDATE`, idServis).Scan(&statusLama)
+	if err == sql.ErrNoRows {
+		return "", errServisTidakDitemukan
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if !canTransition(statusLama, statusBaru) {
+		return statusLama, &transisiError{Dari: statusLama, Ke: statusBaru}
+	}
+
+	// HP hanya boleh diserahkan ke pelanggan setelah lunas
+	if statusBaru == models.StatusDiambil {
+		t, err := loadTagihan(tx, idServis)
+		if err != nil {
+			return statusLama, err
+		}
+		if t.SisaTagihan > 0 {
+			return statusLama, &tagihanError{Sisa: t.SisaTagihan}
+		}
+	}
+
+	// Barang yang sudah dipakai servis batal dikembalikan ke stok (retur)
+	if statusBaru == models.StatusBatal {
+		usage, err := loadStokUsage(tx, idServis)
+		if err != nil {
+			return statusLama, err
+		}
+		restore := map[int]int{}
+		for idBarang, jumlah := range usage {
+			restore[idBarang] = -jumlah
+		}
+		ref := stokRef{IDServis: &idServis, IDUser: idUser, Keterangan: "Servis dibatalkan"}
+		if err := applyStokDelta(tx, restore, ref); err != nil {
+			return statusLama, err
+		}
+	}
+
+	var tanggalSelesai string
+	switch statusBaru {
+	case models.StatusSelesai, models.StatusTidakBisaDiperbaiki, models.StatusBatal:
+		tanggalSelesai = "tanggal_selesai = NOW()"
+	case models.StatusDalamPerbaikan:
+		tanggalSelesai = "tanggal_selesai = NULL"
+	default:
+		tanggalSelesai = "tanggal_selesai = tanggal_selesai"
+	}
+
+	if _, err := tx.Exec(`UPDATE servis SET status_servis = ?, `+tanggalSelesai+` WHERE id_servis = ?`, statusBaru, idServis); err != nil {
+		return statusLama, err
+	}
+
+	// Kode kunci layar tidak disimpan lagi setelah HP keluar dari toko
+	if statusBaru == models.StatusDiambil || statusBaru == models.StatusBatal {
+		if _, err := tx.Exec(`UPDATE servis_intake SET kode_kunci = NULL WHERE id_servis = ?`, idServis); err != nil {
+			return statusLama, err
+		}
+	}
+
+	if err := recordStatusHistory(tx, idServis, &statusLama, statusBaru, idUser, keterangan); err != nil {
+		return statusLama, err
+	}
+	return statusLama, nil
+}
+
+// Helper: tulis response untuk error dari changeServisStatus
+func writeStatusError(w http.ResponseWriter, err error) {
+	var transisi *transisiError
+	var tagihan *tagihanError
+	switch {
+	case errors.Is(err, errServisTidakDitemukan):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	case errors.As(err, &transisi):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":            transisi.Error(),
+			"status_diizinkan": transisiStatus[transisi.Dari],
+		})
+	case errors.As(err, &tagihan):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":        tagihan.Error(),
+			"sisa_tagihan": tagihan.Sisa,
+		})
+	default:
+		log.Println(" Error ubah status servis:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal mengubah status servis"})
+	}
+}
+
+// Helper: ambil id servis dari /api/pegawai/servis/{id}/<aksi>
+func extractServisSubID(path, aksi string) (int, error) {
+	idStr := strings.TrimPrefix(path, "/api/pegawai/servis/")
+	idStr = strings.TrimSuffix(strings.TrimSuffix(idStr, "/"), "/"+aksi)
+	return strconv.Atoi(idStr)
+}
+
+// =======================================================
+// UBAH STATUS SERVIS (PATCH)
+// =======================================================
+func UpdateStatusServis(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractServisSubID(r.URL.Path, "status")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.UbahStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	statusBaru, ok := normalizeStatus(req.Status)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Status tidak dikenal: " + req.Status})
+		return
+	}
+
+	if !checkStatusPermission(w, r, statusBaru) {
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	statusLama, err := changeServisStatus(tx, id, statusBaru, currentUserID(r), req.Keterangan)
+	if err != nil {
+		tx.Rollback()
+		writeStatusError(w, err)
+		return
+	}
+
+	if err := audit.Record(tx, r, audit.Entry{
+		Aksi:      "servis.status",
+		Entitas:   audit.EntitasServis,
+		IDEntitas: id,
+		Sebelum:   map[string]string{"status_servis": statusLama},
+		Sesudah:   map[string]string{"status_servis": statusBaru, "keterangan": req.Keterangan},
+	}); err != nil {
+		tx.Rollback()
+		writeAuditError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":     "Status servis berhasil diubah",
+		"status_lama": statusLama,
+		"status_baru": statusBaru,
+	})
+}
+
+// =======================================================
+// GET RIWAYAT STATUS SERVIS
+// =======================================================
+func GetRiwayatStatusServis(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractServisSubID(r.URL.Path, "status")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT h.id_history, h.id_servis, h.status_lama, h.status_baru,
+			h.id_user, COALESCE(u.nama, ''), COALESCE(h.keterangan, ''), h.created_at
+		FROM servis_status_history h
+		LEFT JOIN user u ON h.id_user = u.id_user
+		WHERE h.id_servis = ?
+		ORDER BY h.id_history ASC
+	`, id)
+	if err != nil {
+		log.Println(" Error query riwayat status:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	list := []models.ServisStatusHistory{}
+	for rows.Next() {
+		var h models.ServisStatusHistory
+		var statusLama sql.NullString
+		var idUser sql.NullInt64
+
+		if err := rows.Scan(&h.IDHistory, &h.IDServis, &statusLama, &h.StatusBaru,
+			&idUser, &h.NamaUser, &h.Keterangan, &h.CreatedAt); err != nil {
+			log.Println(" Error scan riwayat status:", err)
+			continue
+		}
+		if statusLama.Valid {
+			h.StatusLama = &statusLama.String
+		}
+		h.IDUser = nullIntPtr(idUser)
+		list = append(list, h)
+	}
+
+	json.NewEncoder(w).Encode(list)
+}
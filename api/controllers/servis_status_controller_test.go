@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"service_hp/models"
+	"testing"
+)
+
+func TestNormalizeStatus(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		dikenal bool
+	}{
+		{"pending", models.StatusPending, true},
+		{"dalam_perbaikan", models.StatusDalamPerbaikan, true},
+		{"Dalam Perbaikan", models.StatusDalamPerbaikan, true},
+		{"  SIAP DIAMBIL ", models.StatusSiapDiambil, true},
+		{"belum dikerjakan", models.StatusPending, true},
+		{"tidak bisa diperbaiki", models.StatusTidakBisaDiperbaiki, true},
+		{"batal", models.StatusBatal, true},
+		{"", "", false},
+		{"dikirim", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := normalizeStatus(tt.input)
+			if got != tt.want || ok != tt.dikenal {
+				t.Errorf("normalizeStatus(%q) = %q, %v; seharusnya %q, %v", tt.input, got, ok, tt.want, tt.dikenal)
+			}
+		})
+	}
+}
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		dari, ke string
+		want     bool
+	}{
+		{models.StatusPending, models.StatusDalamPerbaikan, true},
+		{models.StatusPending, models.StatusBatal, true},
+		{models.StatusPending, models.StatusSelesai, false},
+		{models.StatusDalamPerbaikan, models.StatusSelesai, true},
+		{models.StatusDalamPerbaikan, models.StatusTidakBisaDiperbaiki, true},
+		{models.StatusSelesai, models.StatusDalamPerbaikan, true},
+		{models.StatusSelesai, models.StatusBatal, false},
+		{models.StatusSiapDiambil, models.StatusDiambil, true},
+		{models.StatusTidakBisaDiperbaiki, models.StatusDiambil, true},
+		{models.StatusDiambil, models.StatusPending, false},
+		{models.StatusBatal, models.StatusPending, false},
+		{models.StatusPending, models.StatusPending, false},
+		{"tidak_dikenal", models.StatusPending, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dari+" ke "+tt.ke, func(t *testing.T) {
+			if got := canTransition(tt.dari, tt.ke); got != tt.want {
+				t.Errorf("canTransition(%q, %q) = %v, seharusnya %v", tt.dari, tt.ke, got, tt.want)
+			}
+		})
+	}
+}
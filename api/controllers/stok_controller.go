@@ -0,0 +1,420 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"service_hp/audit"
+	"service_hp/database"
+	"service_hp/models"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// errBarangTidakDitemukan dikembalikan saat mutasi merujuk id_barang yang tidak ada
+var errBarangTidakDitemukan = errors.New("Barang tidak ditemukan")
+
+// errServisBatal dikembalikan saat barang servis yang sudah dibatalkan mau diubah.
+// Stok servis batal sudah dikembalikan, detailnya tinggal sebagai catatan.
+var errServisBatal = errors.New("Barang servis yang sudah dibatalkan tidak bisa diubah")
+
+// stokKurangError dikembalikan saat stok barang tidak cukup untuk mutasi keluar
+type stokKurangError struct {
+	NamaBarang string
+	Tersedia   int
+	Dibutuhkan int
+}
+
+func (e *stokKurangError) Error() string {
+	return fmt.Sprintf("Stok %s tidak mencukupi (tersedia %d, dibutuhkan %d)", e.NamaBarang, e.Tersedia, e.Dibutuhkan)
+}
+
+// stokRef - Referensi servis/detail/user yang ikut dicatat pada mutasi stok servis
+type stokRef struct {
+	IDServis   *int
+	IDDetail   *int
+	IDUser     *int
+	Keterangan string
+}
+
+// Helper: catat satu mutasi ke kartu stok dan terapkan ke barang.stok.
+// Satu-satunya jalur yang boleh mengubah barang.stok.
+func recordStockMovement(tx *sql.Tx, m models.StockMovement) error {
+	var nama string
+	var stok int
+	err := tx.QueryRow(`SELECT nama_barang, stok FROM barang WHERE id_barang = ? FOR UPDATE`, m.IDBarang).Scan(&nama, &stok)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w (id %d)", errBarangTidakDitemukan, m.IDBarang)
+	}
+	if err != nil {
+		return err
+	}
+
+	if stok+m.Jumlah < 0 {
+		return &stokKurangError{NamaBarang: nama, Tersedia: stok, Dibutuhkan: -m.Jumlah}
+	}
+
+	if _, err := tx.Exec(`UPDATE barang SET stok = stok + ? WHERE id_barang = ?`, m.Jumlah, m.IDBarang); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO stock_movement (id_barang, jenis, jumlah, id_servis, id_detail, id_pembelian, id_user, keterangan)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, m.IDBarang, m.Jenis, m.Jumlah, m.IDServis, m.IDDetail, m.IDPembelian, m.IDUser, m.Keterangan)
+	return err
+}
+
+// Helper: terapkan selisih stok servis dalam transaksi.
+// Nilai positif mengurangi stok (pemakaian), nilai negatif mengembalikan stok (retur).
+// Pemakaian untuk servis ditolak selama penawaran belum disetujui pelanggan atau melebihi
+// jumlah barang pada penawaran yang disetujui, dan servis yang sudah batal tidak boleh
+// berubah pemakaian barangnya.
+func applyStokDelta(tx *sql.Tx, delta map[int]int, ref stokRef) error {
+	// Urutkan id agar urutan lock barang selalu sama antar transaksi
+	ids := make([]int, 0, len(delta))
+	var pakai []int
+	for id, qty := range delta {
+		if qty != 0 {
+			ids = append(ids, id)
+		}
+		if qty > 0 {
+			pakai = append(pakai, id)
+		}
+	}
+	sort.Ints(ids)
+	sort.Ints(pakai)
+
+	if len(ids) > 0 && ref.IDServis != nil {
+		var status string
+		err := tx.QueryRow(`SELECT status_servis FROM servis WHERE id_servis = ?`, *ref.IDServis).Scan(&status)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if status == models.StatusBatal {
+			return errServisBatal
+		}
+	}
+
+	if len(pakai) > 0 && ref.IDServis != nil {
+		if err := cekPemakaianPenawaran(tx, *ref.IDServis, pakai); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range ids {
+		qty := delta[id]
+
+		jenis := models.MutasiPemakaianServis
+		if qty < 0 {
+			jenis = models.MutasiRetur
+		}
+
+		err := recordStockMovement(tx, models.StockMovement{
+			IDBarang:   id,
+			Jenis:      jenis,
+			Jumlah:     -qty,
+			IDServis:   ref.IDServis,
+			IDDetail:   ref.IDDetail,
+			IDUser:     ref.IDUser,
+			Keterangan: ref.Keterangan,
+		})
+		if errors.Is(err, errBarangTidakDitemukan) && qty < 0 {
+			// Barang sudah dihapus, tidak ada stok yang bisa dikembalikan
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Helper: tulis response untuk error mutasi stok
+func writeStokError(w http.ResponseWriter, err error) {
+	var kurang *stokKurangError
+	var melebihi *melebihiPenawaranError
+	switch {
+	case errors.As(err, &kurang):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": kurang.Error()})
+	case errors.As(err, &melebihi):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": melebihi.Error()})
+	case errors.Is(err, errBarangTidakDitemukan):
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	case errors.Is(err, errBelumDisetujui), errors.Is(err, errServisBatal):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	default:
+		log.Println(" Error update stok:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal memperbarui stok barang"})
+	}
+}
+
+// Helper: konversi kolom INT nullable ke pointer
+func nullIntPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	id := int(v.Int64)
+	return &id
+}
+
+// Helper: ambil id barang dari path /api/pegawai/barang/{id}/<aksi>
+func extractBarangSubID(path, aksi string) (int, error) {
+	idStr := strings.TrimPrefix(path, "/api/pegawai/barang/")
+	idStr = strings.TrimSuffix(strings.TrimSuffix(idStr, "/"), "/"+aksi)
+	return strconv.Atoi(idStr)
+}
+
+// =======================================================
+// GET KARTU STOK (mutasi + saldo berjalan)
+// =======================================================
+func GetKartuStok(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	idBarang, err := extractBarangSubID(r.URL.Path, "kartu-stok")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	dari := r.URL.Query().Get("dari")
+	sampai := r.URL.Query().Get("sampai")
+
+	var kartu models.KartuStok
+	err = database.DB.QueryRow(`
+		SELECT id_barang, nama_barang, stok, harga, COALESCE(harga_modal, 0)
+		FROM barang WHERE id_barang = ?
+	`, idBarang).Scan(
+		&kartu.Barang.IDBarang,
+		&kartu.Barang.NamaBarang,
+		&kartu.Barang.Stok,
+		&kartu.Barang.Harga,
+		&kartu.Barang.HargaModal,
+	)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Barang tidak ditemukan"})
+		return
+	}
+	kartu.StokTercatat = kartu.Barang.Stok
+
+	if err := database.DB.QueryRow(`
+		SELECT COALESCE(SUM(jumlah), 0) FROM stock_movement WHERE id_barang = ?
+	`, idBarang).Scan(&kartu.StokLedger); err != nil {
+		log.Println(" Error hitung stok ledger:", err)
+	}
+	kartu.Selisih = kartu.StokTercatat - kartu.StokLedger
+
+	// Saldo awal = total mutasi sebelum tanggal "dari"
+	if dari != "" {
+		if err := database.DB.QueryRow(`
+			SELECT COALESCE(SUM(jumlah), 0) FROM stock_movement
+			WHERE id_barang = ? AND DATE(created_at) < ?
+		`, idBarang, dari).Scan(&kartu.SaldoAwal); err != nil {
+			log.Println(" Error hitung saldo awal:", err)
+		}
+	}
+
+	query := `
+		SELECT id_movement, id_barang, jenis, jumlah, id_servis, id_detail, id_pembelian, id_user,
+			COALESCE(keterangan, ''), created_at
+		FROM stock_movement
+		WHERE id_barang = ?
+	`
+	args := []interface{}{idBarang}
+	if dari != "" {
+		query += " AND DATE(created_at) >= ?"
+		args = append(args, dari)
+	}
+	if sampai != "" {
+		query += " AND DATE(created_at) <= ?"
+		args = append(args, sampai)
+	}
+	query += " ORDER BY id_movement ASC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		log.Println(" Error query kartu stok:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	saldo := kartu.SaldoAwal
+	kartu.Mutasi = []models.StockMovement{}
+
+	for rows.Next() {
+		var m models.StockMovement
+		var idServis, idDetail, idPembelian, idUser sql.NullInt64
+
+		err := rows.Scan(
+			&m.IDMovement, &m.IDBarang, &m.Jenis, &m.Jumlah,
+			&idServis, &idDetail, &idPembelian, &idUser,
+			&m.Keterangan, &m.CreatedAt,
+		)
+		if err != nil {
+			log.Println(" Error scan mutasi:", err)
+			continue
+		}
+
+		m.IDServis = nullIntPtr(idServis)
+		m.IDDetail = nullIntPtr(idDetail)
+		m.IDPembelian = nullIntPtr(idPembelian)
+		m.IDUser = nullIntPtr(idUser)
+
+		saldo += m.Jumlah
+		m.Saldo = saldo
+		kartu.Mutasi = append(kartu.Mutasi, m)
+	}
+	kartu.SaldoAkhir = saldo
+
+	json.NewEncoder(w).Encode(kartu)
+}
+
+// =======================================================
+// STOCK OPNAME (sesuaikan stok dengan hitung fisik)
+// =======================================================
+func StockOpname(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	idBarang, err := extractBarangSubID(r.URL.Path, "stock-opname")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.StockOpnameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	if req.StokFisik < 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Stok tidak boleh negatif"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	var stok int
+	err = tx.QueryRow(`SELECT stok FROM barang WHERE id_barang = ? FOR UPDATE`, idBarang).Scan(&stok)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Barang tidak ditemukan"})
+		return
+	} else if err != nil {
+		tx.Rollback()
+		log.Println(" Error select stok:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	keterangan := req.Keterangan
+	if keterangan == "" {
+		keterangan = fmt.Sprintf("Stock opname: sistem %d, fisik %d", stok, req.StokFisik)
+	}
+
+	// Selisih 0 tetap dicatat sebagai bukti stok sudah dihitung
+	err = recordStockMovement(tx, models.StockMovement{
+		IDBarang:   idBarang,
+		Jenis:      models.MutasiStockOpname,
+		Jumlah:     req.StokFisik - stok,
+		IDUser:     currentUserID(r),
+		Keterangan: keterangan,
+	})
+	if err != nil {
+		tx.Rollback()
+		writeStokError(w, err)
+		return
+	}
+
+	if err := audit.Record(tx, r, audit.Entry{
+		Aksi:      "stock_opname",
+		Entitas:   audit.EntitasBarang,
+		IDEntitas: idBarang,
+		Sebelum:   map[string]int{"stok": stok},
+		Sesudah:   map[string]int{"stok": req.StokFisik},
+	}); err != nil {
+		tx.Rollback()
+		writeAuditError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":    "Stock opname berhasil dicatat",
+		"stok_lama":  stok,
+		"stok_fisik": req.StokFisik,
+		"selisih":    req.StokFisik - stok,
+	})
+}
+
+// =======================================================
+// REKONSILIASI STOK (barang.stok vs ledger)
+// =======================================================
+func GetRekonsiliasiStok(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rows, err := database.DB.Query(`
+		SELECT b.id_barang, b.nama_barang, b.stok, COALESCE(SUM(m.jumlah), 0) as stok_ledger
+		FROM barang b
+		LEFT JOIN stock_movement m ON m.id_barang = b.id_barang
+		GROUP BY b.id_barang, b.nama_barang, b.stok
+		HAVING b.stok <> stok_ledger
+		ORDER BY b.nama_barang ASC
+	`)
+	if err != nil {
+		log.Println(" Error query rekonsiliasi:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	data := []map[string]interface{}{}
+	for rows.Next() {
+		var idBarang, stok, stokLedger int
+		var nama string
+		if err := rows.Scan(&idBarang, &nama, &stok, &stokLedger); err != nil {
+			log.Println(" Error scan row:", err)
+			continue
+		}
+		data = append(data, map[string]interface{}{
+			"id_barang":     idBarang,
+			"nama_barang":   nama,
+			"stok_tercatat": stok,
+			"stok_ledger":   stokLedger,
+			"selisih":       stok - stokLedger,
+		})
+	}
+
+	json.NewEncoder(w).Encode(data)
+}
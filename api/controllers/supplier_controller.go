@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"service_hp/audit"
+	"service_hp/database"
+	"service_hp/models"
+	"strconv"
+	"strings"
+)
+
+// Helper: ambil id dari segmen terakhir path
+func extractLastID(path string) (int, error) {
+	parts := strings.Split(strings.TrimSuffix(path, "/"), "/")
+	return strconv.Atoi(parts[len(parts)-1])
+}
+
+// GET: Ambil semua supplier
+func GetAllSupplier(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rows, err := database.DB.Query(`
+		SELECT
+			id_supplier,
+			nama_supplier,
+			COALESCE(no_telepon, ''),
+			COALESCE(alamat, ''),
+			COALESCE(keterangan, '')
+		FROM supplier
+		ORDER BY nama_supplier ASC
+	`)
+	if err != nil {
+		log.Println(" Error query supplier:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	list := []models.Supplier{}
+	for rows.Next() {
+		var s models.Supplier
+		if err := rows.Scan(&s.IDSupplier, &s.NamaSupplier, &s.NoTelepon, &s.Alamat, &s.Keterangan); err != nil {
+			log.Println(" Error scan row:", err)
+			continue
+		}
+		list = append(list, s)
+	}
+
+	json.NewEncoder(w).Encode(list)
+}
+
+// POST: Tambah supplier
+func CreateSupplier(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req models.Supplier
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	if strings.TrimSpace(req.NamaSupplier) == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Nama supplier wajib diisi"})
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		INSERT INTO supplier (nama_supplier, no_telepon, alamat, keterangan)
+		VALUES (?, ?, ?, ?)`,
+		req.NamaSupplier, req.NoTelepon, req.Alamat, req.Keterangan)
+	if err != nil {
+		log.Println(" Error insert supplier:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	lastID, _ := result.LastInsertId()
+	audit.Catat(database.DB, r, audit.AksiCreate, audit.EntitasSupplier, int(lastID), nil)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":     "Supplier berhasil ditambahkan",
+		"id_supplier": lastID,
+	})
+}
+
+// PUT: Update supplier
+func UpdateSupplier(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	idSupplier, err := extractLastID(r.URL.Path)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.Supplier
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	if strings.TrimSpace(req.NamaSupplier) == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Nama supplier wajib diisi"})
+		return
+	}
+
+	var exists int
+	if err := database.DB.QueryRow("SELECT COUNT(*) FROM supplier WHERE id_supplier=?", idSupplier).Scan(&exists); err != nil || exists == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Supplier tidak ditemukan"})
+		return
+	}
+
+	sebelum, _ := audit.Snapshot(database.DB, audit.EntitasSupplier, idSupplier)
+
+	_, err = database.DB.Exec(`
+		UPDATE supplier
+		SET nama_supplier=?, no_telepon=?, alamat=?, keterangan=?
+		WHERE id_supplier=?`,
+		req.NamaSupplier, req.NoTelepon, req.Alamat, req.Keterangan, idSupplier)
+	if err != nil {
+		log.Println(" Error update supplier:", err)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	audit.Catat(database.DB, r, audit.AksiUpdate, audit.EntitasSupplier, idSupplier, sebelum)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Supplier berhasil diperbarui"})
+}
+
+// DELETE: Hapus supplier (hanya jika belum punya pembelian)
+func DeleteSupplier(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	idSupplier, err := extractLastID(r.URL.Path)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	var totalPembelian int
+	if err := database.DB.QueryRow("SELECT COUNT(*) FROM pembelian WHERE id_supplier=?", idSupplier).Scan(&totalPembelian); err != nil {
+		log.Println(" Error check pembelian supplier:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if totalPembelian > 0 {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Supplier sudah memiliki riwayat pembelian"})
+		return
+	}
+
+	sebelum, _ := audit.Snapshot(database.DB, audit.EntitasSupplier, idSupplier)
+
+	result, err := database.DB.Exec("DELETE FROM supplier WHERE id_supplier=?", idSupplier)
+	if err != nil {
+		log.Println(" Error delete supplier:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Supplier tidak ditemukan"})
+		return
+	}
+
+	audit.Catat(database.DB, r, audit.AksiDelete, audit.EntitasSupplier, idSupplier, sebelum)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Supplier berhasil dihapus"})
+}
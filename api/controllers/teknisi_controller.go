@@ -0,0 +1,305 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"service_hp/audit"
+	"service_hp/database"
+	"service_hp/models"
+	"service_hp/routes/middleware"
+	"strings"
+)
+
+// errTeknisiTidakValid dikembalikan saat pegawai yang ditugaskan bukan teknisi aktif
+var errTeknisiTidakValid = errors.New("Teknisi harus pegawai aktif dengan jabatan teknisi")
+
+// errServisDitutup dikembalikan saat teknisi servis yang sudah ditutup mau diubah
+var errServisDitutup = errors.New("Teknisi servis yang sudah ditutup tidak bisa diubah")
+
+// Helper: pastikan id pegawai adalah teknisi yang masih aktif
+func validateTeknisi(q queryer, idPegawai int) error {
+	var jabatan, status string
+	err := q.QueryRow(`SELECT jabatan, status FROM pegawai WHERE id_pegawai = ?`, idPegawai).Scan(&jabatan, &status)
+	if err == sql.ErrNoRows {
+		return errTeknisiTidakValid
+	}
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(strings.TrimSpace(jabatan), middleware.AksesTeknisi) || !strings.EqualFold(status, "aktif") {
+		return errTeknisiTidakValid
+	}
+	return nil
+}
+
+// Helper: catat riwayat penugasan teknisi servis
+func recordTeknisiHistory(tx *sql.Tx, idServis int, lama, baru *int, idUser *int, keterangan string) error {
+	_, err := tx.Exec(`
+		INSERT INTO servis_teknisi_history (id_servis, id_teknisi_lama, id_teknisi_baru, id_user, keterangan)
+		VALUES (?, ?, ?, ?, ?)
+	`, idServis, lama, baru, idUser, keterangan)
+	return err
+}
+
+func sameIntPtr(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// Helper: tugaskan / alihkan / lepas teknisi servis dalam transaksi.
+// Mengembalikan teknisi lama dan false jika teknisi tidak berubah.
+func assignTeknisi(tx *sql.Tx, idServis int, baru *int, idUser *int, keterangan string) (*int, bool, error) {
+	var lama sql.NullInt64
+	var status string
+	err := tx.QueryRow(`SELECT id_teknisi, status_servis FROM servis WHERE id_servis = ? FOR UPDATE`, idServis).Scan(&lama, &status)
+	if err == sql.ErrNoRows {
+		return nil, false, errServisTidakDitemukan
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	idLama := nullIntPtr(lama)
+
+	// Servis tanpa transisi lanjutan (diambil, batal) sudah ditutup
+	if len(transisiStatus[status]) == 0 {
+		return idLama, false, errServisDitutup
+	}
+	if sameIntPtr(idLama, baru) {
+		return idLama, false, nil
+	}
+	if baru != nil {
+		if err := validateTeknisi(tx, *baru); err != nil {
+			return idLama, false, err
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE servis SET id_teknisi = ? WHERE id_servis = ?`, baru, idServis); err != nil {
+		return idLama, false, err
+	}
+	if err := recordTeknisiHistory(tx, idServis, idLama, baru, idUser, keterangan); err != nil {
+		return idLama, false, err
+	}
+	return idLama, true, nil
+}
+
+// Helper: tulis response untuk error penugasan teknisi
+func writeTeknisiError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errServisTidakDitemukan):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, errTeknisiTidakValid):
+		w.WriteHeader(http.StatusBadRequest)
+	case errors.Is(err, errServisDitutup):
+		w.WriteHeader(http.StatusConflict)
+	default:
+		log.Println(" Error assign teknisi:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal mengubah teknisi servis"})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// =======================================================
+// TUGASKAN / ALIHKAN TEKNISI SERVIS (PATCH)
+// =======================================================
+func AssignTeknisiServis(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractServisSubID(r.URL.Path, "teknisi")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.AssignTeknisiRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+	req.Keterangan = truncate(strings.TrimSpace(req.Keterangan), 255)
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+	defer tx.Rollback()
+
+	lama, berubah, err := assignTeknisi(tx, id, req.IDTeknisi, currentUserID(r), req.Keterangan)
+	if err != nil {
+		writeTeknisiError(w, err)
+		return
+	}
+	if !berubah {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":    "Teknisi servis tidak berubah",
+			"id_teknisi": lama,
+		})
+		return
+	}
+
+	if err := audit.Record(tx, r, audit.Entry{
+		Aksi:      "servis.teknisi",
+		Entitas:   audit.EntitasServis,
+		IDEntitas: id,
+		Sebelum:   map[string]interface{}{"id_teknisi": lama},
+		Sesudah:   map[string]interface{}{"id_teknisi": req.IDTeknisi, "keterangan": req.Keterangan},
+	}); err != nil {
+		writeAuditError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":         "Teknisi servis berhasil diubah",
+		"id_teknisi_lama": lama,
+		"id_teknisi_baru": req.IDTeknisi,
+	})
+}
+
+// =======================================================
+// GET RIWAYAT TEKNISI SERVIS
+// =======================================================
+func GetRiwayatTeknisiServis(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractServisSubID(r.URL.Path, "teknisi")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT h.id_history, h.id_servis,
+			h.id_teknisi_lama, COALESCE(pl.nama_pegawai, ''),
+			h.id_teknisi_baru, COALESCE(pb.nama_pegawai, ''),
+			h.id_user, COALESCE(u.nama, ''), COALESCE(h.keterangan, ''), h.created_at
+		FROM servis_teknisi_history h
+		LEFT JOIN pegawai pl ON h.id_teknisi_lama = pl.id_pegawai
+		LEFT JOIN pegawai pb ON h.id_teknisi_baru = pb.id_pegawai
+		LEFT JOIN user u ON h.id_user = u.id_user
+		WHERE h.id_servis = ?
+		ORDER BY h.id_history ASC
+	`, id)
+	if err != nil {
+		log.Println(" Error query riwayat teknisi:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	list := []models.ServisTeknisiHistory{}
+	for rows.Next() {
+		var h models.ServisTeknisiHistory
+		var lama, baru, idUser sql.NullInt64
+
+		if err := rows.Scan(&h.IDHistory, &h.IDServis, &lama, &h.NamaTeknisiLama, &baru, &h.NamaTeknisiBaru,
+			&idUser, &h.NamaUser, &h.Keterangan, &h.CreatedAt); err != nil {
+			log.Println(" Error scan riwayat teknisi:", err)
+			continue
+		}
+		h.IDTeknisiLama = nullIntPtr(lama)
+		h.IDTeknisiBaru = nullIntPtr(baru)
+		h.IDUser = nullIntPtr(idUser)
+		list = append(list, h)
+	}
+
+	json.NewEncoder(w).Encode(list)
+}
+
+// =======================================================
+// GET ANTRIAN SAYA (servis aktif milik teknisi yang login)
+// =======================================================
+func GetAntrianSaya(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var idPegawai int
+	err := database.DB.QueryRow(`SELECT id_pegawai FROM pegawai WHERE id_user = ?`, r.Context().Value(middleware.UserIDKey)).Scan(&idPegawai)
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Akun ini tidak terdaftar sebagai pegawai"})
+		return
+	} else if err != nil {
+		log.Println(" Error query pegawai:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	// Yang sedang dikerjakan di atas, sisanya urut tanggal masuk (FIFO)
+	rows, err := database.DB.Query(servisListQuery+`
+		WHERE s.id_teknisi = ? AND s.status_servis IN ('pending', 'dalam_perbaikan')
+		ORDER BY s.status_servis = 'dalam_perbaikan' DESC, s.tanggal_masuk ASC, s.id_servis ASC
+	`, idPegawai)
+	if err != nil {
+		log.Println(" Error query antrian teknisi:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	list := scanServisList(rows)
+	if list == nil {
+		list = []models.Servis{}
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+// Helper: beban kerja tiap teknisi aktif. Selesai & rata-rata lama perbaikan dihitung
+// untuk servis yang selesai sejak tanggal dari; lama perbaikan diukur dari pertama kali
+// status dalam_perbaikan (atau tanggal masuk untuk data lama) sampai tanggal_selesai.
+func loadBebanTeknisi(dari string) ([]models.BebanTeknisi, error) {
+	rows, err := database.DB.Query(`
+		SELECT p.id_pegawai, p.nama_pegawai,
+			COUNT(CASE WHEN s.status_servis IN ('pending', 'dalam_perbaikan') THEN 1 END),
+			COUNT(CASE WHEN s.status_servis IN ('selesai', 'siap_diambil', 'diambil') AND s.tanggal_selesai >= ? THEN 1 END),
+			AVG(CASE WHEN s.status_servis IN ('selesai', 'siap_diambil', 'diambil') AND s.tanggal_selesai >= ?
+				THEN TIMESTAMPDIFF(MINUTE, COALESCE((
+					SELECT MIN(h.created_at) FROM servis_status_history h
+					WHERE h.id_servis = s.id_servis AND h.status_baru = 'dalam_perbaikan'
+				), s.tanggal_masuk), s.tanggal_selesai) END)
+		FROM pegawai p
+		LEFT JOIN servis s ON s.id_teknisi = p.id_pegawai
+		WHERE LOWER(p.jabatan) = 'teknisi' AND p.status = 'aktif'
+		GROUP BY p.id_pegawai, p.nama_pegawai
+		ORDER BY p.nama_pegawai
+	`, dari, dari)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.BebanTeknisi{}
+	for rows.Next() {
+		var b models.BebanTeknisi
+		var menit sql.NullFloat64
+		if err := rows.Scan(&b.IDPegawai, &b.NamaTeknisi, &b.ServisAktif, &b.ServisSelesai, &menit); err != nil {
+			return nil, err
+		}
+		if menit.Valid {
+			b.RataRataJam = math.Round(menit.Float64/60*10) / 10
+		}
+		list = append(list, b)
+	}
+	return list, rows.Err()
+}
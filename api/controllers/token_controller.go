@@ -0,0 +1,270 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"service_hp/config"
+	"service_hp/database"
+	"service_hp/models"
+	"service_hp/routes/middleware"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// errRefreshTidakValid - refresh token tidak dikenal, kedaluwarsa, atau sudah dicabut
+var errRefreshTidakValid = errors.New("Refresh token tidak valid")
+
+// execQueryer dipenuhi *sql.DB maupun *sql.Tx
+type execQueryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Helper: buat access token JWT berumur pendek
+func signAccessToken(user models.User, tokenVersion int) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id":  user.ID,
+		"username": user.Username,
+		"role":     user.Role,
+		"ver":      tokenVersion,
+		"exp":      time.Now().Add(config.Cfg.JWT.TTL.Duration).Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(config.Cfg.JWT.Secret))
+}
+
+// Helper: simpan refresh token baru (hash-nya saja) dalam family sesi login
+func insertRefreshToken(q execQueryer, r *http.Request, idUser int, family string) (string, int64, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", 0, err
+	}
+
+	res, err := q.Exec(`
+		INSERT INTO refresh_token (id_user, token_hash, family, expires_at, user_agent, ip_address)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, idUser, hashToken(token), family,
+		time.Now().Add(config.Cfg.JWT.RefreshTTL.Duration),
+		truncate(r.UserAgent(), 255), middleware.ClientIP(r))
+	if err != nil {
+		return "", 0, err
+	}
+	id, _ := res.LastInsertId()
+	return token, id, nil
+}
+
+// Helper: buat sesi login baru (access + refresh token)
+func issueTokens(r *http.Request, user models.User, tokenVersion int) (models.TokenResponse, error) {
+	var resp models.TokenResponse
+
+	access, err := signAccessToken(user, tokenVersion)
+	if err != nil {
+		return resp, err
+	}
+
+	family, err := randomToken(24) // 32 karakter
+	if err != nil {
+		return resp, err
+	}
+	refresh, _, err := insertRefreshToken(database.DB, r, user.ID, family)
+	if err != nil {
+		return resp, err
+	}
+
+	resp.Token = access
+	resp.RefreshToken = refresh
+	resp.ExpiresIn = int(config.Cfg.JWT.TTL.Duration.Seconds())
+	return resp, nil
+}
+
+// revokeUserTokens mencabut semua sesi user: access token lewat token_version,
+// refresh token ditandai revoked. Dipanggil saat ganti password, nonaktif, atau dihapus.
+func revokeUserTokens(q execQueryer, idUser int) error {
+	if _, err := q.Exec(`UPDATE user SET token_version = token_version + 1 WHERE id_user = ?`, idUser); err != nil {
+		return err
+	}
+	_, err := q.Exec(`UPDATE refresh_token SET revoked_at = NOW() WHERE id_user = ? AND revoked_at IS NULL`, idUser)
+	return err
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// =======================================================
+// REFRESH TOKEN (POST /api/refresh) - rotasi refresh token
+// =======================================================
+func RefreshToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "refresh_token wajib diisi"})
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Println(" Error begin tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	resp, err := rotateRefreshToken(tx, r, req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, errRefreshTidakValid) {
+			// Commit tetap dijalankan agar pencabutan family (token dipakai ulang) tersimpan
+			tx.Commit()
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		tx.Rollback()
+		log.Println(" Error rotasi refresh token:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println(" Error commit tx:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error commit"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+func rotateRefreshToken(tx *sql.Tx, r *http.Request, token string) (models.TokenResponse, error) {
+	var resp models.TokenResponse
+	var idToken int
+	var family string
+	var expiresAt time.Time
+	var revokedAt sql.NullTime
+	var user models.User
+
+	err := tx.QueryRow(`
+		SELECT t.id_token, t.family, t.expires_at, t.revoked_at, u.id_user, u.username, u.role
+		FROM refresh_token t
+		JOIN user u ON u.id_user = t.id_user
+		WHERE t.token_hash = ?
+		FOR UPDATE
+	`, hashToken(token)).Scan(&idToken, &family, &expiresAt, &revokedAt, &user.ID, &user.Username, &user.Role)
+	if err == sql.ErrNoRows {
+		return resp, errRefreshTidakValid
+	}
+	if err != nil {
+		return resp, err
+	}
+
+	// Token lama dipakai ulang: kemungkinan dicuri, cabut seluruh sesi ini
+	if revokedAt.Valid {
+		log.Printf(" Refresh token dipakai ulang untuk user %d, family %s dicabut", user.ID, family)
+		if _, err := tx.Exec(`UPDATE refresh_token SET revoked_at = NOW() WHERE family = ? AND revoked_at IS NULL`, family); err != nil {
+			return resp, err
+		}
+		return resp, errRefreshTidakValid
+	}
+	if time.Now().After(expiresAt) {
+		return resp, errRefreshTidakValid
+	}
+
+	sesi, ada, err := middleware.LoadSesi(user.ID)
+	if err != nil {
+		return resp, err
+	}
+	if !ada || !sesi.Aktif {
+		return resp, errRefreshTidakValid
+	}
+
+	refresh, idBaru, err := insertRefreshToken(tx, r, user.ID, family)
+	if err != nil {
+		return resp, err
+	}
+	if _, err := tx.Exec(`UPDATE refresh_token SET revoked_at = NOW(), replaced_by = ? WHERE id_token = ?`, idBaru, idToken); err != nil {
+		return resp, err
+	}
+
+	access, err := signAccessToken(user, sesi.TokenVersion)
+	if err != nil {
+		return resp, err
+	}
+
+	resp.Token = access
+	resp.RefreshToken = refresh
+	resp.ExpiresIn = int(config.Cfg.JWT.TTL.Duration.Seconds())
+	return resp, nil
+}
+
+// =======================================================
+// LOGOUT (POST /api/logout)
+// =======================================================
+func Logout(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "refresh_token wajib diisi"})
+		return
+	}
+
+	var idUser int
+	var family string
+	err := database.DB.QueryRow(`
+		SELECT id_user, family FROM refresh_token
+		WHERE token_hash = ? AND revoked_at IS NULL AND expires_at > NOW()
+	`, hashToken(req.RefreshToken)).Scan(&idUser, &family)
+	if err == sql.ErrNoRows {
+		// Token tidak dikenal, sudah dicabut, atau kedaluwarsa: anggap sudah logout.
+		// Token bekas tidak boleh dipakai mencabut sesi lain (semua_perangkat).
+		json.NewEncoder(w).Encode(map[string]string{"message": "Logout berhasil"})
+		return
+	} else if err != nil {
+		log.Println(" Error select refresh token:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal error"})
+		return
+	}
+
+	if req.SemuaPerangkat {
+		err = revokeUserTokens(database.DB, idUser)
+	} else {
+		_, err = database.DB.Exec(`UPDATE refresh_token SET revoked_at = NOW() WHERE family = ? AND revoked_at IS NULL`, family)
+	}
+	if err != nil {
+		log.Println(" Error revoke token:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal logout"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"message": "Logout berhasil"})
+}
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"math/big"
+	"net/http"
+	"service_hp/database"
+	"service_hp/models"
+	"strings"
+)
+
+// Huruf tanpa karakter yang mirip (0/O, 1/I/L) agar mudah dibaca dari nota
+const kodeTrackingAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// Helper: buat kode tracking acak format XXXXX-XXXXX.
+// rand.Int per karakter agar tiap huruf sama peluangnya (256 bukan kelipatan 31).
+func generateKodeTracking() (string, error) {
+	n := big.NewInt(int64(len(kodeTrackingAlphabet)))
+
+	var b strings.Builder
+	for i := 0; i < 10; i++ {
+		if i == 5 {
+			b.WriteByte('-')
+		}
+		v, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(kodeTrackingAlphabet[v.Int64()])
+	}
+	return b.String(), nil
+}
+
+// Helper: samakan format kode dari input pelanggan (huruf kecil, spasi, tanpa tanda hubung)
+func normalizeKodeTracking(input string) string {
+	var b strings.Builder
+	for _, c := range strings.ToUpper(input) {
+		if (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+			b.WriteRune(c)
+		}
+	}
+	kode := b.String()
+	if len(kode) == 10 {
+		kode = kode[:5] + "-" + kode[5:]
+	}
+	return kode
+}
+
+// Helper: samarkan nama pelanggan, "Budi Santoso" -> "B*** S***"
+func maskNama(nama string) string {
+	parts := strings.Fields(nama)
+	for i, p := range parts {
+		r := []rune(p)
+		parts[i] = string(r[0]) + "***"
+	}
+	return strings.Join(parts, " ")
+}
+
+// Helper: ambil tampilan tracking servis berdasarkan kode (dan nomor HP jika diisi)
+func loadTracking(kode, noWhatsapp string) (models.TrackingServis, int, error) {
+	var t models.TrackingServis
+	var idServis int
+	var tglSelesai sql.NullString
+
+	query := `
+		SELECT id_servis, kode_tracking, nama_pelanggan, tipe_hp, status_servis,
+			biaya_total, tanggal_masuk, tanggal_selesai, id_servis_asal IS NOT NULL,
+			COALESCE((SELECT ` + statusPenawaranSQL + ` FROM penawaran pw
+				WHERE pw.id_servis = servis.id_servis ORDER BY pw.id_penawaran DESC LIMIT 1), '')
+		FROM servis
+		WHERE kode_tracking = ?
+	`
+	args := []interface{}{kode}
+	if noWhatsapp != "" {
+		query += " AND no_whatsapp = ?"
+		args = append(args, noWhatsapp)
+	}
+
+	err := database.DB.QueryRow(query, args...).Scan(
+		&idServis, &t.KodeTracking, &t.NamaPelanggan, &t.TipeHP, &t.StatusServis,
+		&t.EstimasiBiaya, &t.TanggalMasuk, &tglSelesai, &t.KlaimGaransi, &t.StatusPenawaran,
+	)
+	if err != nil {
+		return t, 0, err
+	}
+
+	t.NamaPelanggan = maskNama(t.NamaPelanggan)
+	if tglSelesai.Valid {
+		t.TanggalSelesai = &tglSelesai.String
+	}
+
+	t.Garansi, err = loadGaransi(database.DB, idServis)
+	if err != nil {
+		return t, idServis, err
+	}
+
+	t.Timeline = []models.TrackingStatus{}
+	rows, err := database.DB.Query(`
+		SELECT status_baru, created_at
+		FROM servis_status_history
+		WHERE id_servis = ?
+		ORDER BY id_history ASC
+	`, idServis)
+	if err != nil {
+		return t, idServis, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s models.TrackingStatus
+		if err := rows.Scan(&s.Status, &s.Waktu); err == nil {
+			t.Timeline = append(t.Timeline, s)
+		}
+	}
+	return t, idServis, rows.Err()
+}
+
+// =======================================================
+// TRACK SERVIS (PUBLIC - GET /api/track/{kode})
+// =======================================================
+func TrackServis(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	kode := normalizeKodeTracking(strings.TrimPrefix(r.URL.Path, "/api/track/"))
+	if kode == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Kode tracking harus diisi"})
+		return
+	}
+
+	t, _, err := loadTracking(kode, "")
+	if err == sql.ErrNoRows {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Servis tidak ditemukan"})
+		return
+	} else if err != nil {
+		log.Println(" Error tracking servis:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal mengambil data servis"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(t)
+}
@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"service_hp/audit"
+	"service_hp/database"
+	"service_hp/models"
+	"strings"
+	"time"
+)
+
+// Masa berlaku default kode undangan jika admin tidak mengisi berlaku_jam
+const defaultBerlakuUndangan = 72
+
+// Helper: kode undangan memakai format yang sama dengan kode tracking (XXXXX-XXXXX)
+func normalizeKodeUndangan(input string) string {
+	return normalizeKodeTracking(input)
+}
+
+// =======================================================
+// BUAT KODE UNDANGAN (POST /api/admin/undangan)
+// Kode hanya dikembalikan sekali, yang disimpan hanya hash-nya
+// =======================================================
+func CreateUndangan(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req models.BuatUndanganRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	jabatan, ok := normalizeJabatan(req.Jabatan)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Jabatan harus Kasir atau Teknisi"})
+		return
+	}
+	if req.BerlakuJam <= 0 {
+		req.BerlakuJam = defaultBerlakuUndangan
+	}
+	if req.BerlakuJam > 24*30 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Masa berlaku maksimal 30 hari"})
+		return
+	}
+
+	kode, err := generateKodeTracking()
+	if err != nil {
+		log.Println(" Error generate kode undangan:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Gagal membuat kode undangan"})
+		return
+	}
+
+	expiresAt := time.Now().Add(time.Duration(req.BerlakuJam) * time.Hour)
+	res, err := database.DB.Exec(`
+		INSERT INTO undangan (kode_hash, jabatan, keterangan, dibuat_oleh, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, hashToken(kode), jabatan, truncate(strings.TrimSpace(req.Keterangan), 255), currentUserID(r), expiresAt)
+	if err != nil {
+		log.Println(" Error insert undangan:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	id, _ := res.LastInsertId()
+	audit.Catat(database.DB, r, audit.AksiCreate, audit.EntitasUndangan, int(id), nil)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.Undangan{
+		IDUndangan: int(id),
+		Kode:       kode,
+		Jabatan:    jabatan,
+		Keterangan: req.Keterangan,
+		DibuatOleh: currentUserID(r),
+		ExpiresAt:  expiresAt,
+		CreatedAt:  time.Now(),
+		Status:     "aktif",
+	})
+}
+
+// =======================================================
+// DAFTAR UNDANGAN (GET /api/admin/undangan)
+// =======================================================
+func GetAllUndangan(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rows, err := database.DB.Query(`
+		SELECT id_undangan, jabatan, COALESCE(keterangan, ''), dibuat_oleh, expires_at,
+			dipakai_oleh, dipakai_at, dibatalkan_at, created_at,
+			CASE
+				WHEN dipakai_at IS NOT NULL THEN 'dipakai'
+				WHEN dibatalkan_at IS NOT NULL THEN 'dibatalkan'
+				WHEN expires_at <= NOW() THEN 'kedaluwarsa'
+				ELSE 'aktif'
+			END
+		FROM undangan
+		ORDER BY created_at DESC
+		LIMIT 200
+	`)
+	if err != nil {
+		log.Println(" Error query undangan:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	list := []models.Undangan{}
+	for rows.Next() {
+		var u models.Undangan
+		var dibuatOleh, dipakaiOleh sql.NullInt64
+		var dipakaiAt, dibatalkanAt sql.NullTime
+		if err := rows.Scan(&u.IDUndangan, &u.Jabatan, &u.Keterangan, &dibuatOleh, &u.ExpiresAt,
+			&dipakaiOleh, &dipakaiAt, &dibatalkanAt, &u.CreatedAt, &u.Status); err != nil {
+			log.Println(" Error scan undangan:", err)
+			continue
+		}
+		if dibuatOleh.Valid {
+			v := int(dibuatOleh.Int64)
+			u.DibuatOleh = &v
+		}
+		if dipakaiOleh.Valid {
+			v := int(dipakaiOleh.Int64)
+			u.DipakaiOleh = &v
+		}
+		if dipakaiAt.Valid {
+			u.DipakaiAt = &dipakaiAt.Time
+		}
+		if dibatalkanAt.Valid {
+			u.DibatalkanAt = &dibatalkanAt.Time
+		}
+		list = append(list, u)
+	}
+
+	json.NewEncoder(w).Encode(list)
+}
+
+// =======================================================
+// BATALKAN UNDANGAN (DELETE /api/admin/undangan/{id})
+// =======================================================
+func CancelUndangan(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractLastID(r.URL.Path)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	res, err := database.DB.Exec(`
+		UPDATE undangan SET dibatalkan_at = NOW()
+		WHERE id_undangan = ? AND dipakai_at IS NULL AND dibatalkan_at IS NULL
+	`, id)
+	if err != nil {
+		log.Println(" Error batalkan undangan:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Undangan tidak ditemukan atau sudah dipakai/dibatalkan"})
+		return
+	}
+
+	audit.Log(database.DB, r, audit.Entry{Aksi: "undangan.batal", Entitas: audit.EntitasUndangan, IDEntitas: id})
+	json.NewEncoder(w).Encode(map[string]string{"message": "Undangan dibatalkan"})
+}
+
+// =======================================================
+// TOLAK PENDAFTARAN (DELETE /api/admin/pendaftaran/{id})
+// Hanya untuk akun signup yang belum dijadikan pegawai
+// =======================================================
+func RejectPendaftaran(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := extractLastID(r.URL.Path)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid ID"})
+		return
+	}
+
+	sebelum, _ := audit.Snapshot(database.DB, audit.EntitasUser, id)
+
+	res, err := database.DB.Exec(`
+		DELETE FROM user
+		WHERE id_user = ? AND role = 'pegawai'
+			AND NOT EXISTS (SELECT 1 FROM pegawai p WHERE p.id_user = user.id_user)
+	`, id)
+	if err != nil {
+		log.Println(" Error tolak pendaftaran:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Pendaftaran tidak ditemukan"})
+		return
+	}
+
+	audit.Log(database.DB, r, audit.Entry{Aksi: "pendaftaran.tolak", Entitas: audit.EntitasUser, IDEntitas: id, Sebelum: sebelum})
+	json.NewEncoder(w).Encode(map[string]string{"message": "Pendaftaran ditolak"})
+}
@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Aturan akun yang sama untuk signup, reset password oleh admin, dan ganti password sendiri
+
+const minPanjangPassword = 8
+
+var usernamePattern = regexp.MustCompile(`^[a-z0-9._-]{3,50}$`)
+
+// validateUsername - huruf kecil, angka, titik, garis bawah, strip; 3-50 karakter
+func validateUsername(username string) error {
+	if !usernamePattern.MatchString(username) {
+		return errors.New("Username 3-50 karakter, hanya huruf kecil, angka, titik, _ atau -")
+	}
+	return nil
+}
+
+// validatePassword - minimal 8 karakter, ada huruf & angka, tidak sama dengan username
+func validatePassword(password, username string) error {
+	if len(password) < minPanjangPassword {
+		return errors.New("Password minimal 8 karakter")
+	}
+	if len(password) > 72 {
+		// bcrypt hanya memakai 72 byte pertama
+		return errors.New("Password maksimal 72 karakter")
+	}
+
+	var huruf, angka bool
+	for _, c := range password {
+		switch {
+		case unicode.IsLetter(c):
+			huruf = true
+		case unicode.IsDigit(c):
+			angka = true
+		}
+	}
+	if !huruf || !angka {
+		return errors.New("Password harus mengandung huruf dan angka")
+	}
+	if username != "" && strings.EqualFold(password, username) {
+		return errors.New("Password tidak boleh sama dengan username")
+	}
+	return nil
+}
+
+// validateNama - nama lengkap wajib diisi
+func validateNama(nama string) error {
+	if n := len(strings.TrimSpace(nama)); n == 0 || n > 100 {
+		return errors.New("Nama wajib diisi (maksimal 100 karakter)")
+	}
+	return nil
+}
+
+func hashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hashed), err
+}
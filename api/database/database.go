@@ -4,30 +4,29 @@ import (
     "database/sql"
     "fmt"
     "log"
+    "service_hp/config"
 
     _ "github.com/go-sql-driver/mysql"
 )
 
 var DB *sql.DB
 
-func Connect() {
-    username := "root"
-    password := ""              // default Laragon password kosong
-    host := "localhost"
-    port := "3306"
-    database := "service_hp"
-
-    dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true",
-        username, password, host, port, database,
-    )
+// Open membuka koneksi ke MySQL tanpa memeriksa versi skema (dipakai subcommand migrate)
+func Open() {
+    cfg := config.Cfg.Database
 
     var err error
 
-    DB, err = sql.Open("mysql", dsn)
+    DB, err = sql.Open("mysql", cfg.DSN())
     if err != nil {
         log.Fatal("Gagal membuka koneksi database:", err)
     }
 
+    DB.SetMaxOpenConns(cfg.MaxOpenConns)
+    DB.SetMaxIdleConns(cfg.MaxIdleConns)
+    DB.SetConnMaxLifetime(cfg.ConnMaxLifetime.Duration)
+    DB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime.Duration)
+
     err = DB.Ping()
     if err != nil {
         log.Fatal("Tidak dapat terhubung ke database:", err)
@@ -35,3 +34,12 @@ func Connect() {
 
     fmt.Println("Database MySQL berhasil terhubung!")
 }
+
+// Connect membuka koneksi dan menolak jalan jika skema database belum terbaru
+func Connect() {
+    Open()
+
+    if err := checkSchema(DB); err != nil {
+        log.Fatal(err)
+    }
+}
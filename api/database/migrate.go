@@ -0,0 +1,266 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+// Migration - satu versi skema dari folder migrations (NNNN_nama.up.sql + NNNN_nama.down.sql)
+type Migration struct {
+	Version int
+	Nama    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus - status satu migration di database
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// loadMigrations membaca semua migration yang di-embed, urut berdasarkan versi
+func loadMigrations() ([]Migration, error) {
+	return parseMigrations(migrationFS)
+}
+
+// parseMigrations membaca pasangan file up/down dari folder migrations di fsys
+func parseMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, f := range files {
+		base := strings.TrimPrefix(f, "migrations/")
+
+		var arah string
+		switch {
+		case strings.HasSuffix(base, ".up.sql"):
+			arah = "up"
+		case strings.HasSuffix(base, ".down.sql"):
+			arah = "down"
+		default:
+			return nil, fmt.Errorf("nama file migration tidak valid: %s", base)
+		}
+
+		nama := strings.TrimSuffix(base, "."+arah+".sql")
+		parts := strings.SplitN(nama, "_", 2)
+		version, err := strconv.Atoi(parts[0])
+		if err != nil || len(parts) != 2 {
+			return nil, fmt.Errorf("nama file migration tidak valid: %s", base)
+		}
+
+		isi, err := fs.ReadFile(fsys, f)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Nama: parts[1]}
+			byVersion[version] = m
+		}
+		if arah == "up" {
+			m.Up = string(isi)
+		} else {
+			m.Down = string(isi)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s harus punya file up dan down", m.Version, m.Nama)
+		}
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// splitStatements memecah isi file SQL per statement (diakhiri ';' di akhir baris).
+// Driver MySQL tidak menjalankan banyak statement dalam satu Exec.
+func splitStatements(isi string) []string {
+	var stmts []string
+	var cur strings.Builder
+	for _, line := range strings.Split(isi, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		cur.WriteString(line)
+		cur.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSpace(cur.String()))
+			cur.Reset()
+		}
+	}
+	if s := strings.TrimSpace(cur.String()); s != "" {
+		stmts = append(stmts, s)
+	}
+	return stmts
+}
+
+func ensureMigrationTable(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INT PRIMARY KEY,
+			nama       VARCHAR(100) NOT NULL,
+			applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	return err
+}
+
+// MigrationStatuses menggabungkan daftar migration dengan yang sudah tercatat di database
+func MigrationStatuses(db *sql.DB) ([]MigrationStatus, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureMigrationTable(db); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var v int
+		var t time.Time
+		if err := rows.Scan(&v, &t); err != nil {
+			return nil, err
+		}
+		applied[v] = t
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	list := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		s := MigrationStatus{Migration: m}
+		if t, ok := applied[m.Version]; ok {
+			s.AppliedAt = &t
+		}
+		list = append(list, s)
+	}
+	return list, nil
+}
+
+// MigrateUp menjalankan semua migration yang belum diterapkan, berurutan.
+// DDL MySQL tidak bisa di-rollback, jadi migration yang gagal di tengah harus dibereskan manual.
+func MigrateUp(db *sql.DB) ([]Migration, error) {
+	statuses, err := MigrationStatuses(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, s := range statuses {
+		if s.AppliedAt != nil {
+			continue
+		}
+		for _, stmt := range splitStatements(s.Up) {
+			if _, err := db.Exec(stmt); err != nil {
+				return done, fmt.Errorf("migration %04d_%s gagal: %w", s.Version, s.Nama, err)
+			}
+		}
+		if _, err := db.Exec(`INSERT INTO schema_migrations (version, nama) VALUES (?, ?)`, s.Version, s.Nama); err != nil {
+			return done, err
+		}
+		done = append(done, s.Migration)
+	}
+	return done, nil
+}
+
+// MigrateDown membatalkan n migration terakhir yang sudah diterapkan
+func MigrateDown(db *sql.DB, n int) ([]Migration, error) {
+	statuses, err := MigrationStatuses(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(statuses) - 1; i >= 0 && len(done) < n; i-- {
+		s := statuses[i]
+		if s.AppliedAt == nil {
+			continue
+		}
+		for _, stmt := range splitStatements(s.Down) {
+			if _, err := db.Exec(stmt); err != nil {
+				return done, fmt.Errorf("rollback %04d_%s gagal: %w", s.Version, s.Nama, err)
+			}
+		}
+		if _, err := db.Exec(`DELETE FROM schema_migrations WHERE version = ?`, s.Version); err != nil {
+			return done, err
+		}
+		done = append(done, s.Migration)
+	}
+	return done, nil
+}
+
+// MigrateBaseline mencatat migration sampai versi tertentu sebagai sudah diterapkan tanpa
+// menjalankannya. Untuk database yang tabelnya dibuat manual dari dump awal (sebelum
+// ada migration), agar migrate up tidak menjalankan ulang perubahan yang sudah ada.
+func MigrateBaseline(db *sql.DB, version int) ([]Migration, error) {
+	statuses, err := MigrationStatuses(db)
+	if err != nil {
+		return nil, err
+	}
+
+	ada := false
+	for _, s := range statuses {
+		ada = ada || s.Version == version
+	}
+	if !ada {
+		return nil, fmt.Errorf("migration versi %04d tidak ada", version)
+	}
+
+	var done []Migration
+	for _, s := range statuses {
+		if s.Version > version || s.AppliedAt != nil {
+			continue
+		}
+		if _, err := db.Exec(`INSERT INTO schema_migrations (version, nama) VALUES (?, ?)`, s.Version, s.Nama); err != nil {
+			return done, err
+		}
+		done = append(done, s.Migration)
+	}
+	return done, nil
+}
+
+// checkSchema gagal jika masih ada migration yang belum diterapkan
+func checkSchema(db *sql.DB) error {
+	statuses, err := MigrationStatuses(db)
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			pending = append(pending, fmt.Sprintf("%04d_%s", s.Version, s.Nama))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("skema database belum terbaru, migration belum diterapkan: %s (jalankan: service_hp migrate up)",
+			strings.Join(pending, ", "))
+	}
+	return nil
+}
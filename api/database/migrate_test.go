@@ -0,0 +1,93 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		nama string
+		isi  string
+		want []string
+	}{
+		{"kosong", "", nil},
+		{"hanya komentar", "-- komentar\n\n-- lagi\n", nil},
+		{"satu statement", "DROP TABLE x;\n", []string{"DROP TABLE x;"}},
+		{
+			"banyak baris dan komentar",
+			"-- tabel baru\nCREATE TABLE x (\n    id INT\n);\n\nALTER TABLE y\n    ADD COLUMN z INT;\n",
+			[]string{"CREATE TABLE x (\n    id INT\n);", "ALTER TABLE y\n    ADD COLUMN z INT;"},
+		},
+		{"tanpa titik koma di akhir", "DELETE FROM x;\nDROP TABLE y", []string{"DELETE FROM x;", "DROP TABLE y"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := splitStatements(tt.isi); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements = %q, seharusnya %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMigrations(t *testing.T) {
+	file := func(isi string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(isi)} }
+
+	tests := []struct {
+		nama  string
+		fsys  fstest.MapFS
+		versi []int
+		gagal bool
+	}{
+		{
+			"urut berdasarkan versi",
+			fstest.MapFS{
+				"migrations/0002_b.up.sql":   file("B;"),
+				"migrations/0002_b.down.sql": file("-B;"),
+				"migrations/0001_a.up.sql":   file("A;"),
+				"migrations/0001_a.down.sql": file("-A;"),
+			},
+			[]int{1, 2},
+			false,
+		},
+		{"tanpa file down", fstest.MapFS{"migrations/0001_a.up.sql": file("A;")}, nil, true},
+		{"tanpa file up", fstest.MapFS{"migrations/0001_a.down.sql": file("-A;")}, nil, true},
+		{"versi bukan angka", fstest.MapFS{"migrations/abc_a.up.sql": file("A;")}, nil, true},
+		{"tanpa nama", fstest.MapFS{"migrations/0001.up.sql": file("A;")}, nil, true},
+		{"bukan up / down", fstest.MapFS{"migrations/0001_a.sql": file("A;")}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			list, err := parseMigrations(tt.fsys)
+			if (err != nil) != tt.gagal {
+				t.Fatalf("parseMigrations error = %v, seharusnya gagal = %v", err, tt.gagal)
+			}
+			var versi []int
+			for _, m := range list {
+				versi = append(versi, m.Version)
+			}
+			if !reflect.DeepEqual(versi, tt.versi) {
+				t.Errorf("versi = %v, seharusnya %v", versi, tt.versi)
+			}
+		})
+	}
+}
+
+// Migration yang di-embed harus berurutan tanpa lompatan dan tidak ada file kosong
+func TestMigrationEmbedded(t *testing.T) {
+	list, err := loadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range list {
+		if m.Version != i+1 {
+			t.Errorf("migration %04d_%s seharusnya versi %d", m.Version, m.Nama, i+1)
+		}
+		if len(splitStatements(m.Up)) == 0 || len(splitStatements(m.Down)) == 0 {
+			t.Errorf("migration %04d_%s punya file up / down tanpa statement", m.Version, m.Nama)
+		}
+	}
+}
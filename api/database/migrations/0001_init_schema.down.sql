@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS detail_laporan_servis;
+DROP TABLE IF EXISTS laporan;
+DROP TABLE IF EXISTS detail_servis;
+DROP TABLE IF EXISTS servis;
+DROP TABLE IF EXISTS barang;
+DROP TABLE IF EXISTS pegawai;
+DROP TABLE IF EXISTS user;
@@ -0,0 +1,87 @@
+-- Skema awal service_hp. IF NOT EXISTS agar database lama (Laragon) yang
+-- tabelnya sudah dibuat manual bisa langsung dicatat sebagai versi 1.
+CREATE TABLE IF NOT EXISTS user (
+    id_user  INT AUTO_INCREMENT PRIMARY KEY,
+    nama     VARCHAR(100) NOT NULL,
+    username VARCHAR(50) NOT NULL,
+    password VARCHAR(255) NOT NULL,
+    role     ENUM('admin', 'pegawai') NOT NULL DEFAULT 'pegawai',
+    UNIQUE KEY uq_user_username (username)
+);
+
+CREATE TABLE IF NOT EXISTS pegawai (
+    id_pegawai    INT AUTO_INCREMENT PRIMARY KEY,
+    id_user       INT NOT NULL,
+    nama_pegawai  VARCHAR(100) NOT NULL,
+    jabatan       VARCHAR(50) NOT NULL,
+    alamat        TEXT NULL,
+    no_hp         VARCHAR(20) NULL,
+    tanggal_masuk DATE NULL,
+    status        ENUM('aktif', 'nonaktif') NOT NULL DEFAULT 'aktif',
+    UNIQUE KEY uq_pegawai_user (id_user),
+    CONSTRAINT fk_pegawai_user FOREIGN KEY (id_user) REFERENCES user (id_user) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS barang (
+    id_barang   INT AUTO_INCREMENT PRIMARY KEY,
+    nama_barang VARCHAR(100) NOT NULL,
+    stok        INT NOT NULL DEFAULT 0,
+    harga       DECIMAL(15,2) NOT NULL DEFAULT 0,
+    harga_modal DECIMAL(15,2) NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS servis (
+    id_servis       INT AUTO_INCREMENT PRIMARY KEY,
+    nama_pelanggan  VARCHAR(100) NOT NULL,
+    no_whatsapp     VARCHAR(20) NOT NULL,
+    tipe_hp         VARCHAR(100) NOT NULL,
+    keluhan         TEXT NULL,
+    status_servis   ENUM('pending', 'dalam_perbaikan', 'selesai', 'siap_diambil') NOT NULL DEFAULT 'pending',
+    biaya_servis    DECIMAL(15,2) NOT NULL DEFAULT 0,
+    biaya_total     DECIMAL(15,2) NOT NULL DEFAULT 0,
+    tanggal_masuk   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    tanggal_selesai DATETIME NULL,
+    INDEX idx_servis_tanggal_masuk (tanggal_masuk),
+    INDEX idx_servis_status (status_servis)
+);
+
+CREATE TABLE IF NOT EXISTS detail_servis (
+    id_detail    INT AUTO_INCREMENT PRIMARY KEY,
+    id_servis    INT NOT NULL,
+    id_barang    INT NULL,
+    deskripsi    VARCHAR(255) NOT NULL,
+    jumlah       INT NOT NULL DEFAULT 1,
+    harga_satuan DECIMAL(15,2) NOT NULL DEFAULT 0,
+    biaya        DECIMAL(15,2) NOT NULL DEFAULT 0,
+    CONSTRAINT fk_detail_servis_servis FOREIGN KEY (id_servis) REFERENCES servis (id_servis) ON DELETE CASCADE,
+    CONSTRAINT fk_detail_servis_barang FOREIGN KEY (id_barang) REFERENCES barang (id_barang) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS laporan (
+    id_laporan       INT AUTO_INCREMENT PRIMARY KEY,
+    judul_laporan    VARCHAR(150) NOT NULL,
+    jenis_laporan    VARCHAR(30) NOT NULL,
+    tanggal_awal     DATE NOT NULL,
+    tanggal_akhir    DATE NOT NULL,
+    total_servis     INT NOT NULL DEFAULT 0,
+    total_pendapatan DECIMAL(15,2) NOT NULL DEFAULT 0,
+    total_modal      DECIMAL(15,2) NOT NULL DEFAULT 0,
+    laba_bersih      DECIMAL(15,2) NOT NULL DEFAULT 0,
+    keterangan       TEXT NULL,
+    created_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Snapshot servis per laporan; id_servis sengaja tanpa FK agar laporan tetap utuh saat servis dihapus
+CREATE TABLE IF NOT EXISTS detail_laporan_servis (
+    id_detail      INT AUTO_INCREMENT PRIMARY KEY,
+    id_laporan     INT NOT NULL,
+    id_servis      INT NOT NULL,
+    nama_pelanggan VARCHAR(100) NOT NULL,
+    tipe_hp        VARCHAR(100) NOT NULL,
+    status_servis  ENUM('pending', 'dalam_perbaikan', 'selesai', 'siap_diambil') NOT NULL DEFAULT 'pending',
+    biaya_total    DECIMAL(15,2) NOT NULL DEFAULT 0,
+    modal_servis   DECIMAL(15,2) NOT NULL DEFAULT 0,
+    laba_servis    DECIMAL(15,2) NOT NULL DEFAULT 0,
+    INDEX idx_detail_laporan_laporan (id_laporan),
+    CONSTRAINT fk_detail_laporan_laporan FOREIGN KEY (id_laporan) REFERENCES laporan (id_laporan) ON DELETE CASCADE
+);
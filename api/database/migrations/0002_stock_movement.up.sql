@@ -0,0 +1,21 @@
+-- Kartu stok: setiap perubahan barang.stok dicatat di sini
+CREATE TABLE stock_movement (
+    id_movement INT AUTO_INCREMENT PRIMARY KEY,
+    id_barang   INT NOT NULL,
+    jenis       ENUM('pembelian', 'pemakaian_servis', 'penyesuaian', 'retur', 'stock_opname') NOT NULL,
+    jumlah      INT NOT NULL,
+    id_servis   INT NULL,
+    id_detail   INT NULL,
+    id_user     INT NULL,
+    keterangan  VARCHAR(255) NULL,
+    created_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_stock_movement_barang (id_barang, id_movement),
+    INDEX idx_stock_movement_servis (id_servis),
+    CONSTRAINT fk_stock_movement_barang FOREIGN KEY (id_barang) REFERENCES barang (id_barang) ON DELETE CASCADE
+);
+
+-- Saldo awal agar stok yang sudah ada bisa direkonsiliasi dengan ledger
+INSERT INTO stock_movement (id_barang, jenis, jumlah, keterangan)
+SELECT id_barang, 'stock_opname', stok, 'Saldo awal kartu stok'
+FROM barang
+WHERE stok <> 0;
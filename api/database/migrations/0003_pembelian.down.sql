@@ -0,0 +1,10 @@
+ALTER TABLE laporan
+    DROP COLUMN total_pembelian;
+
+ALTER TABLE stock_movement
+    DROP INDEX idx_stock_movement_pembelian,
+    DROP COLUMN id_pembelian;
+
+DROP TABLE IF EXISTS detail_pembelian;
+DROP TABLE IF EXISTS pembelian;
+DROP TABLE IF EXISTS supplier;
@@ -0,0 +1,42 @@
+-- Supplier & pembelian (purchase order) untuk restock barang
+CREATE TABLE supplier (
+    id_supplier   INT AUTO_INCREMENT PRIMARY KEY,
+    nama_supplier VARCHAR(100) NOT NULL,
+    no_telepon    VARCHAR(20) NULL,
+    alamat        TEXT NULL,
+    keterangan    VARCHAR(255) NULL
+);
+
+CREATE TABLE pembelian (
+    id_pembelian      INT AUTO_INCREMENT PRIMARY KEY,
+    id_supplier       INT NOT NULL,
+    no_faktur         VARCHAR(50) NULL,
+    tanggal_pembelian DATE NOT NULL,
+    status            ENUM('draft', 'diterima', 'batal') NOT NULL DEFAULT 'draft',
+    total_pembelian   DECIMAL(15,2) NOT NULL DEFAULT 0,
+    keterangan        VARCHAR(255) NULL,
+    id_user           INT NULL,
+    tanggal_diterima  DATETIME NULL,
+    created_at        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_pembelian_diterima (status, tanggal_diterima),
+    CONSTRAINT fk_pembelian_supplier FOREIGN KEY (id_supplier) REFERENCES supplier (id_supplier)
+);
+
+CREATE TABLE detail_pembelian (
+    id_detail_pembelian INT AUTO_INCREMENT PRIMARY KEY,
+    id_pembelian        INT NOT NULL,
+    id_barang           INT NOT NULL,
+    jumlah              INT NOT NULL,
+    harga_beli          DECIMAL(15,2) NOT NULL,
+    subtotal            DECIMAL(15,2) NOT NULL,
+    INDEX idx_detail_pembelian_barang (id_barang),
+    CONSTRAINT fk_detail_pembelian_pembelian FOREIGN KEY (id_pembelian) REFERENCES pembelian (id_pembelian) ON DELETE CASCADE,
+    CONSTRAINT fk_detail_pembelian_barang FOREIGN KEY (id_barang) REFERENCES barang (id_barang)
+);
+
+ALTER TABLE stock_movement
+    ADD COLUMN id_pembelian INT NULL AFTER id_detail,
+    ADD INDEX idx_stock_movement_pembelian (id_pembelian);
+
+ALTER TABLE laporan
+    ADD COLUMN total_pembelian DECIMAL(15,2) NOT NULL DEFAULT 0 AFTER total_modal;
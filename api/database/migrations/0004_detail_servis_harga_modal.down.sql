@@ -0,0 +1,2 @@
+ALTER TABLE detail_servis
+    DROP COLUMN harga_modal;
@@ -0,0 +1,11 @@
+-- Snapshot harga modal per detail servis agar laba periode lalu tidak berubah
+-- saat barang.harga_modal diedit atau barang dihapus.
+-- Basis biaya: rata-rata tertimbang (barang.harga_modal, dihitung ulang saat pembelian diterima)
+-- yang dibekukan ke detail saat barang dipakai.
+ALTER TABLE detail_servis
+    ADD COLUMN harga_modal DECIMAL(15,2) NOT NULL DEFAULT 0 AFTER biaya;
+
+-- Data lama memakai harga_modal barang saat migrasi dijalankan
+UPDATE detail_servis ds
+JOIN barang b ON ds.id_barang = b.id_barang
+SET ds.harga_modal = COALESCE(b.harga_modal, 0);
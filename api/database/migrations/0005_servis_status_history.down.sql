@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS servis_status_history;
+
+-- Status baru dipetakan ke status lama terdekat sebelum enum dipersempit
+UPDATE servis SET status_servis = 'siap_diambil' WHERE status_servis = 'diambil';
+UPDATE servis SET status_servis = 'selesai' WHERE status_servis IN ('batal', 'tidak_bisa_diperbaiki');
+UPDATE detail_laporan_servis SET status_servis = 'siap_diambil' WHERE status_servis = 'diambil';
+UPDATE detail_laporan_servis SET status_servis = 'selesai' WHERE status_servis IN ('batal', 'tidak_bisa_diperbaiki');
+
+ALTER TABLE servis
+    MODIFY status_servis ENUM('pending', 'dalam_perbaikan', 'selesai', 'siap_diambil') NOT NULL DEFAULT 'pending';
+
+ALTER TABLE detail_laporan_servis
+    MODIFY status_servis ENUM('pending', 'dalam_perbaikan', 'selesai', 'siap_diambil') NOT NULL DEFAULT 'pending';
@@ -0,0 +1,26 @@
+-- Lifecycle servis: pending -> dalam_perbaikan -> selesai -> siap_diambil -> diambil
+-- plus batal / tidak_bisa_diperbaiki
+ALTER TABLE servis
+    MODIFY status_servis ENUM('pending', 'dalam_perbaikan', 'selesai', 'siap_diambil', 'diambil', 'batal', 'tidak_bisa_diperbaiki')
+        NOT NULL DEFAULT 'pending';
+
+ALTER TABLE detail_laporan_servis
+    MODIFY status_servis ENUM('pending', 'dalam_perbaikan', 'selesai', 'siap_diambil', 'diambil', 'batal', 'tidak_bisa_diperbaiki')
+        NOT NULL DEFAULT 'pending';
+
+CREATE TABLE servis_status_history (
+    id_history  INT AUTO_INCREMENT PRIMARY KEY,
+    id_servis   INT NOT NULL,
+    status_lama VARCHAR(30) NULL,
+    status_baru VARCHAR(30) NOT NULL,
+    id_user     INT NULL,
+    keterangan  VARCHAR(255) NULL,
+    created_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_status_history_servis (id_servis, id_history),
+    CONSTRAINT fk_status_history_servis FOREIGN KEY (id_servis) REFERENCES servis (id_servis) ON DELETE CASCADE
+);
+
+-- Riwayat awal untuk servis yang sudah ada
+INSERT INTO servis_status_history (id_servis, status_lama, status_baru, keterangan, created_at)
+SELECT id_servis, NULL, status_servis, 'Status sebelum riwayat dicatat', tanggal_masuk
+FROM servis;
@@ -0,0 +1,5 @@
+ALTER TABLE servis
+    DROP FOREIGN KEY fk_servis_pelanggan,
+    DROP COLUMN id_pelanggan;
+
+DROP TABLE IF EXISTS pelanggan;
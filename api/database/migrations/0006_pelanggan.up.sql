@@ -0,0 +1,19 @@
+-- Master data pelanggan, nomor WhatsApp disimpan dalam format 62xxxx
+CREATE TABLE pelanggan (
+    id_pelanggan   INT AUTO_INCREMENT PRIMARY KEY,
+    nama_pelanggan VARCHAR(100) NOT NULL,
+    no_whatsapp    VARCHAR(20) NOT NULL,
+    alamat         TEXT NULL,
+    catatan        VARCHAR(255) NULL,
+    created_at     DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_pelanggan_no_whatsapp (no_whatsapp),
+    INDEX idx_pelanggan_nama (nama_pelanggan)
+);
+
+-- nama_pelanggan & no_whatsapp di servis tetap disimpan sebagai salinan untuk laporan
+ALTER TABLE servis
+    ADD COLUMN id_pelanggan INT NULL AFTER id_servis,
+    ADD CONSTRAINT fk_servis_pelanggan FOREIGN KEY (id_pelanggan) REFERENCES pelanggan (id_pelanggan);
+
+-- Servis lama dihubungkan lewat POST /api/admin/pelanggan/sinkron
+-- karena normalisasi nomor dilakukan di aplikasi.
@@ -0,0 +1,3 @@
+ALTER TABLE servis
+    DROP INDEX uq_servis_kode_tracking,
+    DROP COLUMN kode_tracking;
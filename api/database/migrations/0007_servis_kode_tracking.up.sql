@@ -0,0 +1,14 @@
+-- Kode tracking acak untuk cek status servis publik (dicetak di nota)
+ALTER TABLE servis
+    ADD COLUMN kode_tracking VARCHAR(16) NULL AFTER id_pelanggan;
+
+UPDATE servis
+SET kode_tracking = CONCAT(
+    SUBSTRING(UPPER(HEX(RANDOM_BYTES(5))), 1, 5), '-',
+    SUBSTRING(UPPER(HEX(RANDOM_BYTES(5))), 1, 5)
+)
+WHERE kode_tracking IS NULL;
+
+ALTER TABLE servis
+    MODIFY kode_tracking VARCHAR(16) NOT NULL,
+    ADD UNIQUE KEY uq_servis_kode_tracking (kode_tracking);
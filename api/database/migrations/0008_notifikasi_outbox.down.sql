@@ -0,0 +1 @@
+DROP TABLE IF EXISTS notifikasi_outbox;
@@ -0,0 +1,19 @@
+-- Outbox notifikasi WhatsApp pelanggan, dikirim oleh worker dengan retry
+CREATE TABLE IF NOT EXISTS notifikasi_outbox (
+    id_notifikasi INT AUTO_INCREMENT PRIMARY KEY,
+    id_servis INT NOT NULL,
+    jenis ENUM('diterima', 'dalam_perbaikan', 'siap_diambil', 'pengingat') NOT NULL,
+    tujuan VARCHAR(20) NOT NULL,
+    pesan TEXT NOT NULL,
+    status ENUM('pending', 'terkirim', 'gagal') NOT NULL DEFAULT 'pending',
+    percobaan INT NOT NULL DEFAULT 0,
+    error_terakhir VARCHAR(255) NULL,
+    gateway VARCHAR(30) NULL,
+    jadwal_kirim DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    terkirim_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY idx_notifikasi_antrian (status, jadwal_kirim),
+    KEY idx_notifikasi_servis (id_servis, jenis),
+    CONSTRAINT fk_notifikasi_servis FOREIGN KEY (id_servis)
+        REFERENCES servis (id_servis) ON DELETE CASCADE
+);
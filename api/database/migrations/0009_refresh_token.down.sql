@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS refresh_token;
+
+ALTER TABLE user
+    DROP COLUMN token_version;
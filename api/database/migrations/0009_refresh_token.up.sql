@@ -0,0 +1,22 @@
+-- token_version dinaikkan untuk mencabut semua access token user (ganti password, nonaktif)
+ALTER TABLE user
+    ADD COLUMN token_version INT NOT NULL DEFAULT 0;
+
+-- Refresh token disimpan sebagai hash SHA-256, dirotasi setiap dipakai.
+-- Satu family = satu sesi login; token lama yang dipakai ulang mencabut seluruh family.
+CREATE TABLE refresh_token (
+    id_token    INT AUTO_INCREMENT PRIMARY KEY,
+    id_user     INT NOT NULL,
+    token_hash  CHAR(64) NOT NULL,
+    family      CHAR(32) NOT NULL,
+    expires_at  DATETIME NOT NULL,
+    revoked_at  DATETIME NULL,
+    replaced_by INT NULL,
+    user_agent  VARCHAR(255) NULL,
+    ip_address  VARCHAR(45) NULL,
+    created_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_refresh_token_hash (token_hash),
+    INDEX idx_refresh_token_family (family),
+    INDEX idx_refresh_token_user (id_user, revoked_at),
+    CONSTRAINT fk_refresh_token_user FOREIGN KEY (id_user) REFERENCES user (id_user) ON DELETE CASCADE
+);
@@ -0,0 +1,16 @@
+-- Audit percobaan login & dasar penghitungan lockout per username / IP.
+-- dihitung = 0 setelah login berhasil atau dibuka admin, baris tetap disimpan untuk audit.
+CREATE TABLE login_attempt (
+    id_attempt INT AUTO_INCREMENT PRIMARY KEY,
+    username   VARCHAR(50) NOT NULL,
+    id_user    INT NULL,
+    ip_address VARCHAR(45) NOT NULL,
+    user_agent VARCHAR(255) NULL,
+    berhasil   TINYINT(1) NOT NULL DEFAULT 0,
+    alasan     VARCHAR(50) NULL,
+    dihitung   TINYINT(1) NOT NULL DEFAULT 1,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_login_attempt_username (username, berhasil, dihitung, created_at),
+    INDEX idx_login_attempt_ip (ip_address, berhasil, created_at),
+    INDEX idx_login_attempt_created (created_at)
+);
@@ -0,0 +1,16 @@
+-- Kode undangan sekali pakai untuk signup pegawai (mode SIGNUP_MODE=undangan)
+CREATE TABLE undangan (
+    id_undangan INT AUTO_INCREMENT PRIMARY KEY,
+    kode_hash   CHAR(64) NOT NULL,
+    jabatan     VARCHAR(50) NOT NULL,
+    keterangan  VARCHAR(255) NULL,
+    dibuat_oleh INT NULL,
+    expires_at  DATETIME NOT NULL,
+    dipakai_oleh INT NULL,
+    dipakai_at  DATETIME NULL,
+    dibatalkan_at DATETIME NULL,
+    created_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_undangan_kode (kode_hash),
+    CONSTRAINT fk_undangan_pembuat FOREIGN KEY (dibuat_oleh) REFERENCES user (id_user) ON DELETE SET NULL,
+    CONSTRAINT fk_undangan_pemakai FOREIGN KEY (dipakai_oleh) REFERENCES user (id_user) ON DELETE SET NULL
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS password_reset;
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,32 @@
+-- Jejak audit perubahan data: siapa, aksi apa, pada entitas apa, data sebelum/sesudah
+CREATE TABLE audit_log (
+    id_audit     BIGINT AUTO_INCREMENT PRIMARY KEY,
+    id_user      INT NULL,
+    aksi         VARCHAR(50) NOT NULL,
+    entitas      VARCHAR(50) NOT NULL,
+    id_entitas   INT NULL,
+    data_sebelum JSON NULL,
+    data_sesudah JSON NULL,
+    ip_address   VARCHAR(45) NULL,
+    user_agent   VARCHAR(255) NULL,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_audit_entitas (entitas, id_entitas, created_at),
+    INDEX idx_audit_user (id_user, created_at),
+    INDEX idx_audit_created (created_at),
+    CONSTRAINT fk_audit_user FOREIGN KEY (id_user) REFERENCES user (id_user) ON DELETE SET NULL
+);
+
+-- Token reset password sekali pakai yang dibuat admin (hanya hash yang disimpan)
+CREATE TABLE password_reset (
+    id_reset    INT AUTO_INCREMENT PRIMARY KEY,
+    id_user     INT NOT NULL,
+    token_hash  CHAR(64) NOT NULL,
+    dibuat_oleh INT NULL,
+    expires_at  DATETIME NOT NULL,
+    dipakai_at  DATETIME NULL,
+    created_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_password_reset_token (token_hash),
+    INDEX idx_password_reset_user (id_user),
+    CONSTRAINT fk_password_reset_user FOREIGN KEY (id_user) REFERENCES user (id_user) ON DELETE CASCADE,
+    CONSTRAINT fk_password_reset_pembuat FOREIGN KEY (dibuat_oleh) REFERENCES user (id_user) ON DELETE SET NULL
+);
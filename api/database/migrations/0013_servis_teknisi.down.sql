@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS servis_teknisi_history;
+
+ALTER TABLE servis
+    DROP FOREIGN KEY fk_servis_teknisi,
+    DROP INDEX idx_servis_teknisi_status,
+    DROP COLUMN id_teknisi;
@@ -0,0 +1,18 @@
+-- Teknisi yang menangani servis, hanya pegawai aktif berjabatan teknisi (dicek di aplikasi)
+ALTER TABLE servis
+    ADD COLUMN id_teknisi INT NULL AFTER id_pelanggan,
+    ADD INDEX idx_servis_teknisi_status (id_teknisi, status_servis),
+    ADD CONSTRAINT fk_servis_teknisi FOREIGN KEY (id_teknisi) REFERENCES pegawai (id_pegawai) ON DELETE SET NULL;
+
+-- Riwayat penugasan & pengalihan teknisi
+CREATE TABLE servis_teknisi_history (
+    id_history      INT AUTO_INCREMENT PRIMARY KEY,
+    id_servis       INT NOT NULL,
+    id_teknisi_lama INT NULL,
+    id_teknisi_baru INT NULL,
+    id_user         INT NULL,
+    keterangan      VARCHAR(255) NULL,
+    created_at      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_teknisi_history_servis (id_servis, id_history),
+    CONSTRAINT fk_teknisi_history_servis FOREIGN KEY (id_servis) REFERENCES servis (id_servis) ON DELETE CASCADE
+);
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS detail_laporan_komisi;
+
+ALTER TABLE laporan
+    DROP COLUMN total_komisi;
+
+DROP TABLE IF EXISTS komisi_detail;
+DROP TABLE IF EXISTS komisi_periode;
+DROP TABLE IF EXISTS komisi_aturan;
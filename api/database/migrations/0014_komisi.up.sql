@@ -0,0 +1,55 @@
+-- Aturan komisi per teknisi: persen biaya_servis, nominal tetap per servis, atau persen laba
+CREATE TABLE komisi_aturan (
+    id_pegawai INT PRIMARY KEY,
+    jenis      ENUM('persen_biaya', 'tetap', 'persen_laba') NOT NULL,
+    nilai      DECIMAL(15,2) NOT NULL,
+    id_user    INT NULL,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_komisi_aturan_pegawai FOREIGN KEY (id_pegawai) REFERENCES pegawai (id_pegawai) ON DELETE CASCADE
+);
+
+-- Periode komisi yang sudah dikunci (dibayar). Nilai disalin ke komisi_detail
+-- supaya perubahan servis / aturan sesudahnya tidak mengubah yang sudah dibayar.
+CREATE TABLE komisi_periode (
+    id_periode    INT AUTO_INCREMENT PRIMARY KEY,
+    tanggal_awal  DATE NOT NULL,
+    tanggal_akhir DATE NOT NULL,
+    total_komisi  DECIMAL(15,2) NOT NULL DEFAULT 0,
+    keterangan    VARCHAR(255) NULL,
+    id_user       INT NULL,
+    dikunci_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_komisi_periode_tanggal (tanggal_awal, tanggal_akhir)
+);
+
+-- id_pegawai & id_servis sengaja tanpa FK agar periode yang dikunci tetap utuh
+CREATE TABLE komisi_detail (
+    id_komisi_detail INT AUTO_INCREMENT PRIMARY KEY,
+    id_periode       INT NOT NULL,
+    id_servis        INT NOT NULL,
+    id_pegawai       INT NOT NULL,
+    nama_teknisi     VARCHAR(100) NOT NULL,
+    tanggal_selesai  DATETIME NULL,
+    biaya_servis     DECIMAL(15,2) NOT NULL DEFAULT 0,
+    laba             DECIMAL(15,2) NOT NULL DEFAULT 0,
+    jenis            VARCHAR(20) NULL,
+    nilai            DECIMAL(15,2) NOT NULL DEFAULT 0,
+    komisi           DECIMAL(15,2) NOT NULL DEFAULT 0,
+    UNIQUE KEY uq_komisi_detail_servis (id_servis),
+    INDEX idx_komisi_detail_periode (id_periode, id_pegawai),
+    CONSTRAINT fk_komisi_detail_periode FOREIGN KEY (id_periode) REFERENCES komisi_periode (id_periode) ON DELETE CASCADE
+);
+
+-- Rekap komisi per teknisi di laporan
+ALTER TABLE laporan
+    ADD COLUMN total_komisi DECIMAL(15,2) NOT NULL DEFAULT 0 AFTER total_pembelian;
+
+CREATE TABLE detail_laporan_komisi (
+    id_detail     INT AUTO_INCREMENT PRIMARY KEY,
+    id_laporan    INT NOT NULL,
+    id_pegawai    INT NOT NULL,
+    nama_teknisi  VARCHAR(100) NOT NULL,
+    jumlah_servis INT NOT NULL DEFAULT 0,
+    total_komisi  DECIMAL(15,2) NOT NULL DEFAULT 0,
+    INDEX idx_detail_laporan_komisi_laporan (id_laporan),
+    CONSTRAINT fk_detail_laporan_komisi_laporan FOREIGN KEY (id_laporan) REFERENCES laporan (id_laporan) ON DELETE CASCADE
+);
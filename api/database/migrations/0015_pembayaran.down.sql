@@ -0,0 +1,5 @@
+ALTER TABLE laporan
+    DROP COLUMN total_piutang,
+    DROP COLUMN total_diterima;
+
+DROP TABLE IF EXISTS pembayaran;
@@ -0,0 +1,22 @@
+-- Pembayaran servis: DP saat diterima, pelunasan saat diambil, refund.
+-- jumlah selalu positif, refund mengurangi total dibayar.
+-- Tanpa ON DELETE agar servis yang sudah dibayar tidak bisa terhapus bersama jejak uangnya.
+CREATE TABLE pembayaran (
+    id_pembayaran INT AUTO_INCREMENT PRIMARY KEY,
+    id_servis     INT NOT NULL,
+    jenis         ENUM('dp', 'pelunasan', 'refund') NOT NULL,
+    metode        ENUM('tunai', 'transfer', 'qris') NOT NULL,
+    jumlah        DECIMAL(15,2) NOT NULL,
+    referensi     VARCHAR(100) NULL,
+    keterangan    VARCHAR(255) NULL,
+    id_user       INT NULL,
+    tanggal       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_pembayaran_servis (id_servis),
+    INDEX idx_pembayaran_tanggal (tanggal),
+    CONSTRAINT fk_pembayaran_servis FOREIGN KEY (id_servis) REFERENCES servis (id_servis)
+);
+
+-- Pendapatan ditagih (biaya_total) vs uang yang benar-benar diterima
+ALTER TABLE laporan
+    ADD COLUMN total_diterima DECIMAL(15,2) NOT NULL DEFAULT 0 AFTER total_pendapatan,
+    ADD COLUMN total_piutang DECIMAL(15,2) NOT NULL DEFAULT 0 AFTER total_diterima;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS nota;
+DROP TABLE IF EXISTS nota_urut;
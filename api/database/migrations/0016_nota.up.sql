@@ -0,0 +1,21 @@
+-- Penomoran nota per periode (tahun/bulan). Nomor diambil dalam transaksi yang sama
+-- dengan penyimpanan nota, sehingga rollback ikut mengembalikan nomor dan tidak ada lompatan.
+CREATE TABLE nota_urut (
+    periode  CHAR(7) PRIMARY KEY,
+    terakhir INT NOT NULL
+);
+
+-- Nota terbit: salinan beku isi nota + PDF A4 aslinya, satu nota per servis.
+-- Tanpa ON DELETE agar servis yang sudah bernota tidak bisa terhapus.
+CREATE TABLE nota (
+    id_nota    INT AUTO_INCREMENT PRIMARY KEY,
+    id_servis  INT NOT NULL,
+    nomor      VARCHAR(30) NOT NULL,
+    data       JSON NOT NULL,
+    pdf        MEDIUMBLOB NOT NULL,
+    id_user    INT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_nota_servis (id_servis),
+    UNIQUE KEY uq_nota_nomor (nomor),
+    CONSTRAINT fk_nota_servis FOREIGN KEY (id_servis) REFERENCES servis (id_servis)
+);
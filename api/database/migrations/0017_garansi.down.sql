@@ -0,0 +1,18 @@
+DROP TABLE IF EXISTS detail_laporan_garansi;
+
+ALTER TABLE detail_laporan_servis
+    DROP COLUMN id_servis_asal;
+
+ALTER TABLE laporan
+    DROP COLUMN total_biaya_garansi,
+    DROP COLUMN total_klaim_garansi;
+
+ALTER TABLE servis
+    DROP FOREIGN KEY fk_servis_asal,
+    DROP COLUMN id_servis_asal;
+
+ALTER TABLE detail_servis
+    DROP COLUMN garansi_hari;
+
+ALTER TABLE barang
+    DROP COLUMN garansi_hari;
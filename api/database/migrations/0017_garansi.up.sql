@@ -0,0 +1,34 @@
+-- Garansi per barang (hari), jadi default garansi baris detail servis yang memakai barang tsb.
+ALTER TABLE barang
+    ADD COLUMN garansi_hari INT NOT NULL DEFAULT 0 AFTER harga_modal;
+
+-- Garansi per baris detail, dihitung sejak tanggal_selesai servis
+ALTER TABLE detail_servis
+    ADD COLUMN garansi_hari INT NOT NULL DEFAULT 0 AFTER harga_modal;
+
+-- Klaim garansi = servis baru yang menunjuk servis asalnya.
+-- Tanpa ON DELETE agar servis yang pernah diklaim tidak bisa terhapus.
+ALTER TABLE servis
+    ADD COLUMN id_servis_asal INT NULL AFTER id_teknisi,
+    ADD CONSTRAINT fk_servis_asal FOREIGN KEY (id_servis_asal) REFERENCES servis (id_servis);
+
+-- Laporan: klaim garansi dipisah dari pendapatan & modal servis biasa
+ALTER TABLE laporan
+    ADD COLUMN total_klaim_garansi INT NOT NULL DEFAULT 0 AFTER total_modal,
+    ADD COLUMN total_biaya_garansi DECIMAL(15,2) NOT NULL DEFAULT 0 AFTER total_klaim_garansi;
+
+ALTER TABLE detail_laporan_servis
+    ADD COLUMN id_servis_asal INT NULL AFTER id_servis;
+
+-- Rekap biaya garansi per barang dan per teknisi servis asal
+CREATE TABLE detail_laporan_garansi (
+    id_detail    INT AUTO_INCREMENT PRIMARY KEY,
+    id_laporan   INT NOT NULL,
+    kategori     ENUM('barang', 'teknisi') NOT NULL,
+    id_ref       INT NULL,
+    nama         VARCHAR(150) NOT NULL,
+    jumlah_klaim INT NOT NULL DEFAULT 0,
+    biaya        DECIMAL(15,2) NOT NULL DEFAULT 0,
+    INDEX idx_detail_laporan_garansi_laporan (id_laporan, kategori),
+    CONSTRAINT fk_detail_laporan_garansi_laporan FOREIGN KEY (id_laporan) REFERENCES laporan (id_laporan) ON DELETE CASCADE
+);
@@ -0,0 +1,26 @@
+-- Data penerimaan perangkat (checklist saat HP diterima), satu baris per servis.
+-- kode_kunci dikosongkan otomatis saat servis diambil / batal.
+CREATE TABLE servis_intake (
+    id_servis           INT PRIMARY KEY,
+    merek               VARCHAR(50) NOT NULL DEFAULT '',
+    model               VARCHAR(100) NOT NULL DEFAULT '',
+    imei                VARCHAR(15) NULL,
+    serial_number       VARCHAR(50) NULL,
+    warna               VARCHAR(30) NOT NULL DEFAULT '',
+    ada_charger         TINYINT(1) NOT NULL DEFAULT 0,
+    ada_casing          TINYINT(1) NOT NULL DEFAULT 0,
+    ada_simcard         TINYINT(1) NOT NULL DEFAULT 0,
+    ada_memory_card     TINYINT(1) NOT NULL DEFAULT 0,
+    kelengkapan_lain    VARCHAR(255) NOT NULL DEFAULT '',
+    kondisi_fisik       TEXT NULL,
+    kunci_layar         ENUM('tidak_ada', 'pola', 'pin', 'password') NOT NULL DEFAULT 'tidak_ada',
+    kode_kunci          VARCHAR(100) NULL,
+    setuju_risiko_data  TINYINT(1) NOT NULL DEFAULT 0,
+    setuju_buka_segel   TINYINT(1) NOT NULL DEFAULT 0,
+    setuju_tanpa_cek    TINYINT(1) NOT NULL DEFAULT 0,
+    catatan             VARCHAR(255) NOT NULL DEFAULT '',
+    updated_at          DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_servis_intake_imei (imei),
+    INDEX idx_servis_intake_serial (serial_number),
+    CONSTRAINT fk_servis_intake_servis FOREIGN KEY (id_servis) REFERENCES servis (id_servis) ON DELETE CASCADE
+);
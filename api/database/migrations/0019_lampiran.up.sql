@@ -0,0 +1,18 @@
+-- Lampiran servis: foto kondisi HP sebelum/sesudah perbaikan & dokumen pendukung.
+-- Isi berkas ada di storage (lampiran.Storage), tabel ini hanya menyimpan metadata & key.
+-- Tanpa ON DELETE agar servis yang punya lampiran tidak bisa terhapus bersama buktinya.
+CREATE TABLE lampiran (
+    id_lampiran  INT AUTO_INCREMENT PRIMARY KEY,
+    id_servis    INT NOT NULL,
+    jenis        ENUM('sebelum', 'sesudah', 'dokumen') NOT NULL,
+    nama_file    VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    ukuran       INT NOT NULL,
+    storage_key  VARCHAR(255) NOT NULL,
+    thumb_key    VARCHAR(255) NULL,
+    keterangan   VARCHAR(255) NOT NULL DEFAULT '',
+    id_user      INT NULL,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_lampiran_servis (id_servis),
+    CONSTRAINT fk_lampiran_servis FOREIGN KEY (id_servis) REFERENCES servis (id_servis)
+);
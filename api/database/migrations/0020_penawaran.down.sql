@@ -0,0 +1,11 @@
+DELETE FROM notifikasi_outbox WHERE jenis = 'penawaran';
+
+ALTER TABLE notifikasi_outbox
+    MODIFY jenis ENUM('diterima', 'dalam_perbaikan', 'siap_diambil', 'pengingat') NOT NULL;
+
+ALTER TABLE servis
+    DROP COLUMN penawaran_override_alasan,
+    DROP COLUMN penawaran_override_at,
+    DROP COLUMN penawaran_override_oleh;
+
+DROP TABLE IF EXISTS penawaran;
@@ -0,0 +1,31 @@
+-- Penawaran biaya servis yang harus disetujui pelanggan sebelum barang dipakai.
+-- Penawaran terakhir per servis yang berlaku; penawaran baru membatalkan yang masih menunggu.
+-- status 'menunggu' yang lewat berlaku_sampai dibaca sebagai kedaluwarsa.
+CREATE TABLE penawaran (
+    id_penawaran    INT AUTO_INCREMENT PRIMARY KEY,
+    id_servis       INT NOT NULL,
+    biaya_jasa      DECIMAL(15,2) NOT NULL DEFAULT 0,
+    total           DECIMAL(15,2) NOT NULL,
+    item            JSON NOT NULL,
+    catatan         VARCHAR(255) NOT NULL DEFAULT '',
+    berlaku_sampai  DATETIME NOT NULL,
+    status          ENUM('menunggu', 'disetujui', 'ditolak', 'dibatalkan') NOT NULL DEFAULT 'menunggu',
+    kanal           ENUM('link', 'whatsapp', 'telepon', 'langsung') NULL,
+    alasan          VARCHAR(255) NOT NULL DEFAULT '',
+    diputuskan_at   DATETIME NULL,
+    diputuskan_oleh INT NULL, -- pegawai yang mencatat keputusan, NULL jika pelanggan lewat link
+    ip_keputusan    VARCHAR(45) NULL,
+    id_user         INT NULL,
+    created_at      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_penawaran_servis (id_servis),
+    CONSTRAINT fk_penawaran_servis FOREIGN KEY (id_servis) REFERENCES servis (id_servis) ON DELETE CASCADE
+);
+
+-- Override admin: barang boleh dipakai tanpa persetujuan pelanggan
+ALTER TABLE servis
+    ADD COLUMN penawaran_override_oleh INT NULL,
+    ADD COLUMN penawaran_override_at DATETIME NULL,
+    ADD COLUMN penawaran_override_alasan VARCHAR(255) NOT NULL DEFAULT '';
+
+ALTER TABLE notifikasi_outbox
+    MODIFY jenis ENUM('diterima', 'dalam_perbaikan', 'siap_diambil', 'pengingat', 'penawaran') NOT NULL;
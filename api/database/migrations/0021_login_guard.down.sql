@@ -0,0 +1,5 @@
+ALTER TABLE login_attempt
+    DROP INDEX idx_login_attempt_ip,
+    ADD INDEX idx_login_attempt_ip (ip_address, berhasil, created_at);
+
+DROP TABLE IF EXISTS login_guard;
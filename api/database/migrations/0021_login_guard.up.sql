@@ -0,0 +1,10 @@
+-- Baris kunci per IP / username. Dikunci (FOR UPDATE) saat login agar hitung gagal
+-- dan pencatatan percobaan berjalan atomik, request paralel tidak bisa melewati batas.
+CREATE TABLE login_guard (
+    kunci VARCHAR(100) PRIMARY KEY
+);
+
+-- Hitungan gagal per IP juga memakai flag dihitung agar bisa dibuka admin
+ALTER TABLE login_attempt
+    DROP INDEX idx_login_attempt_ip,
+    ADD INDEX idx_login_attempt_ip (ip_address, berhasil, dihitung, created_at);
@@ -0,0 +1,4 @@
+UPDATE notifikasi_outbox SET status = 'pending' WHERE status = 'mengirim';
+
+ALTER TABLE notifikasi_outbox
+    MODIFY status ENUM('pending', 'terkirim', 'gagal') NOT NULL DEFAULT 'pending';
@@ -0,0 +1,4 @@
+-- Status 'mengirim': baris sudah diklaim worker dan sedang dikirim. jadwal_kirim
+-- diisi batas klaim; jika worker mati sebelum selesai, baris diklaim ulang setelahnya.
+ALTER TABLE notifikasi_outbox
+    MODIFY status ENUM('pending', 'mengirim', 'terkirim', 'gagal') NOT NULL DEFAULT 'pending';
@@ -0,0 +1,103 @@
+package lampiran
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png" // decoder PNG untuk image.Decode
+	"net/http"
+	"strings"
+)
+
+// tipeDiizinkan - content type hasil deteksi isi berkas -> ekstensi simpan
+var tipeDiizinkan = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+// maksPiksel - batas resolusi gambar agar decode thumbnail tidak menghabiskan memori
+const maksPiksel = 50_000_000
+
+var (
+	ErrTipe     = errors.New("Tipe berkas harus JPG, PNG, atau PDF")
+	ErrRusak    = errors.New("Berkas gambar rusak atau tidak bisa dibaca")
+	ErrResolusi = errors.New("Resolusi gambar terlalu besar")
+)
+
+// Periksa menentukan tipe berkas dari isinya (bukan dari nama / header dari klien)
+// dan memastikan gambar bisa dibaca.
+func Periksa(data []byte) (contentType, ext string, err error) {
+	contentType = http.DetectContentType(data)
+	ext, ok := tipeDiizinkan[contentType]
+	if !ok {
+		return "", "", ErrTipe
+	}
+	if IsGambar(contentType) {
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return "", "", ErrRusak
+		}
+		if cfg.Width*cfg.Height > maksPiksel {
+			return "", "", ErrResolusi
+		}
+	}
+	return contentType, ext, nil
+}
+
+// IsGambar - true untuk tipe yang dibuatkan thumbnail
+func IsGambar(contentType string) bool {
+	return strings.HasPrefix(contentType, "image/")
+}
+
+// Thumbnail mengecilkan gambar menjadi JPEG dengan sisi terpanjang maksimal maks piksel.
+// Gambar yang sudah kecil tidak diperbesar; area transparan PNG menjadi putih.
+func Thumbnail(data []byte, maks int) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrRusak
+	}
+
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	tw, th := w, h
+	if w >= h && w > maks {
+		tw, th = maks, max(1, h*maks/w)
+	} else if h > w && h > maks {
+		tw, th = max(1, w*maks/h), maks
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := b.Min.Y+y*h/th, b.Min.Y+(y+1)*h/th
+		for x := 0; x < tw; x++ {
+			x0, x1 := b.Min.X+x*w/tw, b.Min.X+(x+1)*w/tw
+			dst.Set(x, y, rataRata(src, x0, y0, max(x1, x0+1), max(y1, y0+1)))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// rataRata - warna rata-rata kotak sumber [x0,x1)x[y0,y1), diambil maksimal 4x4 sampel
+func rataRata(src image.Image, x0, y0, x1, y1 int) color.Color {
+	langkahX, langkahY := max(1, (x1-x0)/4), max(1, (y1-y0)/4)
+	var r, g, b, n uint64
+	for y := y0; y < y1; y += langkahY {
+		for x := x0; x < x1; x += langkahX {
+			cr, cg, cb, ca := src.At(x, y).RGBA()
+			// warna premultiplied, sisa alpha diisi putih
+			r += uint64(cr + 0xffff - ca)
+			g += uint64(cg + 0xffff - ca)
+			b += uint64(cb + 0xffff - ca)
+			n++
+		}
+	}
+	return color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: 0xffff}
+}
@@ -0,0 +1,95 @@
+package lampiran
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage menyimpan berkas di folder lokal server
+type LocalStorage struct {
+	dir string
+}
+
+// NewLocalStorage memakai dir sebagai root, dibuat jika belum ada
+func NewLocalStorage(dir string) (*LocalStorage, error) {
+	if dir == "" {
+		return nil, errors.New("LAMPIRAN_DIR wajib diisi untuk driver local")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("gagal membuat folder lampiran: %w", err)
+	}
+	return &LocalStorage{dir: dir}, nil
+}
+
+func (s *LocalStorage) Name() string {
+	return "local"
+}
+
+// lokasi memetakan key ke path file; key yang keluar dari root ditolak
+func (s *LocalStorage) lokasi(key string) (string, error) {
+	bersih := path.Clean("/" + key)
+	if bersih == "/" || strings.Contains(key, "..") || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("key lampiran tidak valid: %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(bersih[1:])), nil
+}
+
+// Put menulis ke file sementara lalu rename, agar tidak ada berkas setengah jadi
+func (s *LocalStorage) Put(key string, r io.Reader) error {
+	p, err := s.lokasi(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) Open(key string) (io.ReadCloser, error) {
+	p, err := s.lokasi(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrTidakAda
+	}
+	return f, err
+}
+
+// Delete tidak error jika berkas memang sudah tidak ada
+func (s *LocalStorage) Delete(key string) error {
+	p, err := s.lokasi(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+// Package lampiran menyimpan foto & dokumen servis (kondisi HP sebelum/sesudah, bukti perbaikan)
+// lewat storage yang bisa diganti. Metadata ada di tabel lampiran, isi berkas di Storage.
+package lampiran
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"service_hp/config"
+)
+
+// ErrTidakAda - berkas tidak ditemukan di storage
+var ErrTidakAda = errors.New("berkas lampiran tidak ditemukan")
+
+// Storage - tempat penyimpanan isi berkas. Key berupa path relatif dengan pemisah "/",
+// misalnya "servis/12/3f9a....jpg". Implementasi S3-compatible cukup memetakan key ke object key.
+type Storage interface {
+	Name() string
+	Put(key string, r io.Reader) error
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// Store - storage aktif, diisi oleh Init() saat startup
+var Store Storage
+
+// Init membuat Storage sesuai LAMPIRAN_DRIVER
+func Init() error {
+	s, err := NewFromConfig()
+	if err != nil {
+		return err
+	}
+	Store = s
+	return nil
+}
+
+// NewFromConfig membuat Storage sesuai LAMPIRAN_DRIVER
+func NewFromConfig() (Storage, error) {
+	cfg := config.Cfg.Lampiran
+	switch cfg.Driver {
+	case "local":
+		return NewLocalStorage(cfg.Dir)
+	default:
+		return nil, fmt.Errorf("LAMPIRAN_DRIVER tidak dikenal: %s", cfg.Driver)
+	}
+}
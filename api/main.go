@@ -3,7 +3,11 @@ package main
 import (
 	"log"
 	"net/http"
+	"os"
+	"service_hp/config"
 	"service_hp/database"
+	"service_hp/lampiran"
+	"service_hp/notifikasi"
 	"service_hp/routes"
 	m "service_hp/routes/middleware"
   
@@ -12,17 +16,51 @@ import (
 )
 
 func main() {
+	if err := config.Load(); err != nil {
+		log.Fatal("Konfigurasi tidak valid:\n", err)
+	}
+
+	// service_hp migrate up|down|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+
 	database.Connect()
+
+	// Storage foto & dokumen lampiran servis
+	if err := lampiran.Init(); err != nil {
+		log.Fatal("Konfigurasi lampiran tidak valid: ", err)
+	}
+
+	// Worker pengirim notifikasi WhatsApp dari outbox
+	if notifikasi.Enabled() {
+		notifier, err := notifikasi.NewFromConfig()
+		if err != nil {
+			log.Fatal("Konfigurasi notifikasi tidak valid: ", err)
+		}
+		notifikasi.StartWorker(notifier)
+	}
  
 	// Buat mux khusus daripada DefaultServeMux
 	mux := http.NewServeMux()
 
-	// Daftarkan route ke mux
-	routes.RegisterRoutes(mux)
+	// Daftarkan route ke mux, berhenti jika ada route tanpa policy akses
+	if err := routes.RegisterRoutes(mux); err != nil {
+		log.Fatal("Route tidak valid:\n", err)
+	}
 
 	// Bungkus seluruh mux dengan middleware CORS
 	handler := m.CorsMiddleware(mux)
 
-	log.Println("Server berjalan di http://localhost:8080")
-	http.ListenAndServe(":8080", handler)
+	cfg := config.Cfg.Server
+	server := &http.Server{
+		Addr:         cfg.Addr,
+		Handler:      handler,
+		ReadTimeout:  cfg.ReadTimeout.Duration,
+		WriteTimeout: cfg.WriteTimeout.Duration,
+		IdleTimeout:  cfg.IdleTimeout.Duration,
+	}
+
+	log.Printf("Server berjalan di %s (%s)", cfg.Addr, config.Cfg.Env)
+	log.Fatal(server.ListenAndServe())
 }
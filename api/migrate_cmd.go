@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"service_hp/database"
+	"strconv"
+)
+
+// runMigrate menjalankan subcommand: service_hp migrate up|down [n]|baseline <versi>|status
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Penggunaan: service_hp migrate up|down [n]|baseline <versi>|status")
+		return 2
+	}
+
+	database.Open()
+	defer database.DB.Close()
+
+	switch args[0] {
+	case "up":
+		done, err := database.MigrateUp(database.DB)
+		for _, m := range done {
+			fmt.Printf("  up   %04d_%s\n", m.Version, m.Nama)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Migrate up gagal:", err)
+			return 1
+		}
+		if len(done) == 0 {
+			fmt.Println("Skema sudah terbaru.")
+		}
+
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			v, err := strconv.Atoi(args[1])
+			if err != nil || v < 1 {
+				fmt.Fprintln(os.Stderr, "Jumlah migration harus angka >= 1")
+				return 2
+			}
+			n = v
+		}
+		done, err := database.MigrateDown(database.DB, n)
+		for _, m := range done {
+			fmt.Printf("  down %04d_%s\n", m.Version, m.Nama)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Migrate down gagal:", err)
+			return 1
+		}
+		if len(done) == 0 {
+			fmt.Println("Tidak ada migration yang bisa di-rollback.")
+		}
+
+	case "baseline":
+		// Database lama yang tabelnya dibuat manual dari dump awal (Laragon), sebelum
+		// ada schema_migrations. Dump awal setara versi 1; versi lebih tinggi hanya jika
+		// perubahan migration berikutnya juga sudah diterapkan manual. Setelah itu migrate up.
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, "Penggunaan: service_hp migrate baseline <versi>")
+			return 2
+		}
+		v, err := strconv.Atoi(args[1])
+		if err != nil || v < 1 {
+			fmt.Fprintln(os.Stderr, "Versi migration harus angka >= 1")
+			return 2
+		}
+		done, err := database.MigrateBaseline(database.DB, v)
+		for _, m := range done {
+			fmt.Printf("  mark %04d_%s\n", m.Version, m.Nama)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Migrate baseline gagal:", err)
+			return 1
+		}
+		if len(done) == 0 {
+			fmt.Println("Tidak ada migration yang perlu dicatat.")
+		}
+
+	case "status":
+		statuses, err := database.MigrationStatuses(database.DB)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Gagal membaca status migration:", err)
+			return 1
+		}
+		for _, s := range statuses {
+			status := "pending"
+			if s.AppliedAt != nil {
+				status = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("  %04d_%-30s %s\n", s.Version, s.Nama, status)
+		}
+
+	default:
+		fmt.Fprintln(os.Stderr, "Subcommand migrate tidak dikenal:", args[0])
+		return 2
+	}
+	return 0
+}
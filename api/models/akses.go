@@ -0,0 +1,22 @@
+package models
+
+// RoleAkses - role efektif beserta permission-nya
+type RoleAkses struct {
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
+// UserAkses - user beserta role efektifnya untuk manajemen akses admin
+type UserAkses struct {
+	IDUser   int    `json:"id_user"`
+	Nama     string `json:"nama"`
+	Username string `json:"username"`
+	Role     string `json:"role"`    // user.role: admin, pegawai
+	Jabatan  string `json:"jabatan"` // pegawai.jabatan: Kasir, Teknisi
+	Status   string `json:"status"`  // pegawai.status
+}
+
+// UbahAksesRequest - Request admin untuk mengganti role efektif user
+type UbahAksesRequest struct {
+	Akses string `json:"akses"` // admin, kasir, teknisi
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditLog - Model untuk tabel audit_log. DataSebelum/DataSesudah hanya berisi field yang berubah.
+type AuditLog struct {
+	IDAudit     int64           `json:"id_audit"`
+	IDUser      *int            `json:"id_user"`
+	NamaUser    string          `json:"nama_user"`
+	Aksi        string          `json:"aksi"`
+	Entitas     string          `json:"entitas"`
+	IDEntitas   *int            `json:"id_entitas"`
+	DataSebelum json.RawMessage `json:"data_sebelum"`
+	DataSesudah json.RawMessage `json:"data_sesudah"`
+	IPAddress   string          `json:"ip_address"`
+	UserAgent   string          `json:"user_agent"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
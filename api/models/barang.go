@@ -6,4 +6,5 @@ type Barang struct {
     Stok        int     `json:"stok"`
     Harga       float64 `json:"harga"`        
     HargaModal  float64 `json:"harga_modal"`  
+    GaransiHari int     `json:"garansi_hari"` // default garansi detail servis yang memakai barang ini
 }
\ No newline at end of file
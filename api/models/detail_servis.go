@@ -8,5 +8,8 @@ type DetailServis struct {
     Jumlah       int      `json:"jumlah"`          // Qty
     HargaSatuan  float64  `json:"harga_satuan"`    // Harga per unit
     Biaya        float64  `json:"biaya"`           // Auto: jumlah × harga_satuan
+    HargaModal   float64  `json:"harga_modal"`     // Auto: snapshot barang.harga_modal saat dipakai
+    GaransiHari  *int     `json:"garansi_hari"`    // kosong = ikut barang.garansi_hari
+    GaransiSampai *string  `json:"garansi_sampai,omitempty"` // Auto: tanggal_selesai + garansi_hari
    
-}
\ No newline at end of file
+}
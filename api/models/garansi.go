@@ -0,0 +1,42 @@
+package models
+
+// Kategori rekap biaya garansi sesuai enum detail_laporan_garansi.kategori
+const (
+	GaransiPerBarang  = "barang"  // barang pengganti yang dipakai di servis klaim
+	GaransiPerTeknisi = "teknisi" // teknisi yang mengerjakan servis asal
+)
+
+// GaransiItem - Garansi satu baris detail servis
+type GaransiItem struct {
+	IDDetail      int     `json:"id_detail"`
+	Deskripsi     string  `json:"deskripsi"`
+	GaransiHari   int     `json:"garansi_hari"`
+	GaransiSampai *string `json:"garansi_sampai"` // kosong jika servis belum selesai
+	Aktif         bool    `json:"aktif"`
+}
+
+// KlaimGaransiRequest - Request membuka klaim garansi dari servis asal
+type KlaimGaransiRequest struct {
+	Keluhan   string `json:"keluhan"`
+	IDTeknisi *int   `json:"id_teknisi"`
+}
+
+// BiayaGaransi - Rekap biaya klaim garansi per barang atau per teknisi.
+// Biaya = modal barang di servis klaim dikurangi yang tetap ditagih ke pelanggan.
+type BiayaGaransi struct {
+	Kategori    string  `json:"kategori"`
+	IDRef       *int    `json:"id_ref"` // id_barang / id_pegawai, kosong untuk barang non-stok / tanpa teknisi
+	Nama        string  `json:"nama"`
+	JumlahKlaim int     `json:"jumlah_klaim"`
+	Biaya       float64 `json:"biaya"`
+}
+
+// RekapGaransi - Rekap klaim garansi dalam satu periode
+type RekapGaransi struct {
+	TanggalAwal  string         `json:"tanggal_awal"`
+	TanggalAkhir string         `json:"tanggal_akhir"`
+	TotalKlaim   int            `json:"total_klaim"`
+	TotalBiaya   float64        `json:"total_biaya"`
+	PerBarang    []BiayaGaransi `json:"per_barang"`
+	PerTeknisi   []BiayaGaransi `json:"per_teknisi"`
+}
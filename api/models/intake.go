@@ -0,0 +1,65 @@
+package models
+
+// Jenis kunci layar sesuai enum servis_intake.kunci_layar
+const (
+	KunciTidakAda = "tidak_ada"
+	KunciPola     = "pola"
+	KunciPIN      = "pin"
+	KunciPassword = "password"
+)
+
+// ServisIntake - Checklist kondisi perangkat saat diterima
+type ServisIntake struct {
+	Merek        string `json:"merek"`
+	Model        string `json:"model"`
+	IMEI         string `json:"imei"`
+	SerialNumber string `json:"serial_number"`
+	Warna        string `json:"warna"`
+
+	Kelengkapan  Kelengkapan `json:"kelengkapan"`
+	KondisiFisik string      `json:"kondisi_fisik"` // kerusakan fisik yang sudah ada saat diterima
+
+	KunciLayar string `json:"kunci_layar"`
+	// Kode kunci hanya dikirim ke yang boleh mengerjakan servis. Saat update, null = tidak diubah,
+	// "" = dihapus. Dikosongkan otomatis saat servis diambil / batal.
+	KodeKunci          *string `json:"kode_kunci,omitempty"`
+	KodeKunciTersimpan bool    `json:"kode_kunci_tersimpan"`
+
+	Persetujuan Persetujuan `json:"persetujuan"`
+	Catatan     string      `json:"catatan"`
+}
+
+// Kelengkapan - Aksesoris yang ikut dititipkan
+type Kelengkapan struct {
+	Charger    bool   `json:"charger"`
+	Casing     bool   `json:"casing"`
+	SimCard    bool   `json:"simcard"`
+	MemoryCard bool   `json:"memory_card"`
+	Lainnya    string `json:"lainnya"`
+}
+
+// Persetujuan - Persetujuan pelanggan saat perangkat diterima
+type Persetujuan struct {
+	RisikoData bool `json:"risiko_data"` // data di perangkat bisa hilang selama perbaikan
+	BukaSegel  bool `json:"buka_segel"`  // segel / garansi pabrik bisa batal karena unit dibuka
+	TanpaCek   bool `json:"tanpa_cek"`   // perangkat mati / tidak bisa dicek saat diterima
+}
+
+// TandaTerima - Isi tanda terima servis yang dicetak saat perangkat diterima
+type TandaTerima struct {
+	NamaToko    string `json:"nama_toko"`
+	AlamatToko  string `json:"alamat_toko"`
+	TeleponToko string `json:"telepon_toko"`
+	CatatanKaki string `json:"catatan_kaki"`
+
+	IDServis      int           `json:"id_servis"`
+	KodeTracking  string        `json:"kode_tracking"`
+	TanggalMasuk  string        `json:"tanggal_masuk"`
+	NamaPelanggan string        `json:"nama_pelanggan"`
+	NoWhatsapp    string        `json:"no_whatsapp"`
+	TipeHP        string        `json:"tipe_hp"`
+	Keluhan       string        `json:"keluhan"`
+	BiayaTotal    float64       `json:"biaya_total"`
+	TotalDibayar  float64       `json:"total_dibayar"`
+	Intake        *ServisIntake `json:"intake"`
+}
@@ -0,0 +1,66 @@
+package models
+
+// Jenis aturan komisi sesuai enum komisi_aturan.jenis
+const (
+	KomisiPersenBiaya = "persen_biaya" // nilai% dari biaya_servis (jasa, tanpa barang)
+	KomisiTetap       = "tetap"        // nominal tetap per servis selesai
+	KomisiPersenLaba  = "persen_laba"  // nilai% dari biaya_total - modal barang
+)
+
+// KomisiAturan - Aturan komisi satu teknisi, Jenis kosong jika belum diatur
+type KomisiAturan struct {
+	IDPegawai   int     `json:"id_pegawai"`
+	NamaTeknisi string  `json:"nama_teknisi"`
+	Jenis       string  `json:"jenis"`
+	Nilai       float64 `json:"nilai"`
+	UpdatedAt   *string `json:"updated_at"`
+}
+
+// SetKomisiAturanRequest - Request PUT aturan komisi
+type SetKomisiAturanRequest struct {
+	Jenis string  `json:"jenis"`
+	Nilai float64 `json:"nilai"`
+}
+
+// KomisiServis - Komisi satu servis selesai
+type KomisiServis struct {
+	IDServis       int     `json:"id_servis"`
+	IDPegawai      int     `json:"id_pegawai"`
+	NamaTeknisi    string  `json:"nama_teknisi"`
+	TanggalSelesai *string `json:"tanggal_selesai"`
+	BiayaServis    float64 `json:"biaya_servis"`
+	Laba           float64 `json:"laba"`
+	Jenis          string  `json:"jenis"` // kosong jika teknisi belum punya aturan
+	Nilai          float64 `json:"nilai"`
+	Komisi         float64 `json:"komisi"`
+	IDPeriode      *int    `json:"id_periode"` // terisi jika sudah dikunci di periode komisi
+}
+
+// KomisiTeknisi - Rekap komisi per teknisi
+type KomisiTeknisi struct {
+	IDPegawai    int     `json:"id_pegawai"`
+	NamaTeknisi  string  `json:"nama_teknisi"`
+	JumlahServis int     `json:"jumlah_servis"`
+	TotalKomisi  float64 `json:"total_komisi"`
+}
+
+// KomisiPeriode - Perhitungan komisi satu periode. IDPeriode 0 untuk pratinjau yang belum dikunci.
+type KomisiPeriode struct {
+	IDPeriode    int             `json:"id_periode,omitempty"`
+	TanggalAwal  string          `json:"tanggal_awal"`
+	TanggalAkhir string          `json:"tanggal_akhir"`
+	TotalKomisi  float64         `json:"total_komisi"`
+	Keterangan   string          `json:"keterangan,omitempty"`
+	IDUser       *int            `json:"id_user,omitempty"`
+	NamaUser     string          `json:"nama_user,omitempty"`
+	DikunciAt    *string         `json:"dikunci_at,omitempty"`
+	Teknisi      []KomisiTeknisi `json:"teknisi,omitempty"`
+	Detail       []KomisiServis  `json:"detail,omitempty"`
+}
+
+// KunciKomisiRequest - Request mengunci periode komisi
+type KunciKomisiRequest struct {
+	TanggalAwal  string `json:"tanggal_awal"`
+	TanggalAkhir string `json:"tanggal_akhir"`
+	Keterangan   string `json:"keterangan"`
+}
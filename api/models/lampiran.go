@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Jenis lampiran sesuai enum tabel lampiran
+const (
+	LampiranSebelum = "sebelum" // kondisi HP saat diterima
+	LampiranSesudah = "sesudah" // hasil / bukti perbaikan
+	LampiranDokumen = "dokumen"
+)
+
+// Lampiran - Foto / dokumen yang dilampirkan ke servis. URL berkas & thumbnail
+// diisi controller, thumbnail hanya ada untuk gambar.
+type Lampiran struct {
+	IDLampiran  int       `json:"id_lampiran"`
+	IDServis    int       `json:"id_servis"`
+	Jenis       string    `json:"jenis"`
+	NamaFile    string    `json:"nama_file"`
+	ContentType string    `json:"content_type"`
+	Ukuran      int       `json:"ukuran"`
+	Keterangan  string    `json:"keterangan"`
+	URL         string    `json:"url"`
+	ThumbURL    *string   `json:"thumb_url"`
+	IDUser      *int      `json:"id_user"`
+	NamaUser    string    `json:"nama_user"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	StorageKey string  `json:"-"`
+	ThumbKey   *string `json:"-"`
+}
@@ -10,14 +10,26 @@ type Laporan struct {
 	TanggalAwal     string    `json:"tanggal_awal"`
 	TanggalAkhir    string    `json:"tanggal_akhir"`
 	TotalServis     int       `json:"total_servis"`
-	TotalPendapatan float64   `json:"total_pendapatan"`
+	TotalPendapatan float64   `json:"total_pendapatan"` // ditagih: biaya_total servis masuk dalam periode, tanpa klaim garansi
+	TotalDiterima   float64   `json:"total_diterima"`   // uang diterima dalam periode, bersih setelah refund
+	TotalPiutang    float64   `json:"total_piutang"`    // sisa tagihan servis periode ini saat laporan dibuat
 	TotalModal      float64   `json:"total_modal"`
+	TotalKlaim      int       `json:"total_klaim_garansi"` // klaim garansi masuk dalam periode, tidak termasuk total_servis
+	TotalBiayaKlaim float64   `json:"total_biaya_garansi"` // modal klaim garansi dikurangi yang ditagih ke pelanggan
+	TotalPembelian  float64   `json:"total_pembelian"` // belanja pembelian barang yang diterima
+	TotalKomisi     float64   `json:"total_komisi"`    // komisi teknisi dari servis selesai dalam periode
 	LabaBersih      float64   `json:"laba_bersih"`
 	Keterangan      string    `json:"keterangan,omitempty"`
 	CreatedAt       time.Time `json:"created_at"`
+
+	// true jika modal & laba dikosongkan karena user tidak punya izin laporan.view_profit
+	ProfitDisembunyikan bool `json:"profit_disembunyikan,omitempty"`
 	
 	// Untuk detail
-	DetailServis []DetailLaporanServis `json:"detail_servis,omitempty"`
+	DetailServis  []DetailLaporanServis `json:"detail_servis,omitempty"`
+	KomisiTeknisi []KomisiTeknisi       `json:"komisi_teknisi,omitempty"`
+	KlaimBarang   []BiayaGaransi        `json:"garansi_barang,omitempty"`
+	KlaimTeknisi  []BiayaGaransi        `json:"garansi_teknisi,omitempty"`
 }
 
 // DetailLaporanServis - Model untuk detail servis dalam laporan
@@ -25,6 +37,7 @@ type DetailLaporanServis struct {
 	IDDetail       int     `json:"id_detail"`
 	IDLaporan      int     `json:"id_laporan"`
 	IDServis       int     `json:"id_servis"`
+	IDServisAsal   *int    `json:"id_servis_asal"` // terisi untuk klaim garansi
 	NamaPelanggan  string  `json:"nama_pelanggan"`
 	TipeHP         string  `json:"tipe_hp"`
 	StatusServis   string  `json:"status_servis"`
@@ -46,11 +59,14 @@ type DataStats struct {
 	MingguIni PeriodStats   `json:"minggu_ini"`
 	BulanIni  PeriodStats   `json:"bulan_ini"`
 	ChartPendapatan []ChartData `json:"chart_pendapatan"`
+
+	ProfitDisembunyikan bool `json:"profit_disembunyikan,omitempty"`
 }
 
 type PeriodStats struct {
 	TotalServis     int     `json:"total_servis"`
-	TotalPendapatan float64 `json:"total_pendapatan"`
+	TotalPendapatan float64 `json:"total_pendapatan"` // ditagih
+	TotalDiterima   float64 `json:"total_diterima"`   // uang masuk dari pembayaran
 	LabaBersih      float64 `json:"laba_bersih"`
 }
 
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Alasan pada login_attempt
+const (
+	LoginBerhasil      = "berhasil"
+	LoginUserTidakAda  = "user_tidak_ada"
+	LoginPasswordSalah = "password_salah"
+	LoginAkunNonaktif  = "akun_nonaktif"
+	LoginDiblokir      = "diblokir" // ditolak karena lockout / jeda, password tidak dicek
+	LoginProses        = "proses"   // password sedang dicek, dihitung gagal sampai hasilnya dicatat
+)
+
+// LoginAttempt - Model untuk tabel login_attempt
+type LoginAttempt struct {
+	IDAttempt int       `json:"id_attempt"`
+	Username  string    `json:"username"`
+	IDUser    *int      `json:"id_user"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Berhasil  bool      `json:"berhasil"`
+	Alasan    string    `json:"alasan"`
+	Dihitung  bool      `json:"dihitung"` // masih dihitung untuk lockout username / IP
+	CreatedAt time.Time `json:"created_at"`
+}
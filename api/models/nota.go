@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// Format cetak nota
+const (
+	NotaA4        = "a4"
+	NotaThermal58 = "58"
+	NotaThermal80 = "80"
+)
+
+// Nota - Nota resmi yang sudah diterbitkan untuk satu servis
+type Nota struct {
+	IDNota        int       `json:"id_nota"`
+	IDServis      int       `json:"id_servis"`
+	Nomor         string    `json:"nomor"`
+	IDUser        *int      `json:"id_user"`
+	NamaUser      string    `json:"nama_user"`
+	TanggalTerbit time.Time `json:"tanggal_terbit"`
+	Data          NotaData  `json:"data"`
+}
+
+// NotaData - Salinan beku isi nota saat diterbitkan, cetak ulang selalu memakai data ini
+type NotaData struct {
+	Nomor         string `json:"nomor"`
+	TanggalTerbit string `json:"tanggal_terbit"`
+
+	NamaToko    string `json:"nama_toko"`
+	AlamatToko  string `json:"alamat_toko"`
+	TeleponToko string `json:"telepon_toko"`
+	CatatanKaki string `json:"catatan_kaki"`
+
+	IDServis       int    `json:"id_servis"`
+	KodeTracking   string `json:"kode_tracking"`
+	NamaPelanggan  string `json:"nama_pelanggan"`
+	NoWhatsapp     string `json:"no_whatsapp"`
+	TipeHP         string `json:"tipe_hp"`
+	Keluhan        string `json:"keluhan"`
+	StatusServis   string `json:"status_servis"`
+	NamaTeknisi    string `json:"nama_teknisi"`
+	TanggalMasuk   string `json:"tanggal_masuk"`
+	TanggalSelesai string `json:"tanggal_selesai"`
+
+	Item         []NotaItem       `json:"item"`
+	BiayaServis  float64          `json:"biaya_servis"`
+	BiayaTotal   float64          `json:"biaya_total"`
+	Pembayaran   []NotaPembayaran `json:"pembayaran"`
+	TotalDibayar float64          `json:"total_dibayar"`
+	SisaTagihan  float64          `json:"sisa_tagihan"`
+}
+
+// NotaItem - Satu baris barang / jasa di nota
+type NotaItem struct {
+	Deskripsi   string  `json:"deskripsi"`
+	Jumlah      int     `json:"jumlah"`
+	HargaSatuan float64 `json:"harga_satuan"`
+	Biaya       float64 `json:"biaya"`
+}
+
+// NotaPembayaran - Satu pembayaran di nota
+type NotaPembayaran struct {
+	Tanggal string  `json:"tanggal"`
+	Jenis   string  `json:"jenis"`
+	Metode  string  `json:"metode"`
+	Jumlah  float64 `json:"jumlah"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Jenis notifikasi pelanggan
+const (
+	NotifDiterima       = "diterima"
+	NotifDalamPerbaikan = "dalam_perbaikan"
+	NotifSiapDiambil    = "siap_diambil"
+	NotifPengingat      = "pengingat"
+	NotifPenawaran      = "penawaran"
+)
+
+// Status pengiriman pada notifikasi_outbox
+const (
+	NotifStatusPending  = "pending"
+	NotifStatusMengirim = "mengirim" // sudah diklaim worker, sedang dikirim
+	NotifStatusTerkirim = "terkirim"
+	NotifStatusGagal    = "gagal"
+)
+
+// Notifikasi - Model untuk tabel notifikasi_outbox
+type Notifikasi struct {
+	IDNotifikasi  int        `json:"id_notifikasi"`
+	IDServis      int        `json:"id_servis"`
+	Jenis         string     `json:"jenis"`
+	Tujuan        string     `json:"tujuan"` // nomor WhatsApp 62xxxx
+	Pesan         string     `json:"pesan"`
+	Status        string     `json:"status"`
+	Percobaan     int        `json:"percobaan"`
+	ErrorTerakhir string     `json:"error_terakhir"`
+	Gateway       string     `json:"gateway"`
+	JadwalKirim   time.Time  `json:"jadwal_kirim"`
+	TerkirimAt    *time.Time `json:"terkirim_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
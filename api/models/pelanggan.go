@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Pelanggan - Model untuk tabel pelanggan
+type Pelanggan struct {
+	IDPelanggan   int       `json:"id_pelanggan"`
+	NamaPelanggan string    `json:"nama_pelanggan"`
+	NoWhatsapp    string    `json:"no_whatsapp"` // dinormalisasi ke format 62xxxx
+	Alamat        string    `json:"alamat"`
+	Catatan       string    `json:"catatan"`
+	CreatedAt     time.Time `json:"created_at"`
+	TotalServis   int       `json:"total_servis"`
+
+	// Untuk detail
+	RiwayatServis []Servis `json:"riwayat_servis,omitempty"`
+}
+
+// MergePelangganRequest - Gabungkan beberapa pelanggan duplikat ke satu pelanggan
+type MergePelangganRequest struct {
+	IDTujuan int   `json:"id_tujuan"`
+	IDSumber []int `json:"id_sumber"`
+}
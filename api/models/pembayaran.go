@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Jenis & metode pembayaran sesuai enum tabel pembayaran
+const (
+	PembayaranDP        = "dp"
+	PembayaranPelunasan = "pelunasan"
+	PembayaranRefund    = "refund"
+
+	MetodeTunai    = "tunai"
+	MetodeTransfer = "transfer"
+	MetodeQRIS     = "qris"
+)
+
+// Pembayaran - Model untuk tabel pembayaran
+type Pembayaran struct {
+	IDPembayaran int       `json:"id_pembayaran"`
+	IDServis     int       `json:"id_servis"`
+	Jenis        string    `json:"jenis"`
+	Metode       string    `json:"metode"`
+	Jumlah       float64   `json:"jumlah"` // selalu positif, refund mengurangi total dibayar
+	Referensi    string    `json:"referensi"`
+	Keterangan   string    `json:"keterangan"`
+	IDUser       *int      `json:"id_user"`
+	NamaUser     string    `json:"nama_user"`
+	Tanggal      time.Time `json:"tanggal"`
+}
+
+// PembayaranRequest - Request catat pembayaran (juga dipakai untuk DP saat servis dibuat)
+type PembayaranRequest struct {
+	Jenis      string  `json:"jenis"`
+	Metode     string  `json:"metode"`
+	Jumlah     float64 `json:"jumlah"`
+	Referensi  string  `json:"referensi"`
+	Keterangan string  `json:"keterangan"`
+}
+
+// Tagihan - Ringkasan tagihan satu servis
+type Tagihan struct {
+	BiayaTotal   float64 `json:"biaya_total"`
+	TotalDibayar float64 `json:"total_dibayar"`
+	SisaTagihan  float64 `json:"sisa_tagihan"` // negatif berarti kelebihan bayar, perlu refund
+}
+
+// PembayaranServis - Tagihan beserta riwayat pembayaran satu servis
+type PembayaranServis struct {
+	Tagihan
+	IDServis   int          `json:"id_servis"`
+	Pembayaran []Pembayaran `json:"pembayaran"`
+}
+
+// RekapPembayaran - Uang diterima dalam rentang tanggal, dipisah per metode
+type RekapPembayaran struct {
+	TanggalAwal   string             `json:"tanggal_awal"`
+	TanggalAkhir  string             `json:"tanggal_akhir"`
+	TotalDiterima float64            `json:"total_diterima"` // bersih setelah refund
+	TotalRefund   float64            `json:"total_refund"`
+	PerMetode     map[string]float64 `json:"per_metode"`
+	Pembayaran    []Pembayaran       `json:"pembayaran"`
+}
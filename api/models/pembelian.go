@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Status pembelian
+const (
+	PembelianDraft    = "draft"
+	PembelianDiterima = "diterima"
+	PembelianBatal    = "batal"
+)
+
+// Pembelian - Model untuk tabel pembelian (purchase order)
+type Pembelian struct {
+	IDPembelian      int               `json:"id_pembelian"`
+	IDSupplier       int               `json:"id_supplier"`
+	NamaSupplier     string            `json:"nama_supplier"`
+	NoFaktur         string            `json:"no_faktur"`
+	TanggalPembelian string            `json:"tanggal_pembelian"`
+	Status           string            `json:"status"`
+	TotalPembelian   float64           `json:"total_pembelian"` // Auto: SUM(subtotal)
+	Keterangan       string            `json:"keterangan"`
+	IDUser           *int              `json:"id_user"`
+	TanggalDiterima  *string           `json:"tanggal_diterima"`
+	CreatedAt        time.Time         `json:"created_at"`
+	Detail           []DetailPembelian `json:"detail"`
+}
+
+// DetailPembelian - Baris barang dalam pembelian
+type DetailPembelian struct {
+	IDDetailPembelian int     `json:"id_detail_pembelian"`
+	IDPembelian       int     `json:"id_pembelian"`
+	IDBarang          int     `json:"id_barang"`
+	NamaBarang        string  `json:"nama_barang"`
+	Jumlah            int     `json:"jumlah"`
+	HargaBeli         float64 `json:"harga_beli"` // Harga beli per unit
+	Subtotal          float64 `json:"subtotal"`   // Auto: jumlah × harga_beli
+}
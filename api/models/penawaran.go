@@ -0,0 +1,100 @@
+package models
+
+import "time"
+
+// Status penawaran. Kedaluwarsa tidak disimpan, dihitung dari berlaku_sampai.
+const (
+	PenawaranMenunggu    = "menunggu"
+	PenawaranDisetujui   = "disetujui"
+	PenawaranDitolak     = "ditolak"
+	PenawaranDibatalkan  = "dibatalkan" // diganti penawaran baru
+	PenawaranKedaluwarsa = "kedaluwarsa"
+)
+
+// Kanal keputusan pelanggan atas penawaran
+const (
+	KanalLink     = "link" // pelanggan sendiri lewat halaman tracking
+	KanalWhatsapp = "whatsapp"
+	KanalTelepon  = "telepon"
+	KanalLangsung = "langsung" // di toko
+)
+
+// ItemPenawaran - Satu barang / pekerjaan pada penawaran
+type ItemPenawaran struct {
+	IDBarang    *int    `json:"id_barang"`
+	Deskripsi   string  `json:"deskripsi"`
+	Jumlah      int     `json:"jumlah"`
+	HargaSatuan float64 `json:"harga_satuan"`
+	Biaya       float64 `json:"biaya"`
+}
+
+// Penawaran - Estimasi biaya servis yang diajukan ke pelanggan
+type Penawaran struct {
+	IDPenawaran    int             `json:"id_penawaran"`
+	IDServis       int             `json:"id_servis"`
+	Status         string          `json:"status"`
+	BiayaJasa      float64         `json:"biaya_jasa"`
+	Item           []ItemPenawaran `json:"item"`
+	Total          float64         `json:"total"`
+	Catatan        string          `json:"catatan"`
+	BerlakuSampai  time.Time       `json:"berlaku_sampai"`
+	Kanal          *string         `json:"kanal"`
+	Alasan         string          `json:"alasan"`
+	DiputuskanAt   *time.Time      `json:"diputuskan_at"`
+	DiputuskanOleh *int            `json:"diputuskan_oleh"`
+	NamaPemutus    string          `json:"nama_pemutus"`
+	IDUser         *int            `json:"id_user"`
+	NamaUser       string          `json:"nama_user"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// PenawaranRequest - Request buat penawaran baru
+type PenawaranRequest struct {
+	BiayaJasa   float64         `json:"biaya_jasa"`
+	Item        []ItemPenawaran `json:"item"`
+	BerlakuHari int             `json:"berlaku_hari"` // 0 = default dari config
+	Catatan     string          `json:"catatan"`
+}
+
+// KeputusanPenawaranRequest - Setuju / tolak penawaran. Kanal diisi pegawai yang
+// mencatat keputusan lewat WhatsApp / telepon / langsung, pelanggan via link tidak perlu.
+type KeputusanPenawaranRequest struct {
+	Setuju bool   `json:"setuju"`
+	Kanal  string `json:"kanal"`
+	Alasan string `json:"alasan"`
+}
+
+// OverrideRequest - Alasan admin mengizinkan pemakaian barang tanpa persetujuan
+type OverrideRequest struct {
+	Alasan string `json:"alasan"`
+}
+
+// PersetujuanServis - Status persetujuan biaya satu servis beserta riwayat penawarannya
+type PersetujuanServis struct {
+	IDServis         int         `json:"id_servis"`
+	StatusPenawaran  string      `json:"status_penawaran"` // status penawaran terakhir, "" jika belum ada
+	BolehPakaiBarang bool        `json:"boleh_pakai_barang"`
+	Override         *Override   `json:"override"`
+	Penawaran        []Penawaran `json:"penawaran"`
+}
+
+// Override - Izin admin memakai barang tanpa persetujuan pelanggan
+type Override struct {
+	IDUser   *int      `json:"id_user"`
+	NamaUser string    `json:"nama_user"`
+	Waktu    time.Time `json:"waktu"`
+	Alasan   string    `json:"alasan"`
+}
+
+// PenawaranPublik - Penawaran untuk pelanggan di halaman tracking
+type PenawaranPublik struct {
+	KodeTracking  string          `json:"kode_tracking"`
+	TipeHP        string          `json:"tipe_hp"`
+	Status        string          `json:"status"`
+	BiayaJasa     float64         `json:"biaya_jasa"`
+	Item          []ItemPenawaran `json:"item"`
+	Total         float64         `json:"total"`
+	Catatan       string          `json:"catatan"`
+	BerlakuSampai time.Time       `json:"berlaku_sampai"`
+	DiputuskanAt  *time.Time      `json:"diputuskan_at"`
+}
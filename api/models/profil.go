@@ -0,0 +1,37 @@
+package models
+
+// Profil - data akun user yang sedang login (GET /api/me)
+type Profil struct {
+	IDUser       int      `json:"id_user"`
+	Nama         string   `json:"nama"`
+	Username     string   `json:"username"`
+	Role         string   `json:"role"`  // admin, pegawai
+	Akses        string   `json:"akses"` // admin, kasir, teknisi
+	Permissions  []string `json:"permissions"`
+	IDPegawai    *int     `json:"id_pegawai"`
+	Jabatan      string   `json:"jabatan"`
+	Alamat       string   `json:"alamat"`
+	NoHP         string   `json:"no_hp"`
+	TanggalMasuk string   `json:"tanggal_masuk"`
+	Status       string   `json:"status"`
+}
+
+// UpdateProfilRequest - field yang boleh diubah sendiri (PUT /api/me).
+// Jabatan & status hanya bisa diubah admin.
+type UpdateProfilRequest struct {
+	Nama   *string `json:"nama"`
+	Alamat *string `json:"alamat"`
+	NoHP   *string `json:"no_hp"`
+}
+
+// GantiPasswordRequest - PUT /api/me/password
+type GantiPasswordRequest struct {
+	PasswordLama string `json:"password_lama"`
+	PasswordBaru string `json:"password_baru"`
+}
+
+// ResetPasswordRequest - POST /api/password/reset dengan token dari admin
+type ResetPasswordRequest struct {
+	Token        string `json:"token"`
+	PasswordBaru string `json:"password_baru"`
+}
@@ -2,13 +2,24 @@ package models
 
 type Servis struct {
     IDServis       int             `json:"id_servis"`
-    NamaPelanggan  string          `json:"nama_pelanggan"`
+    IDPelanggan    *int            `json:"id_pelanggan"`
+    IDTeknisi      *int            `json:"id_teknisi"` // diisi saat create, selanjutnya lewat PATCH .../teknisi
+    NamaTeknisi    string          `json:"nama_teknisi"`
+    IDServisAsal   *int            `json:"id_servis_asal"` // terisi jika servis ini klaim garansi
+    KodeTracking   string          `json:"kode_tracking"` // Auto: dicetak di nota
+    Pelanggan      *Pelanggan      `json:"pelanggan,omitempty"` // data pelanggan baru (inline) saat create
+    NamaPelanggan  string          `json:"nama_pelanggan"` // salinan dari pelanggan
     NoWhatsapp     string          `json:"no_whatsapp"`
     TipeHP         string          `json:"tipe_hp"`
     Keluhan        string          `json:"keluhan"`
+    Intake         *ServisIntake   `json:"intake,omitempty"` // checklist penerimaan perangkat
     StatusServis   string          `json:"status_servis"`
     BiayaServis    float64         `json:"biaya_servis"`
     BiayaTotal     float64         `json:"biaya_total"`
+    TotalDibayar   float64         `json:"total_dibayar"` // Auto: dari tabel pembayaran
+    SisaTagihan    float64         `json:"sisa_tagihan"`  // Auto: biaya_total - total_dibayar
+    Penawaran      *Penawaran      `json:"penawaran,omitempty"` // penawaran biaya terakhir (detail saja)
+    DP             *PembayaranRequest `json:"dp,omitempty"` // DP opsional saat create
     TanggalMasuk   string          `json:"tanggal_masuk"`
     TanggalSelesai *string         `json:"tanggal_selesai"`
     Detail         []DetailServis  `json:"detail"`
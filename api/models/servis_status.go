@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Status servis sesuai enum servis.status_servis
+const (
+	StatusPending             = "pending"
+	StatusDalamPerbaikan      = "dalam_perbaikan"
+	StatusSelesai             = "selesai"
+	StatusSiapDiambil         = "siap_diambil"
+	StatusDiambil             = "diambil"
+	StatusBatal               = "batal"
+	StatusTidakBisaDiperbaiki = "tidak_bisa_diperbaiki"
+)
+
+// ServisStatusHistory - Model untuk tabel servis_status_history
+type ServisStatusHistory struct {
+	IDHistory  int       `json:"id_history"`
+	IDServis   int       `json:"id_servis"`
+	StatusLama *string   `json:"status_lama"` // nil saat servis dibuat
+	StatusBaru string    `json:"status_baru"`
+	IDUser     *int      `json:"id_user"`
+	NamaUser   string    `json:"nama_user"`
+	Keterangan string    `json:"keterangan"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// UbahStatusRequest - Request PATCH status servis
+type UbahStatusRequest struct {
+	Status     string `json:"status"`
+	Keterangan string `json:"keterangan"`
+}
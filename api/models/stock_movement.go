@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Jenis mutasi pada kartu stok
+const (
+	MutasiPembelian       = "pembelian"
+	MutasiPemakaianServis = "pemakaian_servis"
+	MutasiPenyesuaian     = "penyesuaian"
+	MutasiRetur           = "retur"
+	MutasiStockOpname     = "stock_opname"
+)
+
+// StockMovement - Model untuk tabel stock_movement (kartu stok)
+type StockMovement struct {
+	IDMovement  int       `json:"id_movement"`
+	IDBarang    int       `json:"id_barang"`
+	Jenis       string    `json:"jenis"`
+	Jumlah      int       `json:"jumlah"` // positif = masuk, negatif = keluar
+	Saldo       int       `json:"saldo"`  // saldo berjalan, dihitung dari ledger
+	IDServis    *int      `json:"id_servis"`
+	IDDetail    *int      `json:"id_detail"`
+	IDPembelian *int      `json:"id_pembelian"`
+	IDUser      *int      `json:"id_user"`
+	Keterangan  string    `json:"keterangan"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// KartuStok - Response kartu stok satu barang
+type KartuStok struct {
+	Barang       Barang          `json:"barang"`
+	SaldoAwal    int             `json:"saldo_awal"`
+	SaldoAkhir   int             `json:"saldo_akhir"`
+	StokTercatat int             `json:"stok_tercatat"` // barang.stok
+	StokLedger   int             `json:"stok_ledger"`   // SUM(jumlah) seluruh ledger
+	Selisih      int             `json:"selisih"`
+	Mutasi       []StockMovement `json:"mutasi"`
+}
+
+// StockOpnameRequest - Request hasil hitung fisik stok
+type StockOpnameRequest struct {
+	StokFisik  int    `json:"stok_fisik"`
+	Keterangan string `json:"keterangan"`
+}
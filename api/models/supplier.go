@@ -0,0 +1,9 @@
+package models
+
+type Supplier struct {
+	IDSupplier   int    `json:"id_supplier"`
+	NamaSupplier string `json:"nama_supplier"`
+	NoTelepon    string `json:"no_telepon"`
+	Alamat       string `json:"alamat"`
+	Keterangan   string `json:"keterangan"`
+}
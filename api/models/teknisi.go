@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// ServisTeknisiHistory - Model untuk tabel servis_teknisi_history
+type ServisTeknisiHistory struct {
+	IDHistory       int       `json:"id_history"`
+	IDServis        int       `json:"id_servis"`
+	IDTeknisiLama   *int      `json:"id_teknisi_lama"`
+	NamaTeknisiLama string    `json:"nama_teknisi_lama"`
+	IDTeknisiBaru   *int      `json:"id_teknisi_baru"` // nil saat penugasan dilepas
+	NamaTeknisiBaru string    `json:"nama_teknisi_baru"`
+	IDUser          *int      `json:"id_user"`
+	NamaUser        string    `json:"nama_user"`
+	Keterangan      string    `json:"keterangan"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// AssignTeknisiRequest - Request PATCH teknisi servis, id_teknisi null untuk melepas penugasan
+type AssignTeknisiRequest struct {
+	IDTeknisi  *int   `json:"id_teknisi"`
+	Keterangan string `json:"keterangan"`
+}
+
+// BebanTeknisi - Beban kerja per teknisi untuk dashboard
+type BebanTeknisi struct {
+	IDPegawai     int     `json:"id_pegawai"`
+	NamaTeknisi   string  `json:"nama_teknisi"`
+	ServisAktif   int     `json:"servis_aktif"`   // pending + dalam_perbaikan
+	ServisSelesai int     `json:"servis_selesai"` // selesai bulan ini
+	RataRataJam   float64 `json:"rata_rata_jam"`  // rata-rata lama perbaikan bulan ini
+}
@@ -0,0 +1,14 @@
+package models
+
+// TokenResponse - pasangan access & refresh token yang dikirim ke client
+type TokenResponse struct {
+	Token        string `json:"token"` // access token (JWT)
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // umur access token dalam detik
+}
+
+// RefreshRequest - body POST /api/refresh & /api/logout
+type RefreshRequest struct {
+	RefreshToken   string `json:"refresh_token"`
+	SemuaPerangkat bool   `json:"semua_perangkat"` // logout: cabut semua sesi user
+}
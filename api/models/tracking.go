@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// TrackingServis - Tampilan servis untuk publik (tanpa nomor HP, keluhan & rincian biaya)
+type TrackingServis struct {
+	KodeTracking    string           `json:"kode_tracking"`
+	NamaPelanggan   string           `json:"nama_pelanggan"` // disamarkan
+	TipeHP          string           `json:"tipe_hp"`
+	StatusServis    string           `json:"status_servis"`
+	EstimasiBiaya   float64          `json:"estimasi_biaya"`
+	TanggalMasuk    string           `json:"tanggal_masuk"`
+	TanggalSelesai  *string          `json:"tanggal_selesai"`
+	KlaimGaransi    bool             `json:"klaim_garansi"`
+	StatusPenawaran string           `json:"status_penawaran"` // "" jika belum ada penawaran biaya
+	Garansi         []GaransiItem    `json:"garansi"`
+	Timeline        []TrackingStatus `json:"timeline"`
+}
+
+// TrackingStatus - Satu langkah pada timeline status servis
+type TrackingStatus struct {
+	Status string    `json:"status"`
+	Waktu  time.Time `json:"waktu"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Undangan - Model untuk tabel undangan (kode hanya ditampilkan sekali saat dibuat)
+type Undangan struct {
+	IDUndangan   int        `json:"id_undangan"`
+	Kode         string     `json:"kode,omitempty"`
+	Jabatan      string     `json:"jabatan"`
+	Keterangan   string     `json:"keterangan"`
+	DibuatOleh   *int       `json:"dibuat_oleh"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	DipakaiOleh  *int       `json:"dipakai_oleh"`
+	DipakaiAt    *time.Time `json:"dipakai_at"`
+	DibatalkanAt *time.Time `json:"dibatalkan_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	Status       string     `json:"status"` // aktif, dipakai, kedaluwarsa, dibatalkan
+}
+
+// BuatUndanganRequest - Request admin membuat kode undangan
+type BuatUndanganRequest struct {
+	Jabatan    string `json:"jabatan"`
+	Keterangan string `json:"keterangan"`
+	BerlakuJam int    `json:"berlaku_jam"` // default 72 jam
+}
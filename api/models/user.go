@@ -7,3 +7,11 @@ type User struct {
     Password string `json:"password"`
     Role     string `json:"role"` // admin, pegawai
 }
+
+// SignupRequest - Request signup pegawai
+type SignupRequest struct {
+    Nama         string `json:"nama"`
+    Username     string `json:"username"`
+    Password     string `json:"password"`
+    KodeUndangan string `json:"kode_undangan"` // wajib jika SIGNUP_MODE=undangan
+}
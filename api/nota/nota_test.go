@@ -0,0 +1,148 @@
+package nota
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"service_hp/models"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestEscapePDF(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"Nota 001", "Nota 001"},
+		{"(LCD) \\ baru", "\\(LCD\\) \\\\ baru"},
+		{"Café", "Caf\\351"},
+		{"Rp 150.000 ✓", "Rp 150.000 ?"},
+		{"baris\nbaru", "baris?baru"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := escapePDF(tt.input); got != tt.want {
+				t.Errorf("escapePDF(%q) = %q, seharusnya %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBungkus(t *testing.T) {
+	tests := []struct {
+		nama  string
+		s     string
+		lebar int
+		want  []string
+	}{
+		{"muat satu baris", "Ganti LCD", 20, []string{"Ganti LCD"}},
+		{"dipecah per kata", "Ganti LCD dan baterai original", 12, []string{"Ganti LCD", "dan baterai", "original"}},
+		{"kata terlalu panjang", "IMEI 490154203237518", 6, []string{"IMEI", "490154", "203237", "518"}},
+		{"paragraf dipertahankan", "baris satu\nbaris dua", 20, []string{"baris satu", "baris dua"}},
+		{"spasi berlebih", "  Ganti   LCD  ", 20, []string{"Ganti LCD"}},
+		{"kosong", "", 10, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := bungkus(tt.s, tt.lebar); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("bungkus(%q, %d) = %q, seharusnya %q", tt.s, tt.lebar, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRupiah(t *testing.T) {
+	tests := []struct {
+		v    float64
+		want string
+	}{
+		{0, "Rp 0"},
+		{500, "Rp 500"},
+		{150000, "Rp 150.000"},
+		{1250000, "Rp 1.250.000"},
+		{-25000.5, "-Rp 25.000,50"},
+		{99.999, "Rp 100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := rupiah(tt.v); got != tt.want {
+				t.Errorf("rupiah(%v) = %q, seharusnya %q", tt.v, got, tt.want)
+			}
+		})
+	}
+}
+
+var offsetObjek = regexp.MustCompile(`(?m)^(\d{10}) 00000 n $`)
+
+// cekStrukturPDF memastikan header, trailer dan tabel xref menunjuk ke objek yang benar
+func cekStrukturPDF(t *testing.T, pdf []byte) {
+	t.Helper()
+
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) {
+		t.Fatal("header PDF tidak ada")
+	}
+	if !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatal("PDF tidak diakhiri EOF")
+	}
+
+	i := bytes.LastIndex(pdf, []byte("startxref\n"))
+	if i < 0 {
+		t.Fatal("startxref tidak ada")
+	}
+	xref, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(string(pdf[i+len("startxref\n"):]), "%%EOF\n")))
+	if err != nil || !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d tidak menunjuk ke tabel xref", xref)
+	}
+
+	objek := offsetObjek.FindAllSubmatch(pdf[xref:], -1)
+	if len(objek) < 6 {
+		t.Fatalf("xref hanya berisi %d objek", len(objek))
+	}
+	for n, m := range objek {
+		off, _ := strconv.Atoi(string(m[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", n+1); !bytes.HasPrefix(pdf[off:], []byte(want)) {
+			t.Errorf("xref objek %d menunjuk ke offset %d yang bukan %q", n+1, off, want)
+		}
+	}
+}
+
+func TestPDF(t *testing.T) {
+	d := models.NotaData{
+		Nomor:         "INV/2024/000123",
+		TanggalTerbit: "2024-05-01",
+		NamaToko:      "Servis HP (Pusat)",
+		IDServis:      123,
+		NamaPelanggan: "Budi",
+		TipeHP:        "Samsung A52",
+		Keluhan:       "LCD pecah, touchscreen tidak respon di bagian bawah layar",
+		Item:          []models.NotaItem{{Deskripsi: "LCD Samsung A52 original", Jumlah: 1, HargaSatuan: 850000, Biaya: 850000}},
+		BiayaServis:   150000,
+		BiayaTotal:    1000000,
+		TotalDibayar:  500000,
+		SisaTagihan:   500000,
+	}
+
+	for _, format := range []string{models.NotaA4, models.NotaThermal58, models.NotaThermal80} {
+		t.Run(format, func(t *testing.T) {
+			pdf, err := PDF(d, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cekStrukturPDF(t, pdf)
+
+			ulang, _ := PDF(d, format)
+			if !bytes.Equal(pdf, ulang) {
+				t.Error("data yang sama menghasilkan PDF berbeda")
+			}
+		})
+	}
+
+	if _, err := PDF(d, "a5"); err == nil {
+		t.Error("format tidak dikenal seharusnya error")
+	}
+}
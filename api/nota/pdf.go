@@ -0,0 +1,93 @@
+package nota
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// lebarGlyph - lebar semua glyph Courier dalam satuan ukuran font (monospace)
+const lebarGlyph = 0.6
+
+// halaman - satu halaman PDF berisi perintah teks
+type halaman struct {
+	lebar, tinggi float64
+	isi           bytes.Buffer
+}
+
+// teks menulis satu baris di posisi (x, y) dari kiri bawah halaman.
+// F1 = Courier, F2 = Courier-Bold.
+func (h *halaman) teks(x, y, ukuran float64, tebal bool, s string) {
+	font := "F1"
+	if tebal {
+		font = "F2"
+	}
+	fmt.Fprintf(&h.isi, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, ukuran, x, y, escapePDF(s))
+}
+
+// dokumen - PDF 1.4 minimal dengan font standar (tanpa embed) sehingga hasilnya
+// deterministik: data yang sama selalu menghasilkan byte yang sama.
+type dokumen struct {
+	halaman []*halaman
+}
+
+func (d *dokumen) tambahHalaman(lebar, tinggi float64) *halaman {
+	h := &halaman{lebar: lebar, tinggi: tinggi}
+	d.halaman = append(d.halaman, h)
+	return h
+}
+
+func (d *dokumen) bytes() []byte {
+	var buf bytes.Buffer
+	var offset []int
+
+	obj := func(isi string) {
+		offset = append(offset, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offset), isi)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1 catalog, 2 pages, 3-4 font, lalu pasangan page + content per halaman
+	kids := make([]string, len(d.halaman))
+	for i := range d.halaman {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.halaman)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, h := range d.halaman {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", h.lebar, h.tinggi, 6+i*2))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", h.isi.Len(), h.isi.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offset)+1)
+	for _, o := range offset {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offset)+1, xref)
+	return buf.Bytes()
+}
+
+// escapePDF - string literal PDF dalam WinAnsi; karakter di luar Latin-1 diganti '?'
+func escapePDF(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 32 && r < 127:
+			b.WriteRune(r)
+		case r >= 160 && r <= 255:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,251 @@
+// Package nota merender nota servis dan tanda terima servis (A4 & struk thermal 58/80 mm)
+// menjadi PDF dari data models.
+package nota
+
+import (
+	"fmt"
+	"math"
+	"service_hp/models"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tata letak per format: ukuran kertas (pt), margin, jumlah kolom karakter
+type tataLetak struct {
+	lebar, tinggi float64 // tinggi 0 = kertas gulung, tinggi mengikuti isi
+	margin        float64
+	kolom         int
+}
+
+const mmKePt = 72 / 25.4
+
+var formatCetak = map[string]tataLetak{
+	models.NotaA4:        {lebar: 595.28, tinggi: 841.89, margin: 42, kolom: 80},
+	models.NotaThermal58: {lebar: 58 * mmKePt, margin: 6, kolom: 32},
+	models.NotaThermal80: {lebar: 80 * mmKePt, margin: 6, kolom: 48},
+}
+
+// FormatValid - true jika format cetak dikenal
+func FormatValid(format string) bool {
+	_, ok := formatCetak[format]
+	return ok
+}
+
+// PDF merender nota dalam format a4, 58, atau 80
+func PDF(d models.NotaData, format string) ([]byte, error) {
+	t, ok := formatCetak[format]
+	if !ok {
+		return nil, fmt.Errorf("format nota tidak dikenal: %s", format)
+	}
+
+	b := &penulis{kolom: t.kolom}
+	tulisNota(b, d)
+	return cetak(b, t), nil
+}
+
+// cetak menata baris penulis ke halaman PDF sesuai tata letak
+func cetak(b *penulis, t tataLetak) []byte {
+	ukuran := (t.lebar - 2*t.margin) / (float64(t.kolom) * lebarGlyph)
+	jarak := ukuran * 1.3
+
+	var doc dokumen
+	if t.tinggi == 0 {
+		h := doc.tambahHalaman(t.lebar, 2*t.margin+float64(len(b.baris))*jarak)
+		for i, br := range b.baris {
+			h.teks(t.margin, h.tinggi-t.margin-float64(i+1)*jarak+jarak*0.25, ukuran, br.tebal, br.teks)
+		}
+		return doc.bytes()
+	}
+
+	perHalaman := int((t.tinggi - 2*t.margin) / jarak)
+	for awal := 0; awal < len(b.baris); awal += perHalaman {
+		h := doc.tambahHalaman(t.lebar, t.tinggi)
+		for i := awal; i < awal+perHalaman && i < len(b.baris); i++ {
+			br := b.baris[i]
+			h.teks(t.margin, t.tinggi-t.margin-float64(i-awal+1)*jarak+jarak*0.25, ukuran, br.tebal, br.teks)
+		}
+	}
+	return doc.bytes()
+}
+
+// kopToko - nama, alamat & telepon toko di kepala nota / tanda terima
+func (p *penulis) kopToko(nama, alamat, telepon string) {
+	p.tengah(nama, true)
+	p.tengah(alamat, false)
+	if telepon != "" {
+		p.tengah("Telp. "+telepon, false)
+	}
+	p.garis("=")
+}
+
+// tulisNota menyusun isi nota baris per baris
+func tulisNota(b *penulis, d models.NotaData) {
+	b.kopToko(d.NamaToko, d.AlamatToko, d.TeleponToko)
+	b.tengah("NOTA SERVIS", true)
+	b.tengah(d.Nomor, true)
+	b.garis("=")
+
+	b.info("Tanggal", d.TanggalTerbit)
+	b.info("Kode", d.KodeTracking)
+	b.info("Pelanggan", d.NamaPelanggan)
+	b.info("No. WA", d.NoWhatsapp)
+	b.info("Tipe HP", d.TipeHP)
+	b.info("Teknisi", d.NamaTeknisi)
+	b.info("Masuk", d.TanggalMasuk)
+	b.info("Selesai", d.TanggalSelesai)
+	b.info("Status", strings.ReplaceAll(d.StatusServis, "_", " "))
+	if d.Keluhan != "" {
+		b.tulis("Keluhan:", false)
+		b.tulis("  "+d.Keluhan, false)
+	}
+	b.garis("-")
+
+	for _, it := range d.Item {
+		b.tulis(it.Deskripsi, false)
+		b.kiriKanan(fmt.Sprintf("  %d x %s", it.Jumlah, rupiah(it.HargaSatuan)), rupiah(it.Biaya), false)
+	}
+	b.kiriKanan("Biaya jasa", rupiah(d.BiayaServis), false)
+	b.garis("-")
+	b.kiriKanan("TOTAL", rupiah(d.BiayaTotal), true)
+
+	if len(d.Pembayaran) > 0 {
+		b.garis("-")
+		for _, p := range d.Pembayaran {
+			jumlah := p.Jumlah
+			if p.Jenis == models.PembayaranRefund {
+				jumlah = -jumlah
+			}
+			b.kiriKanan(fmt.Sprintf("%s %s (%s)", p.Tanggal, strings.ToUpper(p.Jenis), p.Metode), rupiah(jumlah), false)
+		}
+		b.kiriKanan("Total dibayar", rupiah(d.TotalDibayar), false)
+	}
+	switch {
+	case d.SisaTagihan > 0:
+		b.kiriKanan("SISA TAGIHAN", rupiah(d.SisaTagihan), true)
+	case d.SisaTagihan < 0:
+		b.kiriKanan("KELEBIHAN BAYAR", rupiah(-d.SisaTagihan), true)
+	default:
+		b.tengah("*** LUNAS ***", true)
+	}
+
+	b.garis("=")
+	b.tengah(d.CatatanKaki, false)
+}
+
+// baris - satu baris teks monospace
+type baris struct {
+	teks  string
+	tebal bool
+}
+
+// penulis - penyusun baris teks dengan lebar kolom tetap
+type penulis struct {
+	kolom int
+	baris []baris
+}
+
+func panjang(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+// tulis menambah teks rata kiri, dipotong per kata jika melebihi kolom
+func (p *penulis) tulis(s string, tebal bool) {
+	for _, br := range bungkus(s, p.kolom) {
+		p.baris = append(p.baris, baris{br, tebal})
+	}
+}
+
+func (p *penulis) tengah(s string, tebal bool) {
+	for _, br := range bungkus(s, p.kolom) {
+		p.baris = append(p.baris, baris{strings.Repeat(" ", (p.kolom-panjang(br))/2) + br, tebal})
+	}
+}
+
+func (p *penulis) garis(c string) {
+	p.baris = append(p.baris, baris{strings.Repeat(c, p.kolom), false})
+}
+
+func (p *penulis) kosong(n int) {
+	for i := 0; i < n; i++ {
+		p.baris = append(p.baris, baris{})
+	}
+}
+
+// kiriKanan - label rata kiri dan nilai rata kanan; label panjang diberi baris sendiri
+func (p *penulis) kiriKanan(kiri, kanan string, tebal bool) {
+	sisa := p.kolom - panjang(kanan) - 1
+	if panjang(kiri) > sisa {
+		p.tulis(kiri, tebal)
+		kiri = ""
+	}
+	p.baris = append(p.baris, baris{kiri + strings.Repeat(" ", p.kolom-panjang(kiri)-panjang(kanan)) + kanan, tebal})
+}
+
+// info - "Label     : nilai", baris lanjutan menjorok sejajar nilai
+func (p *penulis) info(label, nilai string) {
+	if nilai == "" {
+		nilai = "-"
+	}
+	awal := fmt.Sprintf("%-10s: ", label)
+	for i, br := range bungkus(nilai, p.kolom-panjang(awal)) {
+		if i > 0 {
+			awal = strings.Repeat(" ", panjang(awal))
+		}
+		p.baris = append(p.baris, baris{awal + br, false})
+	}
+}
+
+// bungkus memecah s per kata menjadi baris selebar maksimal lebar; kata yang terlalu panjang dipotong
+func bungkus(s string, lebar int) []string {
+	var hasil []string
+	for _, paragraf := range strings.Split(s, "\n") {
+		var cur string
+		for _, kata := range strings.Fields(paragraf) {
+			for panjang(kata) > lebar {
+				if cur != "" {
+					hasil = append(hasil, cur)
+					cur = ""
+				}
+				r := []rune(kata)
+				hasil = append(hasil, string(r[:lebar]))
+				kata = string(r[lebar:])
+			}
+			switch {
+			case cur == "":
+				cur = kata
+			case panjang(cur)+1+panjang(kata) <= lebar:
+				cur += " " + kata
+			default:
+				hasil = append(hasil, cur)
+				cur = kata
+			}
+		}
+		if cur != "" {
+			hasil = append(hasil, cur)
+		}
+	}
+	return hasil
+}
+
+// rupiah: 150000 -> "Rp 150.000", -25000.5 -> "-Rp 25.000,50"
+func rupiah(v float64) string {
+	tanda := ""
+	if v < 0 {
+		tanda = "-"
+		v = -v
+	}
+	sen := int64(math.Round(v * 100))
+	s := strconv.FormatInt(sen/100, 10)
+	var out []byte
+	for i := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			out = append(out, '.')
+		}
+		out = append(out, s[i])
+	}
+	if sen%100 != 0 {
+		return fmt.Sprintf("%sRp %s,%02d", tanda, out, sen%100)
+	}
+	return fmt.Sprintf("%sRp %s", tanda, out)
+}
@@ -0,0 +1,116 @@
+package nota
+
+import (
+	"fmt"
+	"service_hp/models"
+	"strings"
+)
+
+// TandaTerimaPDF merender tanda terima servis (saat perangkat diterima) dalam format a4, 58, atau 80
+func TandaTerimaPDF(d models.TandaTerima, format string) ([]byte, error) {
+	t, ok := formatCetak[format]
+	if !ok {
+		return nil, fmt.Errorf("format tanda terima tidak dikenal: %s", format)
+	}
+
+	b := &penulis{kolom: t.kolom}
+	tulisTandaTerima(b, d)
+	return cetak(b, t), nil
+}
+
+// centang - "[x] label" / "[ ] label", baris lanjutan menjorok sejajar label
+func (p *penulis) centang(ya bool, label string) {
+	awal := "[ ] "
+	if ya {
+		awal = "[x] "
+	}
+	for i, br := range bungkus(label, p.kolom-len(awal)) {
+		if i > 0 {
+			awal = strings.Repeat(" ", len(awal))
+		}
+		p.baris = append(p.baris, baris{awal + br, false})
+	}
+}
+
+// tulisTandaTerima menyusun isi tanda terima baris per baris. Kode kunci layar tidak pernah dicetak.
+func tulisTandaTerima(b *penulis, d models.TandaTerima) {
+	b.kopToko(d.NamaToko, d.AlamatToko, d.TeleponToko)
+	b.tengah("TANDA TERIMA SERVIS", true)
+	b.tengah(d.KodeTracking, true)
+	b.garis("=")
+
+	b.info("No. Servis", fmt.Sprintf("#%d", d.IDServis))
+	b.info("Masuk", d.TanggalMasuk)
+	b.info("Pelanggan", d.NamaPelanggan)
+	b.info("No. WA", d.NoWhatsapp)
+	b.info("Tipe HP", d.TipeHP)
+
+	in := d.Intake
+	if in != nil {
+		b.info("Merek", strings.TrimSpace(in.Merek+" "+in.Model))
+		b.info("Warna", in.Warna)
+		b.info("IMEI", in.IMEI)
+		b.info("S/N", in.SerialNumber)
+	}
+	if d.Keluhan != "" {
+		b.tulis("Keluhan:", false)
+		b.tulis("  "+d.Keluhan, false)
+	}
+
+	if in != nil {
+		b.garis("-")
+		b.tulis("Kelengkapan:", true)
+		b.centang(in.Kelengkapan.Charger, "Charger")
+		b.centang(in.Kelengkapan.Casing, "Casing")
+		b.centang(in.Kelengkapan.SimCard, "SIM card")
+		b.centang(in.Kelengkapan.MemoryCard, "Memory card")
+		if in.Kelengkapan.Lainnya != "" {
+			b.tulis("Lainnya: "+in.Kelengkapan.Lainnya, false)
+		}
+
+		b.tulis("Kondisi fisik:", true)
+		if in.KondisiFisik != "" {
+			b.tulis("  "+in.KondisiFisik, false)
+		} else {
+			b.tulis("  -", false)
+		}
+
+		kunci := strings.ToUpper(strings.ReplaceAll(in.KunciLayar, "_", " "))
+		if in.KunciLayar != models.KunciTidakAda {
+			if in.KodeKunciTersimpan {
+				kunci += " (kode dititipkan)"
+			} else {
+				kunci += " (kode tidak dititipkan)"
+			}
+		}
+		b.info("Kunci", kunci)
+		if in.Catatan != "" {
+			b.info("Catatan", in.Catatan)
+		}
+
+		b.garis("-")
+		b.tulis("Pelanggan menyetujui:", true)
+		b.centang(in.Persetujuan.RisikoData, "Data di perangkat bisa hilang")
+		b.centang(in.Persetujuan.BukaSegel, "Segel/garansi pabrik bisa batal")
+		b.centang(in.Persetujuan.TanpaCek, "Perangkat diterima tanpa bisa dicek")
+	}
+
+	if d.BiayaTotal > 0 || d.TotalDibayar > 0 {
+		b.garis("-")
+		if d.BiayaTotal > 0 {
+			b.kiriKanan("Estimasi biaya", rupiah(d.BiayaTotal), false)
+		}
+		if d.TotalDibayar > 0 {
+			b.kiriKanan("Uang muka", rupiah(d.TotalDibayar), false)
+		}
+	}
+
+	b.garis("=")
+	b.tengah(d.CatatanKaki, false)
+
+	// tempat tanda tangan
+	b.kosong(2)
+	b.kiriKanan("Pelanggan", "Petugas", false)
+	b.kosong(3)
+	b.kiriKanan("(__________)", "(__________)", false)
+}
@@ -0,0 +1,42 @@
+package notifikasi
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogNotifier tidak benar-benar mengirim, hanya menulis pesan ke file atau log server.
+// Dipakai untuk development & testing.
+type LogNotifier struct {
+	Path string // kosong = tulis ke log server
+	mu   sync.Mutex
+}
+
+func NewLogNotifier(path string) *LogNotifier {
+	return &LogNotifier{Path: path}
+}
+
+func (n *LogNotifier) Name() string { return "log" }
+
+func (n *LogNotifier) Send(ctx context.Context, p Pesan) error {
+	if n.Path == "" {
+		log.Printf(" [notifikasi #%d] ke %s:\n%s", p.ID, p.Tujuan, p.Isi)
+		return nil
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "[%s] #%d ke %s\n%s\n\n", time.Now().Format(time.RFC3339), p.ID, p.Tujuan, p.Isi)
+	return err
+}
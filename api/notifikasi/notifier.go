@@ -0,0 +1,44 @@
+// Package notifikasi mengirim pesan WhatsApp ke pelanggan lewat gateway yang bisa diganti.
+// Pesan dicatat dulu di tabel notifikasi_outbox (dalam transaksi yang sama dengan
+// perubahan servis), lalu dikirim oleh worker dengan retry.
+package notifikasi
+
+import (
+	"context"
+	"fmt"
+	"service_hp/config"
+)
+
+// Pesan - satu pesan yang dikirim ke gateway
+type Pesan struct {
+	ID     int    // id_notifikasi, dipakai gateway sebagai referensi
+	Tujuan string // nomor WhatsApp format 62xxxx
+	Isi    string
+}
+
+// Notifier - gateway pengirim pesan WhatsApp
+type Notifier interface {
+	Name() string
+	Send(ctx context.Context, p Pesan) error
+}
+
+// Enabled false jika notifikasi dimatikan lewat NOTIF_DRIVER=nonaktif
+func Enabled() bool {
+	return config.Cfg.Notif.Driver != "nonaktif"
+}
+
+// NewFromConfig membuat Notifier sesuai NOTIF_DRIVER
+func NewFromConfig() (Notifier, error) {
+	cfg := config.Cfg.Notif
+	switch cfg.Driver {
+	case "log":
+		return NewLogNotifier(cfg.LogFile), nil
+	case "webhook":
+		if cfg.WebhookURL == "" {
+			return nil, fmt.Errorf("NOTIF_WEBHOOK_URL wajib diisi untuk driver webhook")
+		}
+		return NewWebhookNotifier(cfg.WebhookURL, cfg.WebhookToken), nil
+	default:
+		return nil, fmt.Errorf("NOTIF_DRIVER tidak dikenal: %s", cfg.Driver)
+	}
+}
@@ -0,0 +1,57 @@
+package notifikasi
+
+import (
+	"database/sql"
+	"service_hp/config"
+	"service_hp/models"
+)
+
+// execQueryer dipenuhi *sql.DB maupun *sql.Tx
+type execQueryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Enqueue menaruh notifikasi servis ke outbox. Dipanggil di dalam transaksi perubahan
+// servis supaya notifikasi hanya tercatat jika perubahannya ikut tersimpan.
+// Servis tanpa nomor WhatsApp dilewati.
+func Enqueue(q execQueryer, idServis int, jenis string) error {
+	if !Enabled() {
+		return nil
+	}
+
+	var data DataPesan
+	var tujuan string
+	err := q.QueryRow(`
+		SELECT s.nama_pelanggan, s.no_whatsapp, s.tipe_hp, s.kode_tracking, s.biaya_total,
+			COALESCE((SELECT pw.total FROM penawaran pw WHERE pw.id_servis = s.id_servis ORDER BY pw.id_penawaran DESC LIMIT 1), 0)
+		FROM servis s WHERE s.id_servis = ?
+	`, idServis).Scan(&data.NamaPelanggan, &tujuan, &data.TipeHP, &data.KodeTracking, &data.BiayaTotal, &data.Penawaran)
+	if err != nil {
+		return err
+	}
+	if tujuan == "" {
+		return nil
+	}
+	data.NamaToko = config.Cfg.Notif.NamaToko
+
+	isi, err := Render(jenis, data)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Exec(`
+		INSERT INTO notifikasi_outbox (id_servis, jenis, tujuan, pesan, status, jadwal_kirim)
+		VALUES (?, ?, ?, ?, ?, NOW())
+	`, idServis, jenis, tujuan, isi, models.NotifStatusPending)
+	return err
+}
+
+// EnqueueStatus menaruh notifikasi untuk status servis baru, jika status tersebut dinotifikasi
+func EnqueueStatus(q execQueryer, idServis int, status string) error {
+	jenis, ok := JenisUntukStatus(status)
+	if !ok {
+		return nil
+	}
+	return Enqueue(q, idServis, jenis)
+}
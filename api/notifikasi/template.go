@@ -0,0 +1,69 @@
+package notifikasi
+
+import (
+	"bytes"
+	"fmt"
+	"service_hp/models"
+	"strconv"
+	"text/template"
+)
+
+// DataPesan - data servis yang bisa dipakai di template pesan
+type DataPesan struct {
+	NamaToko      string
+	NamaPelanggan string
+	TipeHP        string
+	KodeTracking  string
+	BiayaTotal    float64
+	Penawaran     float64 // total penawaran terakhir
+}
+
+var templatePesan = template.Must(template.New("pesan").Funcs(template.FuncMap{
+	"rupiah": formatRupiah,
+}).Parse(`
+{{define "diterima"}}Halo {{.NamaPelanggan}}, HP {{.TipeHP}} Anda sudah kami terima di {{.NamaToko}}.
+Kode tracking: {{.KodeTracking}}
+Simpan kode ini untuk cek status servis.{{end}}
+{{define "dalam_perbaikan"}}Halo {{.NamaPelanggan}}, HP {{.TipeHP}} Anda (kode {{.KodeTracking}}) sedang dalam perbaikan oleh teknisi {{.NamaToko}}.{{end}}
+{{define "siap_diambil"}}Halo {{.NamaPelanggan}}, HP {{.TipeHP}} Anda (kode {{.KodeTracking}}) sudah siap diambil di {{.NamaToko}}.
+Total biaya: {{rupiah .BiayaTotal}}{{end}}
+{{define "pengingat"}}Halo {{.NamaPelanggan}}, pengingat dari {{.NamaToko}}: HP {{.TipeHP}} Anda (kode {{.KodeTracking}}) masih menunggu untuk diambil.
+Total biaya: {{rupiah .BiayaTotal}}{{end}}
+{{define "penawaran"}}Halo {{.NamaPelanggan}}, estimasi biaya servis HP {{.TipeHP}} Anda (kode {{.KodeTracking}}) di {{.NamaToko}}: {{rupiah .Penawaran}}.
+Mohon setujui atau tolak penawaran lewat halaman tracking dengan kode di atas agar perbaikan bisa dilanjutkan.{{end}}
+`))
+
+// Render isi pesan untuk jenis notifikasi tertentu
+func Render(jenis string, data DataPesan) (string, error) {
+	var buf bytes.Buffer
+	if err := templatePesan.ExecuteTemplate(&buf, jenis, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// JenisUntukStatus memetakan status servis ke jenis notifikasi, false jika status tidak dinotifikasi
+func JenisUntukStatus(status string) (string, bool) {
+	switch status {
+	case models.StatusPending:
+		return models.NotifDiterima, true
+	case models.StatusDalamPerbaikan:
+		return models.NotifDalamPerbaikan, true
+	case models.StatusSiapDiambil:
+		return models.NotifSiapDiambil, true
+	}
+	return "", false
+}
+
+// formatRupiah: 150000 -> "Rp 150.000"
+func formatRupiah(v float64) string {
+	s := strconv.FormatInt(int64(v+0.5), 10)
+	var out []byte
+	for i := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			out = append(out, '.')
+		}
+		out = append(out, s[i])
+	}
+	return fmt.Sprintf("Rp %s", out)
+}
@@ -0,0 +1,60 @@
+package notifikasi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WebhookNotifier mengirim pesan sebagai JSON POST ke gateway WhatsApp HTTP generik
+type WebhookNotifier struct {
+	URL    string
+	Token  string // dikirim sebagai Authorization: Bearer, opsional
+	Client *http.Client
+}
+
+func NewWebhookNotifier(url, token string) *WebhookNotifier {
+	return &WebhookNotifier{
+		URL:    url,
+		Token:  token,
+		Client: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (n *WebhookNotifier) Name() string { return "webhook" }
+
+func (n *WebhookNotifier) Send(ctx context.Context, p Pesan) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"to":        p.Tujuan,
+		"message":   p.Isi,
+		"reference": p.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.Token)
+	}
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("gateway membalas %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package notifikasi
+
+import (
+	"context"
+	"log"
+	"service_hp/config"
+	"service_hp/database"
+	"service_hp/models"
+	"time"
+)
+
+const (
+	intervalKirim     = 15 * time.Second
+	intervalPengingat = time.Hour
+	ukuranBatch       = 20
+	timeoutKirim      = 30 * time.Second
+	// masaKlaim lebih lama dari waktu terburuk mengirim satu batch
+	masaKlaim = ukuranBatch*timeoutKirim + 5*time.Minute
+)
+
+// StartWorker menjalankan pengirim outbox & penjadwal pengingat di background
+func StartWorker(n Notifier) {
+	go func() {
+		kirim := time.NewTicker(intervalKirim)
+		pengingat := time.NewTicker(intervalPengingat)
+		defer kirim.Stop()
+		defer pengingat.Stop()
+
+		jadwalkanPengingat()
+		prosesOutbox(n)
+		for {
+			select {
+			case <-kirim.C:
+				prosesOutbox(n)
+			case <-pengingat.C:
+				jadwalkanPengingat()
+			}
+		}
+	}()
+	log.Println("Worker notifikasi berjalan dengan gateway:", n.Name())
+}
+
+// backoff: 1, 2, 4, 8, ... menit (maks 1 jam) setelah percobaan ke-n gagal
+func backoff(percobaan int) time.Duration {
+	d := time.Minute << uint(percobaan-1)
+	if d <= 0 || d > time.Hour {
+		return time.Hour
+	}
+	return d
+}
+
+type antrian struct {
+	pesan     Pesan
+	percobaan int
+}
+
+// klaimOutbox mengambil notifikasi jatuh tempo dan menandainya 'mengirim' dalam satu
+// transaksi. SKIP LOCKED membuat worker lain melewati baris yang sedang diklaim, jadi
+// satu pesan tidak dikirim dua kali. Klaim yang melewati masaKlaim (worker mati di
+// tengah kirim) dianggap lepas dan boleh diklaim ulang.
+func klaimOutbox() ([]antrian, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT id_notifikasi, tujuan, pesan, percobaan
+		FROM notifikasi_outbox
+		WHERE status IN (?, ?) AND jadwal_kirim <= NOW()
+		ORDER BY jadwal_kirim ASC, id_notifikasi ASC
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED
+	`, models.NotifStatusPending, models.NotifStatusMengirim, ukuranBatch)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []antrian
+	for rows.Next() {
+		var a antrian
+		if err := rows.Scan(&a.pesan.ID, &a.pesan.Tujuan, &a.pesan.Isi, &a.percobaan); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, a := range list {
+		if _, err := tx.Exec(`
+			UPDATE notifikasi_outbox
+			SET status = ?, jadwal_kirim = NOW() + INTERVAL ? SECOND
+			WHERE id_notifikasi = ?
+		`, models.NotifStatusMengirim, int(masaKlaim.Seconds()), a.pesan.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// prosesOutbox mengirim notifikasi yang sudah diklaim worker ini
+func prosesOutbox(n Notifier) {
+	list, err := klaimOutbox()
+	if err != nil {
+		log.Println(" Error klaim outbox notifikasi:", err)
+		return
+	}
+
+	for _, a := range list {
+		ctx, cancel := context.WithTimeout(context.Background(), timeoutKirim)
+		err := n.Send(ctx, a.pesan)
+		cancel()
+
+		percobaan := a.percobaan + 1
+		if err == nil {
+			_, err = database.DB.Exec(`
+				UPDATE notifikasi_outbox
+				SET status = ?, percobaan = ?, gateway = ?, error_terakhir = NULL, terkirim_at = NOW()
+				WHERE id_notifikasi = ?
+			`, models.NotifStatusTerkirim, percobaan, n.Name(), a.pesan.ID)
+			if err != nil {
+				log.Println(" Error update outbox notifikasi:", err)
+			}
+			continue
+		}
+
+		log.Printf(" Gagal kirim notifikasi #%d (percobaan %d): %v", a.pesan.ID, percobaan, err)
+		pesanError := truncate(err.Error(), 255)
+
+		status := models.NotifStatusPending
+		if percobaan >= config.Cfg.Notif.MaxPercobaan {
+			status = models.NotifStatusGagal
+		}
+		_, err = database.DB.Exec(`
+			UPDATE notifikasi_outbox
+			SET status = ?, percobaan = ?, gateway = ?, error_terakhir = ?,
+				jadwal_kirim = NOW() + INTERVAL ? SECOND
+			WHERE id_notifikasi = ?
+		`, status, percobaan, n.Name(), pesanError, int(backoff(percobaan).Seconds()), a.pesan.ID)
+		if err != nil {
+			log.Println(" Error update outbox notifikasi:", err)
+		}
+	}
+}
+
+// truncate memotong s menjadi paling banyak n karakter tanpa memecah karakter UTF-8
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
+
+// jadwalkanPengingat menaruh pengingat untuk HP yang sudah siap diambil
+// lebih dari NOTIF_PENGINGAT_HARI hari, maksimal sekali per jeda tersebut
+func jadwalkanPengingat() {
+	hari := config.Cfg.Notif.PengingatHari
+	rows, err := database.DB.Query(`
+		SELECT s.id_servis
+		FROM servis s
+		WHERE s.status_servis = ?
+			AND s.no_whatsapp <> ''
+			AND (
+				SELECT MAX(h.created_at) FROM servis_status_history h
+				WHERE h.id_servis = s.id_servis AND h.status_baru = ?
+			) <= NOW() - INTERVAL ? DAY
+			AND NOT EXISTS (
+				SELECT 1 FROM notifikasi_outbox n
+				WHERE n.id_servis = s.id_servis AND n.jenis = ?
+					AND n.created_at > NOW() - INTERVAL ? DAY
+			)
+	`, models.StatusSiapDiambil, models.StatusSiapDiambil, hari, models.NotifPengingat, hari)
+	if err != nil {
+		log.Println(" Error query pengingat:", err)
+		return
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if err := Enqueue(database.DB, id, models.NotifPengingat); err != nil {
+			log.Println(" Error jadwalkan pengingat servis", id, ":", err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package notifikasi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		percobaan int
+		want      time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{6, 32 * time.Minute},
+		{7, time.Hour}, // 64 menit dibatasi 1 jam
+		{40, time.Hour},
+		{100, time.Hour}, // overflow tetap 1 jam
+	}
+
+	for _, tt := range tests {
+		if got := backoff(tt.percobaan); got != tt.want {
+			t.Errorf("backoff(%d) = %v, seharusnya %v", tt.percobaan, got, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		nama string
+		s    string
+		n    int
+		want string
+	}{
+		{"lebih pendek", "timeout", 10, "timeout"},
+		{"pas", "timeout", 7, "timeout"},
+		{"dipotong", "connection refused", 10, "connection"},
+		{"multibyte utuh", "gagal kirim — coba lagi", 13, "gagal kirim —"},
+		{"kosong", "", 5, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := truncate(tt.s, tt.n); got != tt.want {
+				t.Errorf("truncate(%q, %d) = %q, seharusnya %q", tt.s, tt.n, got, tt.want)
+			}
+		})
+	}
+}
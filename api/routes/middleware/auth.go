@@ -2,9 +2,12 @@ package middleware
 
 import (
     "context"
+    "database/sql"
+    "log"
     "net/http"
     "strings"
     "service_hp/config"
+    "service_hp/database"
     "github.com/golang-jwt/jwt/v4"
     "fmt"
 )
@@ -17,6 +20,49 @@ const (
     RoleKey        key = "role"
 )
 
+// Sesi - status user terkini dari database, dicek di setiap request
+type Sesi struct {
+    Role         string
+    Akses        string // role efektif: admin, kasir, teknisi (kosong = tanpa izin)
+    TokenVersion int
+    Aktif        bool
+}
+
+// LoadSesi membaca status user. Admin selalu aktif, pegawai aktif jika punya
+// data pegawai berstatus aktif. ok=false jika user tidak ada.
+// Berupa variabel agar test policy route bisa jalan tanpa database.
+var LoadSesi = loadSesi
+
+func loadSesi(userID int) (Sesi, bool, error) {
+    var s Sesi
+    var jabatan, status string
+    err := database.DB.QueryRow(`
+        SELECT u.role, u.token_version, COALESCE(p.jabatan, ''), COALESCE(p.status, '')
+        FROM user u
+        LEFT JOIN pegawai p ON p.id_user = u.id_user
+        WHERE u.id_user = ?
+    `, userID).Scan(&s.Role, &s.TokenVersion, &jabatan, &status)
+    if err == sql.ErrNoRows {
+        return s, false, nil
+    }
+    if err != nil {
+        return s, false, err
+    }
+
+    if s.Role == RoleAdmin {
+        s.Aktif = true
+        s.Akses = AksesAdmin
+        return s, true, nil
+    }
+
+    s.Aktif = strings.EqualFold(status, "aktif")
+    jabatan = strings.ToLower(strings.TrimSpace(jabatan))
+    if s.Aktif && (jabatan == AksesKasir || jabatan == AksesTeknisi) {
+        s.Akses = jabatan
+    }
+    return s, true, nil
+}
+
 func RequireAuth(next http.HandlerFunc) http.HandlerFunc {
     return func(w http.ResponseWriter, r *http.Request) {
 
@@ -39,7 +85,7 @@ func RequireAuth(next http.HandlerFunc) http.HandlerFunc {
             if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
                 return nil, fmt.Errorf("invalid signing method")
             }
-            return []byte(config.JWTSecret), nil
+            return []byte(config.Cfg.JWT.Secret), nil
         })
 
         if err != nil || !token.Valid {
@@ -61,29 +107,63 @@ func RequireAuth(next http.HandlerFunc) http.HandlerFunc {
         }
         userID := int(userIDFloat)
 
-        // Ambil role dari claims
-        role, _ := claims["role"].(string)
+        // Token tanpa versi (sebelum refresh token ada) dianggap sudah dicabut
+        versi, ok := claims["ver"].(float64)
+        if !ok {
+            http.Error(w, "Token revoked", http.StatusUnauthorized)
+            return
+        }
 
-        // Simpan semuanya ke context
+        // User dihapus, dinonaktifkan, atau token dicabut (ganti password / logout semua perangkat)
+        sesi, ada, err := LoadSesi(userID)
+        if err != nil {
+            log.Println(" Error load sesi user:", err)
+            http.Error(w, "Internal error", http.StatusInternalServerError)
+            return
+        }
+        if !ada || !sesi.Aktif {
+            http.Error(w, "User tidak aktif", http.StatusUnauthorized)
+            return
+        }
+        if int(versi) != sesi.TokenVersion {
+            http.Error(w, "Token revoked", http.StatusUnauthorized)
+            return
+        }
+
+        // Simpan semuanya ke context, role diambil dari database bukan dari token
         ctx := r.Context()
         ctx = context.WithValue(ctx, UserContextKey, claims)
         ctx = context.WithValue(ctx, UserIDKey, userID)
-        ctx = context.WithValue(ctx, RoleKey, role)
+        ctx = context.WithValue(ctx, RoleKey, sesi.Role)
+        ctx = context.WithValue(ctx, AksesKey, sesi.Akses)
 
         next(w, r.WithContext(ctx))
     }
 }
 
+// Role user sesuai enum user.role
+const (
+    RoleAdmin   = "admin"
+    RolePegawai = "pegawai"
+)
+
 func RequireRole(role string, next http.HandlerFunc) http.HandlerFunc {
+    return RequireRoles([]string{role}, next)
+}
+
+// RequireRoles mengizinkan request jika role user salah satu dari roles
+func RequireRoles(roles []string, next http.HandlerFunc) http.HandlerFunc {
     return RequireAuth(func(w http.ResponseWriter, r *http.Request) {
 
         roleInToken, _ := r.Context().Value(RoleKey).(string)
 
-        if roleInToken != role {
-            http.Error(w, "Forbidden", http.StatusForbidden)
-            return
+        for _, role := range roles {
+            if roleInToken == role {
+                next(w, r)
+                return
+            }
         }
 
-        next(w, r)
+        http.Error(w, "Forbidden", http.StatusForbidden)
     })
 }
@@ -1,15 +1,26 @@
 package middleware
 
-import "net/http"
+import (
+    "net/http"
+    "service_hp/config"
+)
 
 func CorsMiddleware(next http.Handler) http.Handler {
+    allowed := map[string]bool{}
+    for _, o := range config.Cfg.CORS.AllowedOrigins {
+        allowed[o] = true
+    }
+
     return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
-        // Izinkan akses dari frontend Vite
-        w.Header().Set("Access-Control-Allow-Origin", "http://localhost:5173")
-        w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-        w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-        w.Header().Set("Access-Control-Allow-Credentials", "true")
+        // Izinkan hanya origin frontend yang terdaftar (CORS_ALLOWED_ORIGINS)
+        w.Header().Add("Vary", "Origin")
+        if origin := r.Header.Get("Origin"); allowed[origin] {
+            w.Header().Set("Access-Control-Allow-Origin", origin)
+            w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+            w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+            w.Header().Set("Access-Control-Allow-Credentials", "true")
+        }
 
         // Jika preflight, jangan teruskan ke controller
         if r.Method == http.MethodOptions {
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sort"
+)
+
+// Role efektif untuk permission. Admin dari user.role, kasir & teknisi dari pegawai.jabatan.
+const (
+	AksesAdmin   = "admin"
+	AksesKasir   = "kasir"
+	AksesTeknisi = "teknisi"
+)
+
+// Daftar permission
+const (
+	PermServisView     = "servis.view"
+	PermServisCreate   = "servis.create"
+	PermServisEdit     = "servis.edit"
+	PermServisDelete   = "servis.delete"
+	PermServisKerjakan = "servis.kerjakan" // dalam_perbaikan, selesai, tidak_bisa_diperbaiki
+	PermServisSerahkan = "servis.serahkan" // diambil & batal
+	PermServisAssign   = "servis.assign"   // tugaskan & alihkan teknisi
+	PermPembayaran     = "pembayaran.create"
+
+	PermBarangView      = "barang.view"
+	PermBarangKelola    = "barang.kelola" // tambah, hapus, stok & stock opname
+	PermBarangEditHarga = "barang.edit_harga"
+
+	PermPelangganView   = "pelanggan.view"
+	PermPelangganKelola = "pelanggan.kelola"
+	PermPelangganMerge  = "pelanggan.merge"
+
+	PermPembelianKelola = "pembelian.kelola" // supplier & pembelian
+
+	PermDashboardView = "dashboard.view"
+	PermLaporanView   = "laporan.view"
+	PermLaporanProfit = "laporan.view_profit"
+	PermPegawaiKelola = "pegawai.kelola"
+	PermAuditView     = "audit.view"
+	PermKomisiKelola  = "komisi.kelola" // aturan & kunci periode komisi teknisi
+
+	PermPenawaranPutuskan = "penawaran.putuskan" // catat keputusan pelanggan via WhatsApp / telepon / langsung
+	PermPenawaranOverride = "penawaran.override" // pakai barang tanpa persetujuan penawaran pelanggan
+)
+
+// rolePermissions - permission tiap role efektif. Admin selalu punya semua permission.
+var rolePermissions = map[string][]string{
+	AksesKasir: {
+		PermServisView, PermServisCreate, PermServisEdit, PermServisSerahkan, PermServisAssign, PermPembayaran,
+		PermPenawaranPutuskan,
+		PermBarangView, PermBarangKelola,
+		PermPelangganView, PermPelangganKelola,
+		PermPembelianKelola,
+		PermDashboardView, PermLaporanView,
+	},
+	AksesTeknisi: {
+		PermServisView, PermServisEdit, PermServisKerjakan,
+		PermBarangView,
+		PermPelangganView,
+		PermDashboardView,
+	},
+}
+
+// semuaPermission - gabungan seluruh permission, dipakai untuk admin
+var semuaPermission = []string{
+	PermServisView, PermServisCreate, PermServisEdit, PermServisDelete, PermServisKerjakan, PermServisSerahkan, PermServisAssign, PermPembayaran,
+	PermBarangView, PermBarangKelola, PermBarangEditHarga,
+	PermPelangganView, PermPelangganKelola, PermPelangganMerge,
+	PermPembelianKelola,
+	PermDashboardView, PermLaporanView, PermLaporanProfit, PermPegawaiKelola,
+	PermAuditView, PermKomisiKelola, PermPenawaranPutuskan, PermPenawaranOverride,
+}
+
+const AksesKey key = "akses"
+
+// AksesValid - role efektif yang bisa di-assign admin
+func AksesValid(akses string) bool {
+	return akses == AksesAdmin || akses == AksesKasir || akses == AksesTeknisi
+}
+
+// PermissionsOf mengembalikan permission role efektif, urut abjad
+func PermissionsOf(akses string) []string {
+	var list []string
+	if akses == AksesAdmin {
+		list = append(list, semuaPermission...)
+	} else {
+		list = append(list, rolePermissions[akses]...)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func roleHas(akses, perm string) bool {
+	if akses == AksesAdmin {
+		return true
+	}
+	for _, p := range rolePermissions[akses] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirePermission mengizinkan request jika role efektif user punya permission perm.
+// Role efektif dibaca ulang dari database oleh RequireAuth (jabatan bisa berubah setelah login).
+func RequirePermission(perm string, next http.HandlerFunc) http.HandlerFunc {
+	return RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+		if !HasPermission(r.Context(), perm) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		next(w, r)
+	})
+}
+
+// HasPermission - cek permission tambahan di dalam controller (setelah RequirePermission)
+func HasPermission(ctx context.Context, perm string) bool {
+	akses, _ := ctx.Value(AksesKey).(string)
+	return roleHas(akses, perm)
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// RateLimit membatasi jumlah request per IP dalam satu jendela waktu (fixed window)
+func RateLimit(limit int, window time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	var mu sync.Mutex
+	clients := map[string]*rateWindow{}
+	lastCleanup := time.Now()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ip := ClientIP(r)
+		now := time.Now()
+
+		mu.Lock()
+		// Bersihkan jendela yang sudah kedaluwarsa agar map tidak terus membesar
+		if now.Sub(lastCleanup) > window {
+			for k, v := range clients {
+				if now.Sub(v.start) > window {
+					delete(clients, k)
+				}
+			}
+			lastCleanup = now
+		}
+
+		c, ok := clients[ip]
+		if !ok || now.Sub(c.start) > window {
+			c = &rateWindow{start: now}
+			clients[ip] = c
+		}
+		c.count++
+		count := c.count
+		retryAfter := window - now.Sub(c.start)
+		mu.Unlock()
+
+		if count > limit {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			http.Error(w, "Terlalu banyak permintaan, coba lagi nanti", http.StatusTooManyRequests)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// ClientIP mengambil IP klien dari koneksi
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,64 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Route - satu endpoint beserta policy aksesnya
+type Route struct {
+	Method     string
+	Pattern    string // pola http.ServeMux, mis. /api/pegawai/servis/{id}/status
+	Public     bool   // boleh diakses tanpa login
+	Login      bool   // cukup login, tanpa permission khusus (mis. profil sendiri)
+	Permission string // permission yang dibutuhkan jika tidak public/login
+	Handler    http.HandlerFunc
+}
+
+// public - route tanpa login
+func public(method, pattern string, h http.HandlerFunc) Route {
+	return Route{Method: method, Pattern: pattern, Public: true, Handler: h}
+}
+
+// loggedIn - route untuk semua user yang sudah login dan aktif
+func loggedIn(method, pattern string, h http.HandlerFunc) Route {
+	return Route{Method: method, Pattern: pattern, Login: true, Handler: h}
+}
+
+// need - route yang hanya boleh diakses user dengan permission tertentu
+func need(perm, method, pattern string, h http.HandlerFunc) Route {
+	return Route{Method: method, Pattern: pattern, Permission: perm, Handler: h}
+}
+
+// validatePolicies memastikan setiap route /api/ punya policy dan tidak ada route ganda
+func validatePolicies(table []Route) error {
+	var errs []error
+	seen := map[string]bool{}
+
+	for _, rt := range table {
+		key := rt.Method + " " + rt.Pattern
+		if seen[key] {
+			errs = append(errs, fmt.Errorf("route ganda: %s", key))
+		}
+		seen[key] = true
+
+		if rt.Handler == nil {
+			errs = append(errs, fmt.Errorf("route %s tidak punya handler", key))
+		}
+		if !strings.HasPrefix(rt.Pattern, "/api/") {
+			continue
+		}
+		if rt.Public && (rt.Login || rt.Permission != "") {
+			errs = append(errs, fmt.Errorf("route %s public sekaligus butuh login", key))
+		}
+		if rt.Login && rt.Permission != "" {
+			errs = append(errs, fmt.Errorf("route %s punya policy login dan permission sekaligus", key))
+		}
+		if !rt.Public && !rt.Login && rt.Permission == "" {
+			errs = append(errs, fmt.Errorf("route %s belum punya policy akses", key))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -4,282 +4,195 @@ import (
 	"net/http"
 	"service_hp/controllers"
 	"service_hp/routes/middleware"
+	"time"
 )
 
-func RegisterRoutes(mux *http.ServeMux) {
-
-	// Home
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.Write([]byte(`{"message":"Service HP API is running"}`))
-	})
-
-	// Public
-	mux.HandleFunc("/api/signup", controllers.SignUpPegawai)
-	mux.HandleFunc("/api/login", controllers.Login)
-
-	// Public - Search Servis (untuk landing page)
-	mux.HandleFunc("/api/servis/search", func(w http.ResponseWriter, r *http.Request) {
-		// Enable CORS for preflight
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-		
-		if r.Method != http.MethodGet {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-		
-		controllers.SearchServis(w, r)
-	})
-
-
-
-	// ============================================
-	// ADMIN ROUTES
-	// ============================================
-
-	// Pegawai Management - Admin only
-	mux.HandleFunc("/api/admin/pegawai/available-users", middleware.RequireRole("admin", controllers.GetAvailableUsers))
-
-	mux.HandleFunc("/api/admin/pegawai", middleware.RequireRole("admin", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodGet:
-			controllers.GetAllPegawai(w, r)
-		case http.MethodPost:
-			controllers.CreatePegawai(w, r)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	}))
-
-	mux.HandleFunc("/api/admin/pegawai/", middleware.RequireRole("admin", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/api/admin/pegawai/" || r.URL.Path == "/api/admin/pegawai" {
-			http.Error(w, "ID required", http.StatusBadRequest)
-			return
-		}
-
-		switch r.Method {
-		case http.MethodPut:
-			controllers.UpdatePegawai(w, r)
-		case http.MethodDelete:
-			controllers.DeletePegawai(w, r)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	}))
-
-	  // =====================================================
-	 // PROTECTED ADMIN ROUTES - LAPORAN
-	 // =====================================================
-		mux.HandleFunc("/api/admin/dashboard-stats", func(w http.ResponseWriter, r *http.Request) {
-		(func(w http.ResponseWriter, r *http.Request) {
-			if r.Method == http.MethodGet {
-				controllers.GetDashboardAdmin(w, r)
-			} else {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-		})(w, r)
-	})
-
-	mux.HandleFunc("/api/admin/simple-stats", func(w http.ResponseWriter, r *http.Request) {
-		(func(w http.ResponseWriter, r *http.Request) {
-			if r.Method == http.MethodGet {
-				controllers.GetSimpleStats(w, r)
-			} else {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-		})(w, r)
-	})
-	
-	mux.HandleFunc("/api/admin/dashboard", func(w http.ResponseWriter, r *http.Request) {
-		(func(w http.ResponseWriter, r *http.Request) {
-			if r.Method == http.MethodGet {
-				controllers.GetDataStats(w, r)
-			} else {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-		})(w, r)
-	})
-
-	mux.HandleFunc("/api/admin/laporan", func(w http.ResponseWriter, r *http.Request) {
-		(func(w http.ResponseWriter, r *http.Request) {
-			switch r.Method {
-			case http.MethodGet:
-				controllers.GetAllLaporan(w, r)
-			case http.MethodPost:
-				controllers.GenerateLaporan(w, r)
-			default:
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-		})(w, r)
-	})
-
-	mux.HandleFunc("/api/admin/laporan/", func(w http.ResponseWriter, r *http.Request) {
-		(func(w http.ResponseWriter, r *http.Request) {
-			switch r.Method {
-			case http.MethodGet:
-				controllers.GetLaporanDetail(w, r)
-			case http.MethodDelete:
-				controllers.DeleteLaporan(w, r)
-			default:
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-		})(w, r)
-	})
-
-
-
-
-
-	// ============================================
-	// PEGAWAI ROUTES
-	// ============================================
-
-	// Barang Management - Pegawai
-	mux.HandleFunc("/api/pegawai/barang", middleware.RequireRole("pegawai", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodGet:
-			controllers.GetAllBarang(w, r)
-		case http.MethodPost:
-			controllers.CreateBarang(w, r)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	}))
-
-	mux.HandleFunc("/api/pegawai/barang/", middleware.RequireRole("pegawai", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/api/pegawai/barang/" || r.URL.Path == "/api/pegawai/barang" {
-			http.Error(w, "ID required", http.StatusBadRequest)
-			return
-		}
-
-		switch r.Method {
-		case http.MethodPut:
-			controllers.UpdateBarang(w, r)
-		case http.MethodDelete:
-			controllers.DeleteBarang(w, r)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	}))
-
-	// ============================
-	// SERVIS (CRUD UTAMA)
-	// ============================
-
-	// GET ALL + CREATE
-	mux.HandleFunc("/api/pegawai/servis", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodGet:
-			controllers.GetAllServis(w, r)
-		case http.MethodPost:
-			controllers.CreateServis(w, r)
-		default:
-			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
-		}
-	})
-
-	// GET DETAIL + UPDATE + DELETE
-	mux.HandleFunc("/api/pegawai/servis/", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodGet:
-			controllers.GetServisDetail(w, r)
-		case http.MethodPut:
-			controllers.UpdateServis(w, r)
-		case http.MethodDelete:
-			controllers.DeleteServis(w, r)
-		default:
-			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
-		}
-	})
-
-
-	// ============================
-	// DETAIL SERVIS (ITEM BARANG)
-	// ============================
-
-	// CREATE DETAIL ITEM
-	mux.HandleFunc("/api/pegawai/detail-servis", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodPost {
-			controllers.AddDetailServis(w, r)
-			return
-		}
-		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
-	})
+// apiRoutes - tabel semua endpoint API. Setiap route /api/ wajib public, loggedIn, atau punya permission,
+// dicek saat startup oleh RegisterRoutes.
+func apiRoutes() []Route {
+	return []Route{
+		// Home
+		public("GET", "/{$}", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"message":"Service HP API is running"}`))
+		}),
+
+		// ============================================
+		// PUBLIC
+		// ============================================
+		public("GET", "/api/signup/mode", controllers.SignupMode),
+		public("POST", "/api/signup", middleware.RateLimit(10, time.Minute, controllers.SignUpPegawai)),
+		public("POST", "/api/login", controllers.Login),
+		public("POST", "/api/refresh", middleware.RateLimit(30, time.Minute, controllers.RefreshToken)),
+		public("POST", "/api/logout", controllers.Logout),
+		public("POST", "/api/password/reset", middleware.RateLimit(10, time.Minute, controllers.ResetPassword)),
+
+		// Profil & password sendiri, semua user yang login
+		loggedIn("GET", "/api/me", controllers.GetProfil),
+		loggedIn("PUT", "/api/me", controllers.UpdateProfil),
+		loggedIn("PUT", "/api/me/password", controllers.ChangePassword),
+
+		// Search servis untuk landing page, wajib nomor WhatsApp + kode tracking
+		public("GET", "/api/servis/search", middleware.RateLimit(20, time.Minute, controllers.SearchServis)),
+		// Tracking servis dengan kode dari nota
+		public("GET", "/api/track/{kode}", middleware.RateLimit(30, time.Minute, controllers.TrackServis)),
+		// Penawaran biaya: pelanggan melihat & menyetujui / menolak lewat kode tracking
+		public("GET", "/api/track/{kode}/penawaran", middleware.RateLimit(30, time.Minute, controllers.GetPenawaranPublik)),
+		public("POST", "/api/track/{kode}/penawaran", middleware.RateLimit(10, time.Minute, controllers.PutuskanPenawaranPublik)),
+
+		// ============================================
+		// ADMIN ROUTES
+		// ============================================
+
+		// Pegawai Management
+		need(middleware.PermPegawaiKelola, "GET", "/api/admin/pegawai/available-users", controllers.GetAvailableUsers),
+		need(middleware.PermPegawaiKelola, "GET", "/api/admin/pegawai", controllers.GetAllPegawai),
+		need(middleware.PermPegawaiKelola, "POST", "/api/admin/pegawai", controllers.CreatePegawai),
+		need(middleware.PermPegawaiKelola, "PUT", "/api/admin/pegawai/{id}", controllers.UpdatePegawai),
+		need(middleware.PermPegawaiKelola, "DELETE", "/api/admin/pegawai/{id}", controllers.DeletePegawai),
+
+		// Pendaftaran: kode undangan & tolak akun signup yang menunggu persetujuan
+		need(middleware.PermPegawaiKelola, "GET", "/api/admin/undangan", controllers.GetAllUndangan),
+		need(middleware.PermPegawaiKelola, "POST", "/api/admin/undangan", controllers.CreateUndangan),
+		need(middleware.PermPegawaiKelola, "DELETE", "/api/admin/undangan/{id}", controllers.CancelUndangan),
+		need(middleware.PermPegawaiKelola, "DELETE", "/api/admin/pendaftaran/{id}", controllers.RejectPendaftaran),
+
+		// Role & permission user
+		need(middleware.PermPegawaiKelola, "GET", "/api/admin/roles", controllers.GetAllRoleAkses),
+		need(middleware.PermPegawaiKelola, "GET", "/api/admin/users", controllers.GetAllUserAkses),
+		need(middleware.PermPegawaiKelola, "PUT", "/api/admin/users/{id}/akses", controllers.UpdateUserAkses),
+		need(middleware.PermPegawaiKelola, "POST", "/api/admin/users/{id}/unlock", controllers.UnlockUserLogin),
+		need(middleware.PermPegawaiKelola, "POST", "/api/admin/users/{id}/reset-password", controllers.CreatePasswordReset),
+		need(middleware.PermPegawaiKelola, "GET", "/api/admin/login-attempts", controllers.GetLoginAttempts),
+
+		// Audit log perubahan data
+		need(middleware.PermAuditView, "GET", "/api/admin/audit", controllers.GetAuditLog),
+
+		// Pelanggan (sinkron data lama, deduplikasi & merge)
+		need(middleware.PermPelangganMerge, "POST", "/api/admin/pelanggan/sinkron", controllers.SinkronPelanggan),
+		need(middleware.PermPelangganMerge, "GET", "/api/admin/pelanggan/duplikat", controllers.GetDuplikatPelanggan),
+		need(middleware.PermPelangganMerge, "POST", "/api/admin/pelanggan/merge", controllers.MergePelanggan),
+
+		// Komisi teknisi
+		need(middleware.PermKomisiKelola, "GET", "/api/admin/komisi", controllers.GetKomisi),
+		need(middleware.PermKomisiKelola, "GET", "/api/admin/komisi/aturan", controllers.GetKomisiAturan),
+		need(middleware.PermKomisiKelola, "PUT", "/api/admin/komisi/aturan/{id}", controllers.SetKomisiAturan),
+		need(middleware.PermKomisiKelola, "DELETE", "/api/admin/komisi/aturan/{id}", controllers.DeleteKomisiAturan),
+		need(middleware.PermKomisiKelola, "GET", "/api/admin/komisi/periode", controllers.GetAllKomisiPeriode),
+		need(middleware.PermKomisiKelola, "POST", "/api/admin/komisi/periode", controllers.KunciKomisi),
+		need(middleware.PermKomisiKelola, "GET", "/api/admin/komisi/periode/{id}", controllers.GetKomisiPeriodeDetail),
+
+		// Dashboard & laporan
+		need(middleware.PermLaporanProfit, "GET", "/api/admin/dashboard-stats", controllers.GetDashboardAdmin),
+		need(middleware.PermLaporanProfit, "GET", "/api/admin/simple-stats", controllers.GetSimpleStats),
+		need(middleware.PermLaporanProfit, "GET", "/api/admin/dashboard", controllers.GetDataStats),
+		need(middleware.PermLaporanProfit, "GET", "/api/admin/laporan", controllers.GetAllLaporan),
+		need(middleware.PermLaporanProfit, "POST", "/api/admin/laporan", controllers.GenerateLaporan),
+		need(middleware.PermLaporanProfit, "GET", "/api/admin/laporan/{id}", controllers.GetLaporanDetail),
+		need(middleware.PermLaporanProfit, "DELETE", "/api/admin/laporan/{id}", controllers.DeleteLaporan),
+
+		// ============================================
+		// PEGAWAI ROUTES
+		// ============================================
+
+		// Barang
+		need(middleware.PermBarangView, "GET", "/api/pegawai/barang", controllers.GetAllBarang),
+		need(middleware.PermBarangKelola, "POST", "/api/pegawai/barang", controllers.CreateBarang),
+		need(middleware.PermBarangView, "GET", "/api/pegawai/barang/rekonsiliasi", controllers.GetRekonsiliasiStok),
+		need(middleware.PermBarangKelola, "PUT", "/api/pegawai/barang/{id}", controllers.UpdateBarang),
+		need(middleware.PermBarangKelola, "DELETE", "/api/pegawai/barang/{id}", controllers.DeleteBarang),
+		need(middleware.PermBarangView, "GET", "/api/pegawai/barang/{id}/kartu-stok", controllers.GetKartuStok),
+		need(middleware.PermBarangView, "GET", "/api/pegawai/barang/{id}/pembelian", controllers.GetRiwayatPembelianBarang),
+		need(middleware.PermBarangKelola, "POST", "/api/pegawai/barang/{id}/stock-opname", controllers.StockOpname),
+
+		// Pelanggan
+		need(middleware.PermPelangganView, "GET", "/api/pegawai/pelanggan", controllers.GetAllPelanggan),
+		need(middleware.PermPelangganKelola, "POST", "/api/pegawai/pelanggan", controllers.CreatePelanggan),
+		need(middleware.PermPelangganView, "GET", "/api/pegawai/pelanggan/{id}", controllers.GetPelangganDetail),
+		need(middleware.PermPelangganKelola, "PUT", "/api/pegawai/pelanggan/{id}", controllers.UpdatePelanggan),
+		need(middleware.PermPelangganKelola, "DELETE", "/api/pegawai/pelanggan/{id}", controllers.DeletePelanggan),
+
+		// Supplier
+		need(middleware.PermPembelianKelola, "GET", "/api/pegawai/supplier", controllers.GetAllSupplier),
+		need(middleware.PermPembelianKelola, "POST", "/api/pegawai/supplier", controllers.CreateSupplier),
+		need(middleware.PermPembelianKelola, "PUT", "/api/pegawai/supplier/{id}", controllers.UpdateSupplier),
+		need(middleware.PermPembelianKelola, "DELETE", "/api/pegawai/supplier/{id}", controllers.DeleteSupplier),
+
+		// Pembelian (restock barang)
+		need(middleware.PermPembelianKelola, "GET", "/api/pegawai/pembelian", controllers.GetAllPembelian),
+		need(middleware.PermPembelianKelola, "POST", "/api/pegawai/pembelian", controllers.CreatePembelian),
+		need(middleware.PermPembelianKelola, "GET", "/api/pegawai/pembelian/{id}", controllers.GetPembelianDetail),
+		need(middleware.PermPembelianKelola, "PUT", "/api/pegawai/pembelian/{id}", controllers.UpdatePembelian),
+		need(middleware.PermPembelianKelola, "POST", "/api/pegawai/pembelian/{id}/terima", controllers.TerimaPembelian),
+		need(middleware.PermPembelianKelola, "POST", "/api/pegawai/pembelian/{id}/batal", controllers.BatalPembelian),
+
+		// Servis (CRUD utama). Izin per transisi status dicek di controller
+		need(middleware.PermServisView, "GET", "/api/pegawai/servis", controllers.GetAllServis),
+		need(middleware.PermServisCreate, "POST", "/api/pegawai/servis", controllers.CreateServis),
+		need(middleware.PermServisView, "GET", "/api/pegawai/servis/{id}", controllers.GetServisDetail),
+		need(middleware.PermServisEdit, "PUT", "/api/pegawai/servis/{id}", controllers.UpdateServis),
+		need(middleware.PermServisDelete, "DELETE", "/api/pegawai/servis/{id}", controllers.DeleteServis),
+		need(middleware.PermServisView, "GET", "/api/pegawai/servis/{id}/status", controllers.GetRiwayatStatusServis),
+		need(middleware.PermServisView, "PATCH", "/api/pegawai/servis/{id}/status", controllers.UpdateStatusServis),
+		need(middleware.PermServisView, "GET", "/api/pegawai/servis/{id}/notifikasi", controllers.GetNotifikasiServis),
+		need(middleware.PermServisView, "GET", "/api/pegawai/servis/{id}/teknisi", controllers.GetRiwayatTeknisiServis),
+		need(middleware.PermServisAssign, "PATCH", "/api/pegawai/servis/{id}/teknisi", controllers.AssignTeknisiServis),
+		need(middleware.PermServisView, "GET", "/api/pegawai/servis/{id}/tanda-terima.pdf", controllers.GetTandaTerimaPDF),
+		need(middleware.PermServisView, "GET", "/api/pegawai/servis/{id}/pembayaran", controllers.GetPembayaranServis),
+		need(middleware.PermPembayaran, "POST", "/api/pegawai/servis/{id}/pembayaran", controllers.CreatePembayaran),
+		need(middleware.PermServisView, "GET", "/api/pegawai/servis/{id}/nota", controllers.GetNota),
+		need(middleware.PermServisView, "GET", "/api/pegawai/servis/{id}/nota.pdf", controllers.GetNotaPDF),
+		need(middleware.PermPembayaran, "POST", "/api/pegawai/servis/{id}/nota", controllers.TerbitkanNota),
+		need(middleware.PermServisView, "GET", "/api/pegawai/servis/{id}/garansi", controllers.GetGaransiServis),
+		need(middleware.PermServisView, "GET", "/api/pegawai/servis/{id}/penawaran", controllers.GetPenawaranServis),
+		need(middleware.PermServisEdit, "POST", "/api/pegawai/servis/{id}/penawaran", controllers.CreatePenawaran),
+		need(middleware.PermPenawaranPutuskan, "POST", "/api/pegawai/servis/{id}/penawaran/keputusan", controllers.PutuskanPenawaran),
+		need(middleware.PermPenawaranOverride, "POST", "/api/pegawai/servis/{id}/penawaran/override", controllers.OverridePersetujuan),
+		need(middleware.PermServisView, "GET", "/api/pegawai/servis/{id}/lampiran", controllers.GetLampiranServis),
+		need(middleware.PermServisEdit, "POST", "/api/pegawai/servis/{id}/lampiran", controllers.UploadLampiran),
+		need(middleware.PermServisView, "GET", "/api/pegawai/servis/{id}/lampiran/{idLampiran}", controllers.GetBerkasLampiran),
+		need(middleware.PermServisView, "GET", "/api/pegawai/servis/{id}/lampiran/{idLampiran}/thumb", controllers.GetThumbLampiran),
+		need(middleware.PermServisEdit, "DELETE", "/api/pegawai/servis/{id}/lampiran/{idLampiran}", controllers.DeleteLampiran),
+		need(middleware.PermServisCreate, "POST", "/api/pegawai/servis/{id}/klaim-garansi", controllers.KlaimGaransi),
+
+		// Antrian servis teknisi yang login
+		need(middleware.PermServisKerjakan, "GET", "/api/pegawai/antrian-saya", controllers.GetAntrianSaya),
+
+		// Detail servis (item barang)
+		need(middleware.PermServisEdit, "POST", "/api/pegawai/detail-servis", controllers.AddDetailServis),
+		need(middleware.PermServisEdit, "PUT", "/api/pegawai/detail-servis/{id}", controllers.UpdateDetailServis),
+		need(middleware.PermServisEdit, "DELETE", "/api/pegawai/detail-servis/{id}", controllers.DeleteDetailServis),
+
+		// Dashboard & laporan
+		need(middleware.PermDashboardView, "GET", "/api/pegawai/dashboard-stats", controllers.GetDashboardPegawai),
+		need(middleware.PermDashboardView, "GET", "/api/pegawai/simple-stats", controllers.GetSimpleStats),
+		need(middleware.PermDashboardView, "GET", "/api/pegawai/dashboard", controllers.GetDataStats),
+		need(middleware.PermLaporanView, "GET", "/api/pegawai/pembayaran", controllers.GetRekapPembayaran),
+		need(middleware.PermLaporanProfit, "GET", "/api/pegawai/garansi", controllers.GetRekapGaransi),
+		need(middleware.PermLaporanView, "GET", "/api/pegawai/laporan", controllers.GetAllLaporan),
+		need(middleware.PermLaporanView, "POST", "/api/pegawai/laporan", controllers.GenerateLaporan),
+		need(middleware.PermLaporanView, "GET", "/api/pegawai/laporan/{id}", controllers.GetLaporanDetail),
+		need(middleware.PermLaporanProfit, "DELETE", "/api/pegawai/laporan/{id}", controllers.DeleteLaporan),
+	}
+}
 
-	// UPDATE / DELETE DETAIL ITEM
-	mux.HandleFunc("/api/pegawai/detail-servis/", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodPut:
-			controllers.UpdateDetailServis(w, r)
-		case http.MethodDelete:
-			controllers.DeleteDetailServis(w, r)
-		default:
-			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+// RegisterRoutes mendaftarkan tabel route ke mux. Gagal jika ada route /api/ tanpa policy.
+// Penolakan request tanpa login / role salah diuji di policy_test.go.
+func RegisterRoutes(mux *http.ServeMux) error {
+	table := apiRoutes()
+	if err := validatePolicies(table); err != nil {
+		return err
+	}
+
+	for _, rt := range table {
+		h := rt.Handler
+		switch {
+		case rt.Login:
+			h = middleware.RequireAuth(h)
+		case !rt.Public:
+			h = middleware.RequirePermission(rt.Permission, h)
 		}
-	})
-
-  // =====================================================
-	// PROTECTED PEGAWAI ROUTES - LAPORAN
-	// =====================================================
-		mux.HandleFunc("/api/pegawai/dashboard-stats", func(w http.ResponseWriter, r *http.Request) {
-		(func(w http.ResponseWriter, r *http.Request) {
-			if r.Method == http.MethodGet {
-				controllers.GetDashboardPegawai(w, r)
-			} else {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-		})(w, r)
-	})
-
-	mux.HandleFunc("/api/pegawai/simple-stats", func(w http.ResponseWriter, r *http.Request) {
-		(func(w http.ResponseWriter, r *http.Request) {
-			if r.Method == http.MethodGet {
-				controllers.GetSimpleStats(w, r)
-			} else {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-		})(w, r)
-	})
-	
-	mux.HandleFunc("/api/pegawai/dashboard", func(w http.ResponseWriter, r *http.Request) {
-		(func(w http.ResponseWriter, r *http.Request) {
-			if r.Method == http.MethodGet {
-				controllers.GetDataStats(w, r)
-			} else {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-		})(w, r)
-	})
-
-	mux.HandleFunc("/api/pegawai/laporan", func(w http.ResponseWriter, r *http.Request) {
-		(func(w http.ResponseWriter, r *http.Request) {
-			switch r.Method {
-			case http.MethodGet:
-				controllers.GetAllLaporan(w, r)
-			case http.MethodPost:
-				controllers.GenerateLaporan(w, r)
-			default:
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-		})(w, r)
-	})
-
-	mux.HandleFunc("/api/pegawai/laporan/", func(w http.ResponseWriter, r *http.Request) {
-		(func(w http.ResponseWriter, r *http.Request) {
-			switch r.Method {
-			case http.MethodGet:
-				controllers.GetLaporanDetail(w, r)
-			case http.MethodDelete:
-				controllers.DeleteLaporan(w, r)
-			default:
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-		})(w, r)
-	})
-
-
+		mux.HandleFunc(rt.Method+" "+rt.Pattern, h)
+	}
+	return nil
 }
-	
@@ -1,4 +1,5 @@
 import { Link, useLocation, useNavigate } from "react-router-dom"
+import { logout } from "../../services/auth"
 import type { ReactNode } from "react"
 
 interface AdminLayoutProps {
@@ -9,10 +10,9 @@ export default function AdminLayout({ children }: AdminLayoutProps) {
   const location = useLocation()
   const navigate = useNavigate()
 
-  const handleLogout = () => {
+  const handleLogout = async () => {
     if (confirm("Yakin ingin logout?")) {
-      localStorage.removeItem("token")
-      localStorage.removeItem("user")
+      await logout()
       navigate("/login")
     }
   }
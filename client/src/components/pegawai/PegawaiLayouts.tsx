@@ -1,4 +1,5 @@
 import { Link, useLocation, useNavigate } from "react-router-dom"
+import { logout } from "../../services/auth"
 import { type ReactNode, useEffect, useState } from "react"
 
 interface PegawaiLayoutProps {
@@ -24,10 +25,9 @@ export default function PegawaiLayout({ children }: PegawaiLayoutProps) {
     }
   }, [])
 
-  const handleLogout = () => {
+  const handleLogout = async () => {
     if (confirm("Yakin ingin logout?")) {
-      localStorage.removeItem("token")
-      localStorage.removeItem("user")
+      await logout()
       navigate("/login")
     }
   }
@@ -1,6 +1,7 @@
 import React from "react"
 import ReactDOM from "react-dom/client"
 import "./index.css"
+import { installAuthInterceptor } from "./services/auth"
 
 import { BrowserRouter, Routes, Route } from "react-router-dom"
 import LandingPage from "./pages/main/LandingPage"
@@ -19,6 +20,9 @@ import PegawaiServisPage from "./pages/pegawai/servis/ServisPage"
 import PegawaiServisDetailPage from "./pages/pegawai/servis/ServisDetailPage"
 import LaporanPegawaiPage from "./pages/pegawai/laporan/LaporanPegawaiPage"
 
+// Access token diperbarui otomatis saat API membalas 401
+installAuthInterceptor()
+
 ReactDOM.createRoot(document.getElementById("root")!).render(
   <React.StrictMode>
     <BrowserRouter>
@@ -1,5 +1,6 @@
 import { useState } from "react"
 import { Link, useNavigate } from "react-router-dom"
+import { saveTokens } from "../services/auth"
 
 
 export default function Login() {
@@ -39,8 +40,8 @@ const handleLogin = async (e: React.FormEvent) => {
       return
     }
 
-    // Simpan token dan role
-    localStorage.setItem("token", data.token)
+    // Simpan access & refresh token dan role
+    saveTokens(data)
     localStorage.setItem("role", data.user.role)
 
     // Redirect sesuai role
@@ -1,36 +1,26 @@
 import { useState } from "react"
 
 
-interface ServiceDetail {
-  id_detail: number
-  id_servis: number
-  id_barang: number | null
-  deskripsi: string
-  jumlah: number
-  harga_satuan: number
-  biaya: number
+interface TrackingStatus {
+  status: string
+  waktu: string
 }
 
-interface Service {
-  id_servis: number
+interface Tracking {
+  kode_tracking: string
   nama_pelanggan: string
-  no_whatsapp: string
   tipe_hp: string
-  keluhan: string
   status_servis: string
-  biaya_total: number
+  estimasi_biaya: number
   tanggal_masuk: string
   tanggal_selesai: string | null
-  detail: ServiceDetail[]
+  timeline: TrackingStatus[]
 }
 
 export default function LandingPage() {
-  const [searchName, setSearchName] = useState("")
+  const [searchKode, setSearchKode] = useState("")
   const [searchPhone, setSearchPhone] = useState("")
-  const [results, setResults] = useState<Service[]>([])
-  const [selectedService, setSelectedService] = useState<Service | null>(null)
-  const [showResults, setShowResults] = useState(false)
-  const [showDetails, setShowDetails] = useState(false)
+  const [result, setResult] = useState<Tracking | null>(null)
   const [loading, setLoading] = useState(false)
   const [error, setError] = useState("")
 
@@ -58,6 +48,21 @@ export default function LandingPage() {
         color: "bg-blue-100 text-blue-700",
         icon: "/images/icons/ready.png",
       },
+      diambil: {
+        label: "Sudah Diambil",
+        color: "bg-green-100 text-green-700",
+        icon: "/images/icons/done.png",
+      },
+      tidak_bisa_diperbaiki: {
+        label: "Tidak Bisa Diperbaiki",
+        color: "bg-red-100 text-red-700",
+        icon: "/images/icons/pending.png",
+      },
+      batal: {
+        label: "Dibatalkan",
+        color: "bg-gray-100 text-gray-700",
+        icon: "/images/icons/pending.png",
+      },
     }
     return (
       statusMap[status] || {
@@ -68,32 +73,41 @@ export default function LandingPage() {
     )
   }
 
+  // Pencarian publik butuh kode tracking (tercetak di nota) + nomor WhatsApp
   const searchService = async () => {
-    if (!searchName && !searchPhone) {
-      setError("Masukkan nama atau nomor WhatsApp")
+    if (!searchKode || !searchPhone) {
+      setError("Masukkan kode tracking dan nomor WhatsApp")
       return
     }
 
     setLoading(true)
     setError("")
+    setResult(null)
 
     try {
       const params = new URLSearchParams()
-      if (searchName) params.append("name", searchName)
-      if (searchPhone) params.append("phone", searchPhone)
+      params.append("kode", searchKode)
+      params.append("phone", searchPhone)
 
       const response = await fetch(
         `http://localhost:8080/api/servis/search?${params}`
       )
 
+      if (response.status === 404) {
+        setError("Servis tidak ditemukan, periksa kode tracking dan nomor WhatsApp")
+        return
+      }
+      if (response.status === 429) {
+        setError("Terlalu banyak pencarian, coba lagi beberapa saat")
+        return
+      }
       if (!response.ok) {
-        throw new Error("Gagal mencari data servis")
+        const data = await response.json().catch(() => null)
+        throw new Error(data?.error || "Gagal mencari data servis")
       }
 
-      const data = await response.json()
-      setResults(data)
-      setShowResults(true)
-      setShowDetails(false)
+      const data: Tracking = await response.json()
+      setResult(data)
     } catch (err) {
       setError("Terjadi kesalahan saat mencari data")
       console.error(err)
@@ -102,19 +116,6 @@ export default function LandingPage() {
     }
   }
 
-  const handleShowDetails = (serviceId: number) => {
-    const service = results.find((s) => s.id_servis === serviceId)
-    if (service) {
-      setSelectedService(service)
-      setShowDetails(true)
-    }
-  }
-
-  const closeDetails = () => {
-    setShowDetails(false)
-    setSelectedService(null)
-  }
-
   return (
     <div className="bg-gray-50">
       <style>{`
@@ -253,13 +254,13 @@ export default function LandingPage() {
                 <div className="space-y-4">
                   <div>
                     <label className="block text-xs text-gray-600 mb-2">
-                      Nama Pelanggan
+                      Kode Tracking
                     </label>
                     <input
                       type="text"
-                      value={searchName}
-                      onChange={(e) => setSearchName(e.target.value)}
-                      placeholder="Contoh: Budi Santoso"
+                      value={searchKode}
+                      onChange={(e) => setSearchKode(e.target.value.toUpperCase())}
+                      placeholder="Contoh: ABCDE-23456 (lihat nota servis)"
                       className="w-full px-4 py-3 border-2 border-gray-200 rounded-xl focus:border-purple-500 focus:outline-none transition"
                     />
                   </div>
@@ -296,221 +297,87 @@ export default function LandingPage() {
               </div>
             </div>
 
-            {/* Results Section */}
-            {showResults && (
-              <div className="bg-white p-6 rounded-2xl shadow-md">
-                <h3 className="text-xl font-bold text-gray-800 mb-4">
-                  Hasil Pencarian
-                </h3>
-                {results.length === 0 ? (
-                  <div className="text-center py-8">
-                    <img
-                      src="/images/icons/sad.png"
-                      className="w-30 h-30 mx-auto mb-4"
-                    />
-                    <p className="text-gray-600">
-                      Tidak ada servis ditemukan dengan kriteria tersebut
-                    </p>
-                  </div>
-                ) : (
+            {/* Result Section */}
+            {result && (
+              <div className="bg-white p-8 rounded-2xl shadow-md">
+                <div className="flex justify-between items-start mb-6">
                   <div>
-                    {results.map((service) => {
-                      const status = getStatusInfo(service.status_servis)
-                      return (
-                        <div
-                          key={service.id_servis}
-                          className="border-2 border-gray-200 rounded-xl p-6 mb-4 hover:border-purple-400 transition cursor-pointer"
-                          onClick={() => handleShowDetails(service.id_servis)}
-                        >
-                          <div className="flex justify-between items-start mb-3">
-                            <div>
-                              <h4 className="font-bold text-lg text-gray-800">
-                                {service.nama_pelanggan}
-                              </h4>
-                              <p className="text-sm text-gray-600">
-                                {service.tipe_hp}
-                              </p>
-                            </div>
-                            <span
-                              className={`px-4 py-2 rounded-full text-sm font-semibold ${status.color} flex items-center gap-2`}
-                            >
-                              <img
-                                src={status.icon}
-                                alt={status.label}
-                                className="w-5 h-5"
-                              />
-                              {status.label}
-                            </span>
-                          </div>
-                          <div className="grid grid-cols-2 gap-4 text-sm mt-4">
-                            <div>
-                              <p className="text-gray-500">Tanggal Masuk</p>
-                              <p className="font-medium">
-                                {new Date(
-                                  service.tanggal_masuk,
-                                ).toLocaleDateString("id-ID")}
-                              </p>
-                            </div>
-                            <div>
-                              <p className="text-gray-500">Total Biaya</p>
-                              <p className="font-bold text-purple-600">
-                                Rp {service.biaya_total.toLocaleString("id-ID")}
-                              </p>
-                            </div>
-                          </div>
-                          <button className="mt-4 text-purple-600 font-semibold text-sm hover:text-purple-800">
-                            Lihat Detail →
-                          </button>
-                        </div>
-                      )
-                    })}
+                    <h3 className="text-2xl font-bold text-gray-800">
+                      {result.tipe_hp}
+                    </h3>
+                    <p className="text-sm text-gray-500">
+                      {result.kode_tracking}
+                    </p>
                   </div>
-                )}
-              </div>
-            )}
-
-            {/* Details Section */}
-            {showDetails && selectedService && (
-              <div className="mt-6 bg-white p-8 rounded-2xl shadow-md">
-                <div className="flex justify-between items-start mb-6">
-                  <h3 className="text-2xl font-bold text-gray-800">
-                    Detail Servis
-                  </h3>
-                  <button
-                    onClick={closeDetails}
-                    className="text-gray-500 hover:text-gray-700 text-2xl"
+                  <span
+                    className={`px-4 py-2 rounded-full text-sm font-semibold ${
+                      getStatusInfo(result.status_servis).color
+                    } flex items-center gap-2`}
                   >
-                    &times;
-                  </button>
+                    <img
+                      src={getStatusInfo(result.status_servis).icon}
+                      alt={getStatusInfo(result.status_servis).label}
+                      className="w-5 h-5"
+                    />
+                    {getStatusInfo(result.status_servis).label}
+                  </span>
                 </div>
-                <div className="space-y-6">
-                  {/* Status Badge */}
-                  <div className="flex justify-center">
-                    <span
-                      className={`px-6 py-3 rounded-full text-lg font-bold ${
-                        getStatusInfo(selectedService.status_servis).color
-                      } flex items-center gap-2`}
-                    >
-                      <img
-                        src={getStatusInfo(selectedService.status_servis).icon}
-                        alt={getStatusInfo(selectedService.status_servis).label}
-                        className="w-6 h-6"
-                      />
-                      {getStatusInfo(selectedService.status_servis).label}
-                    </span>
-                  </div>
 
-                  {/* Customer Info */}
-                  <div className="bg-gray-50 p-6 rounded-xl">
-                    <h4 className="font-bold text-gray-700 mb-4">
-                      Informasi Pelanggan
-                    </h4>
-                    <div className="grid md:grid-cols-2 gap-4 text-sm">
-                      <div>
-                        <p className="text-gray-500">Nama</p>
-                        <p className="font-semibold">
-                          {selectedService.nama_pelanggan}
-                        </p>
-                      </div>
-                      <div>
-                        <p className="text-gray-500">WhatsApp</p>
-                        <p className="font-semibold">
-                          ****{selectedService.no_whatsapp.slice(-4)}
-                        </p>
-                      </div>
-                      <div>
-                        <p className="text-gray-500">Tipe HP</p>
-                        <p className="font-semibold">
-                          {selectedService.tipe_hp}
-                        </p>
-                      </div>
-                      <div>
-                        <p className="text-gray-500">Tanggal Masuk</p>
-                        <p className="font-semibold">
-                          {new Date(
-                            selectedService.tanggal_masuk,
-                          ).toLocaleDateString("id-ID")}
-                        </p>
-                      </div>
+                <div className="bg-gray-50 p-6 rounded-xl mb-6">
+                  <div className="grid md:grid-cols-3 gap-4 text-sm">
+                    <div>
+                      <p className="text-gray-500">Nama</p>
+                      <p className="font-semibold">{result.nama_pelanggan}</p>
                     </div>
-                  </div>
-
-                  {/* Problem */}
-                  <div className="bg-red-50 border-l-4 border-red-400 p-4 rounded">
-                    <p className="text-sm text-gray-600 mb-1">Keluhan</p>
-                    <p className="font-medium text-gray-800">
-                      {selectedService.keluhan}
-                    </p>
-                  </div>
-
-                  {/* Service Details */}
-                  <div>
-                    <h4 className="font-bold text-gray-700 mb-3">
-                      Rincian Perbaikan
-                    </h4>
-                    <div className="overflow-x-auto">
-                      <table className="w-full text-sm">
-                        <thead className="bg-gray-100">
-                          <tr>
-                            <th className="text-left p-3 border">Item</th>
-                            <th className="text-center p-3 border">Jumlah</th>
-                            <th className="text-right p-3 border">
-                              Harga Satuan
-                            </th>
-                            <th className="text-right p-3 border">Total</th>
-                          </tr>
-                        </thead>
-                        <tbody>
-                          {selectedService.detail.map((d, idx) => (
-                            <tr key={idx}>
-                              <td className="p-3 border">{d.deskripsi}</td>
-                              <td className="text-center p-3 border">
-                                {d.jumlah}
-                              </td>
-                              <td className="text-right p-3 border">
-                                Rp {d.harga_satuan.toLocaleString("id-ID")}
-                              </td>
-                              <td className="text-right p-3 border font-medium">
-                                Rp {d.biaya.toLocaleString("id-ID")}
-                              </td>
-                            </tr>
-                          ))}
-                        </tbody>
-                        <tfoot>
-                          <tr className="bg-purple-50 font-bold">
-                            <td colSpan={3} className="text-right p-3 border">
-                              Total Pembayaran:
-                            </td>
-                            <td className="text-right p-3 border text-purple-600 text-lg">
-                              Rp{" "}
-                              {selectedService.biaya_total.toLocaleString(
-                                "id-ID",
-                              )}
-                            </td>
-                          </tr>
-                        </tfoot>
-                      </table>
+                    <div>
+                      <p className="text-gray-500">Tanggal Masuk</p>
+                      <p className="font-semibold">
+                        {new Date(result.tanggal_masuk).toLocaleDateString(
+                          "id-ID",
+                        )}
+                      </p>
+                    </div>
+                    <div>
+                      <p className="text-gray-500">Estimasi Biaya</p>
+                      <p className="font-bold text-purple-600">
+                        Rp {result.estimasi_biaya.toLocaleString("id-ID")}
+                      </p>
                     </div>
                   </div>
+                </div>
 
-                  {selectedService.status_servis === "siap_diambil" && (
-                    <div className="bg-blue-50 border-l-4 border-blue-400 p-4 rounded flex items-start gap-3">
-                      <img
-                        src="/images/icons/ready.png"
-                        alt="Ready"
-                        className="w-6 h-6 mt-1"
-                      />
-                      <div>
-                        <p className="font-semibold text-blue-800">
-                          HP Anda sudah siap diambil!
-                        </p>
-                        <p className="text-sm text-blue-700 mt-1">
-                          Silakan datang ke toko untuk mengambil HP Anda
-                        </p>
-                      </div>
+                {/* Timeline */}
+                <h4 className="font-bold text-gray-700 mb-3">Riwayat Status</h4>
+                <ol className="border-l-2 border-purple-200 ml-2 space-y-4">
+                  {result.timeline.map((t, idx) => (
+                    <li key={idx} className="ml-4">
+                      <p className="font-semibold text-gray-800">
+                        {getStatusInfo(t.status).label}
+                      </p>
+                      <p className="text-xs text-gray-500">
+                        {new Date(t.waktu).toLocaleString("id-ID")}
+                      </p>
+                    </li>
+                  ))}
+                </ol>
+
+                {result.status_servis === "siap_diambil" && (
+                  <div className="mt-6 bg-blue-50 border-l-4 border-blue-400 p-4 rounded flex items-start gap-3">
+                    <img
+                      src="/images/icons/ready.png"
+                      alt="Ready"
+                      className="w-6 h-6 mt-1"
+                    />
+                    <div>
+                      <p className="font-semibold text-blue-800">
+                        HP Anda sudah siap diambil!
+                      </p>
+                      <p className="text-sm text-blue-700 mt-1">
+                        Silakan datang ke toko untuk mengambil HP Anda
+                      </p>
                     </div>
-                  )}
-                </div>
+                  </div>
+                )}
               </div>
             )}
           </div>
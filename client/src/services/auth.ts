@@ -0,0 +1,104 @@
+// Sesi login: access token berumur pendek (15 menit) diperbarui otomatis
+// lewat /api/refresh memakai refresh token, tanpa user harus login ulang.
+
+const API_URL = "http://localhost:8080"
+
+// fetch asli browser, dipakai interceptor dan refresh agar tidak memanggil dirinya sendiri
+const originalFetch = window.fetch.bind(window)
+
+// Endpoint auth tidak ikut di-refresh agar tidak berputar saat refresh token ditolak
+const TANPA_REFRESH = ["/api/login", "/api/refresh", "/api/logout"]
+
+interface TokenResponse {
+  token: string
+  refresh_token: string
+}
+
+export function saveTokens(data: TokenResponse) {
+  localStorage.setItem("token", data.token)
+  localStorage.setItem("refresh_token", data.refresh_token)
+}
+
+export function clearSession() {
+  localStorage.removeItem("token")
+  localStorage.removeItem("refresh_token")
+  localStorage.removeItem("role")
+  localStorage.removeItem("user")
+}
+
+// Logout: cabut refresh token di server lalu hapus sesi lokal
+export async function logout() {
+  const refreshToken = localStorage.getItem("refresh_token")
+  if (refreshToken) {
+    await fetch(`${API_URL}/api/logout`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ refresh_token: refreshToken }),
+    }).catch(() => null)
+  }
+  clearSession()
+}
+
+// Satu refresh untuk semua request yang gagal bersamaan. Refresh token hanya
+// sekali pakai, refresh paralel akan dianggap pemakaian ulang dan mencabut sesi.
+let refreshing: Promise<string | null> | null = null
+
+export function refreshAccessToken(): Promise<string | null> {
+  if (!refreshing) {
+    refreshing = doRefresh().finally(() => {
+      refreshing = null
+    })
+  }
+  return refreshing
+}
+
+async function doRefresh(): Promise<string | null> {
+  const refreshToken = localStorage.getItem("refresh_token")
+  if (!refreshToken) return null
+
+  try {
+    const res = await originalFetch(`${API_URL}/api/refresh`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ refresh_token: refreshToken }),
+    })
+    if (!res.ok) return null
+
+    const data: TokenResponse = await res.json()
+    saveTokens(data)
+    return data.token
+  } catch {
+    return null
+  }
+}
+
+// Interceptor fetch: request API ber-Authorization yang dibalas 401 di-refresh
+// sekali lalu diulang dengan token baru. Jika refresh gagal, sesi dihapus dan
+// user diarahkan ke halaman login.
+export function installAuthInterceptor() {
+  window.fetch = async (input: RequestInfo | URL, init?: RequestInit) => {
+    const req = new Request(input, init)
+    const path = req.url.startsWith(API_URL) ? req.url.slice(API_URL.length) : ""
+    const perluRefresh =
+      path.startsWith("/api/") &&
+      req.headers.has("Authorization") &&
+      !TANPA_REFRESH.some((p) => path.startsWith(p))
+
+    if (!perluRefresh) return originalFetch(req)
+
+    const cadangan = req.clone()
+    const res = await originalFetch(req)
+    if (res.status !== 401) return res
+
+    const token = await refreshAccessToken()
+    if (!token) {
+      clearSession()
+      window.location.assign("/login")
+      return res
+    }
+
+    const headers = new Headers(cadangan.headers)
+    headers.set("Authorization", `Bearer ${token}`)
+    return originalFetch(new Request(cadangan, { headers }))
+  }
+}